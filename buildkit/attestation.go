@@ -0,0 +1,66 @@
+package buildkit
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/frontend/gateway/client"
+	gatewaypb "github.com/moby/buildkit/frontend/gateway/pb"
+	"github.com/moby/buildkit/solver/result"
+)
+
+// Attestation is an in-toto attestation attached to the image (e.g. an SBOM)
+type Attestation struct {
+	// The in-toto predicate type (e.g. https://spdx.dev/Document)
+	PredicateType string
+
+	// File name of the predicate (e.g. sbom.spdx.json)
+	Name string
+
+	// Why the attestation was added (e.g. sbom or provenance)
+	Reason string
+
+	Predicate []byte
+}
+
+// addAttestations writes each attestation predicate to a file and attaches it to the result for every platform
+func addAttestations(ctx context.Context, c client.Client, res *client.Result, ids []string, attestations []Attestation) error {
+	for _, attestation := range attestations {
+		filePath := path.Join("/", attestation.Name)
+
+		def, err := llb.Scratch().File(llb.Mkfile(filePath, 0644, attestation.Predicate)).Marshal(ctx)
+		if err != nil {
+			return fmt.Errorf("error marshalling %s attestation: %w", attestation.Name, err)
+		}
+
+		attRes, err := c.Solve(ctx, client.SolveRequest{
+			Definition: def.ToPB(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to solve %s attestation: %w", attestation.Name, err)
+		}
+
+		ref, err := attRes.SingleRef()
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			res.AddAttestation(id, result.Attestation[client.Reference]{
+				Kind: gatewaypb.AttestationKind_InToto,
+				Metadata: map[string][]byte{
+					result.AttestationReasonKey: []byte(attestation.Reason),
+				},
+				Ref:  ref,
+				Path: filePath,
+				InToto: result.InTotoAttestation{
+					PredicateType: attestation.PredicateType,
+				},
+			})
+		}
+	}
+
+	return nil
+}
@@ -1,22 +1,23 @@
 package buildkit
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/containerd/platforms"
+	"github.com/docker/cli/cli/config"
 	"github.com/moby/buildkit/client"
 	_ "github.com/moby/buildkit/client/connhelper/dockercontainer"
 	_ "github.com/moby/buildkit/client/connhelper/nerdctlcontainer"
 	"github.com/moby/buildkit/client/llb"
+	gwclient "github.com/moby/buildkit/frontend/gateway/client"
 	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/auth/authprovider"
 	"github.com/moby/buildkit/session/secrets/secretsprovider"
 	"github.com/moby/buildkit/util/appcontext"
 	_ "github.com/moby/buildkit/util/grpcutil/encoding/proto"
@@ -46,15 +47,21 @@ Use 'railpack --verbose' to view more error details.
 type BuildWithBuildkitClientOptions struct {
 	ImageName    string
 	DumpLLB      bool
-	OutputDir    string
+	Output       string
+	Push         bool
 	ProgressMode string
 	SecretsHash  string
 	Secrets      map[string]string
 	Platform     string
-	ImportCache  string
-	ExportCache  string
+	CacheFrom    []string
+	CacheTo      []string
 	CacheKey     string
 	GitHubToken  string
+	Attestations []Attestation
+	Labels       map[string]string
+
+	// Builds a reproducible image with the epoch as the timestamp of the image and its files
+	SourceDateEpoch *time.Time
 }
 
 func BuildWithBuildkitClient(appDir string, plan *plan.BuildPlan, opts BuildWithBuildkitClientOptions) error {
@@ -86,62 +93,72 @@ func BuildWithBuildkitClient(appDir string, plan *plan.BuildPlan, opts BuildWith
 		return errors.New(buildkitInfoError)
 	}
 
-	// Parse the platform string using our helper function
-	buildPlatform, err := ParsePlatformWithDefaults(opts.Platform)
+	// Parse the comma separated platforms using our helper function
+	buildPlatforms, err := ParsePlatforms(opts.Platform)
 	if err != nil {
 		return fmt.Errorf("failed to parse platform '%s': %w", opts.Platform, err)
 	}
 
-	llbState, image, err := ConvertPlanToLLB(plan, ConvertPlanOptions{
-		BuildPlatform: buildPlatform,
-		SecretsHash:   opts.SecretsHash,
-		CacheKey:      opts.CacheKey,
-		GitHubToken:   opts.GitHubToken,
-	})
+	output, err := ParseOutput(opts.Output, opts.Push)
 	if err != nil {
-		return fmt.Errorf("error converting plan to LLB: %w", err)
+		return err
+	}
+
+	if err := output.checkPlatforms(len(buildPlatforms)); err != nil {
+		return err
+	}
+
+	if err := output.checkAttestations(len(opts.Attestations)); err != nil {
+		return err
 	}
 
-	imageBytes, err := json.Marshal(image)
+	cacheImports, err := ParseCacheImports(opts.CacheFrom)
 	if err != nil {
-		return fmt.Errorf("error marshalling image: %w", err)
+		return err
 	}
 
-	def, err := llbState.Marshal(ctx, llb.LinuxAmd64)
+	cacheExports, err := ParseCacheExports(opts.CacheTo)
 	if err != nil {
-		return fmt.Errorf("error marshaling LLB state: %w", err)
+		return err
+	}
+
+	convertOpts := ConvertPlanOptions{
+		SecretsHash:     opts.SecretsHash,
+		CacheKey:        opts.CacheKey,
+		GitHubToken:     opts.GitHubToken,
+		Labels:          opts.Labels,
+		SourceDateEpoch: opts.SourceDateEpoch,
 	}
 
 	if opts.DumpLLB {
-		err = llb.WriteTo(def, os.Stdout)
-		if err != nil {
-			return fmt.Errorf("error writing LLB definition: %w", err)
+		for _, platform := range buildPlatforms {
+			def, _, err := marshalPlan(ctx, plan, platform, convertOpts)
+			if err != nil {
+				return err
+			}
+
+			err = llb.WriteTo(def, os.Stdout)
+			if err != nil {
+				return fmt.Errorf("error writing LLB definition: %w", err)
+			}
 		}
 		return nil
 	}
 
-	ch := make(chan *client.SolveStatus)
-
-	var pipeR *io.PipeReader
-	var pipeW *io.PipeWriter
-	errCh := make(chan error, 1)
+	export, err := newExport(output, imageName)
+	if err != nil {
+		return err
+	}
 
-	// Only set up pipe and docker load if we're not saving to a directory
-	if opts.OutputDir == "" {
-		// Create a pipe to connect buildkit output to docker load
-		pipeR, pipeW = io.Pipe()
-		defer pipeR.Close()
+	// An image index is exported for multiple platforms and for attestations
+	export.addAnnotations(opts.Labels, len(buildPlatforms) > 1 || len(opts.Attestations) > 0)
 
-		// Pipe the image into `docker load`
-		go func() {
-			cmd := exec.Command("docker", "load")
-			cmd.Stdin = pipeR
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			errCh <- cmd.Run()
-		}()
+	if opts.SourceDateEpoch != nil {
+		export.setSourceDateEpoch(*opts.SourceDateEpoch)
 	}
 
+	ch := make(chan *client.SolveStatus)
+
 	progressDone := make(chan bool)
 	go func() {
 		displayCh := make(chan *client.SolveStatus)
@@ -176,7 +193,11 @@ func BuildWithBuildkitClient(appDir string, plan *plan.BuildPlan, opts BuildWith
 		return fmt.Errorf("error creating FS: %w", err)
 	}
 
-	log.Debugf("Building image for %s with BuildKit %s", platforms.Format(buildPlatform), info.BuildkitVersion.Version)
+	platformNames := make([]string, len(buildPlatforms))
+	for i, platform := range buildPlatforms {
+		platformNames[i] = platforms.Format(platform)
+	}
+	log.Debugf("Building image for %s with BuildKit %s", strings.Join(platformNames, ", "), info.BuildkitVersion.Version)
 
 	secretsMap := make(map[string][]byte)
 	for k, v := range opts.Secrets {
@@ -184,90 +205,42 @@ func BuildWithBuildkitClient(appDir string, plan *plan.BuildPlan, opts BuildWith
 	}
 	secrets := secretsprovider.FromMap(secretsMap)
 
+	// Registry credentials are read from the docker config (e.g. ~/.docker/config.json)
+	dockerAuth := authprovider.NewDockerAuthProvider(config.LoadDefaultConfigFile(os.Stderr), nil)
+
 	solveOpts := client.SolveOpt{
 		LocalMounts: map[string]fsutil.FS{
 			"context": appFS,
 		},
-		Session: []session.Attachable{secrets},
-		Exports: []client.ExportEntry{
-			{
-				Type: client.ExporterDocker,
-				Attrs: map[string]string{
-					"name":                  imageName,
-					"containerimage.config": string(imageBytes),
-				},
-				Output: func(_ map[string]string) (io.WriteCloser, error) {
-					return pipeW, nil
-				},
-			},
-		},
-	}
-
-	// Add cache import if specified
-	if opts.ImportCache != "" {
-		solveOpts.CacheImports = append(solveOpts.CacheImports, client.CacheOptionsEntry{
-			Type:  "gha",
-			Attrs: parseKeyValue(opts.ImportCache),
-		})
-	}
-
-	// Add cache export if specified
-	if opts.ExportCache != "" {
-		solveOpts.CacheExports = append(solveOpts.CacheExports, client.CacheOptionsEntry{
-			Type:  "gha",
-			Attrs: parseKeyValue(opts.ExportCache),
-		})
-	}
-
-	// Save the resulting filesystem to a directory
-	if opts.OutputDir != "" {
-		err = os.MkdirAll(opts.OutputDir, 0755)
-		if err != nil {
-			return fmt.Errorf("error creating output directory: %w", err)
-		}
-
-		solveOpts = client.SolveOpt{
-			LocalMounts: map[string]fsutil.FS{
-				"context": appFS,
-			},
-			Exports: []client.ExportEntry{
-				{
-					Type:      client.ExporterLocal,
-					OutputDir: opts.OutputDir,
-				},
-			},
-		}
+		Session:      []session.Attachable{secrets, dockerAuth},
+		Exports:      []client.ExportEntry{export.entry},
+		CacheImports: cacheImports,
+		CacheExports: cacheExports,
 	}
 
 	startTime := time.Now()
-	_, err = c.Solve(ctx, def, solveOpts, ch)
+	_, err = c.Build(ctx, solveOpts, "railpack", func(ctx context.Context, gw gwclient.Client) (*gwclient.Result, error) {
+		buildOpts := convertOpts
+		buildOpts.SessionID = gw.BuildOpts().SessionID
+		return solvePlan(ctx, gw, plan, buildPlatforms, opts.Attestations, buildOpts)
+	}, ch)
 
 	// Wait for progress monitoring to complete
 	<-progressDone
 
-	if pipeW != nil {
-		pipeW.Close()
-	}
+	waitErr := export.wait()
 
 	if err != nil {
 		return fmt.Errorf("failed to solve: %w", err)
 	}
 
-	// Only wait for docker load if we used it
-	if opts.OutputDir == "" {
-		if err := <-errCh; err != nil {
-			return fmt.Errorf("docker load failed: %w", err)
-		}
+	if waitErr != nil {
+		return waitErr
 	}
 
 	buildDuration := time.Since(startTime)
 	log.Infof("Successfully built image in %.2fs", buildDuration.Seconds())
-
-	if opts.OutputDir != "" {
-		log.Infof("Saved image filesystem to directory `%s`", opts.OutputDir)
-	} else {
-		log.Infof("Run with `docker run -it %s`", imageName)
-	}
+	log.Info(export.message)
 
 	return nil
 }
@@ -281,16 +254,3 @@ func getImageName(appDir string) string {
 	// Docker requires image names to be lowercase
 	return strings.ToLower(name)
 }
-
-// Helper function to parse key=value strings into a map
-func parseKeyValue(s string) map[string]string {
-	attrs := make(map[string]string)
-	parts := strings.Split(s, ",")
-	for _, part := range parts {
-		kv := strings.SplitN(part, "=", 2)
-		if len(kv) == 2 {
-			attrs[kv[0]] = kv[1]
-		}
-	}
-	return attrs
-}
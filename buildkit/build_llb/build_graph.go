@@ -8,7 +8,9 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/moby/buildkit/client/llb"
 	"github.com/moby/buildkit/util/system"
@@ -18,7 +20,10 @@ import (
 	"github.com/railwayapp/railpack/core/plan"
 )
 
-const githubTokenEnvVar = "GITHUB_TOKEN"
+const (
+	githubTokenEnvVar     = "GITHUB_TOKEN"
+	sourceDateEpochEnvVar = "SOURCE_DATE_EPOCH"
+)
 
 type BuildGraph struct {
 	graph      *graph.Graph
@@ -27,6 +32,10 @@ type BuildGraph struct {
 	Platform   *specs.Platform
 	LocalState *llb.State
 
+	// Timestamp of files created by copy and file operations, which is also passed to exec commands as SOURCE_DATE_EPOCH.
+	// Used for reproducible builds
+	SourceDateEpoch *time.Time
+
 	githubToken     string
 	secretsFile     *llb.State
 	usedSecretsBase *llb.State
@@ -285,6 +294,10 @@ func (g *BuildGraph) convertExecCommandToLLB(node *StepNode, cmd plan.ExecComman
 		opts = append(opts, githubTokenOpts...)
 	}
 
+	if g.SourceDateEpoch != nil {
+		opts = append(opts, llb.AddEnv(sourceDateEpochEnvVar, strconv.FormatInt(g.SourceDateEpoch.Unix(), 10)))
+	}
+
 	s := state.Run(opts...).Root()
 
 	return s, nil
@@ -320,6 +333,7 @@ func (g *BuildGraph) convertCopyCommandToLLB(cmd plan.CopyCommand, state llb.Sta
 		CopyDirContentsOnly: false,
 		AllowWildcard:       true,
 		AllowEmptyWildcard:  true,
+		CreatedTime:         g.SourceDateEpoch,
 	}), opts...)
 
 	return s, nil
@@ -335,7 +349,11 @@ func (g *BuildGraph) convertFileCommandToLLB(cmd plan.FileCommand, state llb.Sta
 	// Create parent directories for the file
 	parentDir := filepath.Dir(cmd.Path)
 	if parentDir != "/" {
-		s := state.File(llb.Mkdir(parentDir, 0755, llb.WithParents(true)))
+		mkdirOpts := []llb.MkdirOption{llb.WithParents(true)}
+		if g.SourceDateEpoch != nil {
+			mkdirOpts = append(mkdirOpts, llb.WithCreatedTime(*g.SourceDateEpoch))
+		}
+		s := state.File(llb.Mkdir(parentDir, 0755, mkdirOpts...))
 		state = s
 	}
 
@@ -344,7 +362,12 @@ func (g *BuildGraph) convertFileCommandToLLB(cmd plan.FileCommand, state llb.Sta
 		mode = cmd.Mode
 	}
 
-	fileAction := llb.Mkfile(cmd.Path, mode, []byte(asset))
+	mkfileOpts := []llb.MkfileOption{}
+	if g.SourceDateEpoch != nil {
+		mkfileOpts = append(mkfileOpts, llb.WithCreatedTime(*g.SourceDateEpoch))
+	}
+
+	fileAction := llb.Mkfile(cmd.Path, mode, []byte(asset), mkfileOpts...)
 	s := state.File(fileAction)
 	if cmd.CustomName != "" {
 		s = state.File(fileAction, llb.WithCustomName(cmd.CustomName))
@@ -71,7 +71,7 @@ func (g *BuildGraph) getCopyState(layers []plan.Layer) llb.State {
 
 	for _, input := range layers[1:] {
 		inputState := g.GetStateForLayer(input)
-		state = copyLayerPaths(state, inputState, input.Filter, input.Local)
+		state = g.copyLayerPaths(state, inputState, input.Filter, input.Local)
 	}
 	return state
 }
@@ -85,7 +85,7 @@ func (g *BuildGraph) getMergeState(layers []plan.Layer) llb.State {
 			log.Warnf("input %s has no include or exclude paths. This is probably a mistake.", input.Step)
 		}
 		inputState := g.GetStateForLayer(input)
-		destState := copyLayerPaths(llb.Scratch(), inputState, input.Filter, input.Local)
+		destState := g.copyLayerPaths(llb.Scratch(), inputState, input.Filter, input.Local)
 		mergeStates = append(mergeStates, destState)
 		mergeNames = append(mergeNames, input.DisplayName())
 	}
@@ -96,7 +96,8 @@ func (g *BuildGraph) getMergeState(layers []plan.Layer) llb.State {
 // copyLayerPaths copies paths from srcState to destState, applying the given filter.
 // If isLocal is true, files are copied from local filesystem into /app directory.
 // Otherwise paths are copied directly between container locations.
-func copyLayerPaths(destState, srcState llb.State, filter plan.Filter, isLocal bool) llb.State {
+// Copied files are timestamped with the source date epoch, if set.
+func (g *BuildGraph) copyLayerPaths(destState, srcState llb.State, filter plan.Filter, isLocal bool) llb.State {
 	for _, include := range filter.Include {
 		srcPath, destPath := resolvePaths(include, isLocal)
 
@@ -112,6 +113,7 @@ func copyLayerPaths(destState, srcState llb.State, filter plan.Filter, isLocal b
 			AllowWildcard:       true,
 			AllowEmptyWildcard:  true,
 			ExcludePatterns:     filter.Exclude,
+			CreatedTime:         g.SourceDateEpoch,
 		}), opts...)
 	}
 	return destState
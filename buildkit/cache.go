@@ -0,0 +1,143 @@
+package buildkit
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/moby/buildkit/client"
+)
+
+// Cache backends supported by BuildKit
+var cacheTypes = []string{"local", "registry", "inline", "gha", "s3", "azblob"}
+
+// ParseCacheImports parses --cache-from specs (e.g. "type=local,src=/tmp/cache" or "type=registry,ref=ghcr.io/org/app:cache")
+func ParseCacheImports(specs []string) ([]client.CacheOptionsEntry, error) {
+	return parseCacheSpecs(specs, false)
+}
+
+// ParseCacheExports parses --cache-to specs (e.g. "type=local,dest=/tmp/cache,mode=max" or "type=inline")
+func ParseCacheExports(specs []string) ([]client.CacheOptionsEntry, error) {
+	return parseCacheSpecs(specs, true)
+}
+
+func parseCacheSpecs(specs []string, export bool) ([]client.CacheOptionsEntry, error) {
+	entries := []client.CacheOptionsEntry{}
+
+	for _, spec := range specs {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+
+		entry, err := parseCacheSpec(spec, export)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache spec %q: %w", spec, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func parseCacheSpec(spec string, export bool) (client.CacheOptionsEntry, error) {
+	entry := client.CacheOptionsEntry{Attrs: map[string]string{}}
+
+	// A spec without a type is a registry reference, the same as buildctl
+	if !strings.Contains(spec, "=") {
+		entry.Type = "registry"
+		entry.Attrs["ref"] = spec
+	} else {
+		attrs, err := parseSpec(spec)
+		if err != nil {
+			return entry, err
+		}
+
+		entry.Type = attrs["type"]
+		delete(attrs, "type")
+		entry.Attrs = attrs
+	}
+
+	if entry.Type == "" {
+		return entry, fmt.Errorf("type is required. Must be one of: %s", strings.Join(cacheTypes, ", "))
+	}
+
+	if !slices.Contains(cacheTypes, entry.Type) {
+		return entry, fmt.Errorf("unsupported cache type %q. Must be one of: %s", entry.Type, strings.Join(cacheTypes, ", "))
+	}
+
+	if err := validateCacheAttrs(entry, export); err != nil {
+		return entry, err
+	}
+
+	if export {
+		if _, ok := entry.Attrs["mode"]; !ok && entry.Type != "inline" {
+			entry.Attrs["mode"] = "min"
+		}
+	}
+
+	// The GitHub Actions runtime provides the cache service URL and token
+	if entry.Type == "gha" {
+		if _, ok := entry.Attrs["url"]; !ok {
+			if url := os.Getenv("ACTIONS_CACHE_URL"); url != "" {
+				entry.Attrs["url"] = url
+			}
+		}
+		if _, ok := entry.Attrs["token"]; !ok {
+			if token := os.Getenv("ACTIONS_RUNTIME_TOKEN"); token != "" {
+				entry.Attrs["token"] = token
+			}
+		}
+	}
+
+	return entry, nil
+}
+
+// parseSpec parses comma separated key=value pairs as used by BuildKit specs. Values can be quoted to include commas
+func parseSpec(spec string) (map[string]string, error) {
+	fields, err := csv.NewReader(strings.NewReader(spec)).Read()
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := map[string]string{}
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected key=value, got %q", field)
+		}
+		attrs[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+
+	return attrs, nil
+}
+
+func validateCacheAttrs(entry client.CacheOptionsEntry, export bool) error {
+	required := []string{}
+
+	switch entry.Type {
+	case "local":
+		if export {
+			required = append(required, "dest")
+		} else {
+			required = append(required, "src")
+		}
+	case "registry":
+		required = append(required, "ref")
+	case "s3":
+		required = append(required, "bucket")
+	case "inline":
+		if !export {
+			return fmt.Errorf("inline cache is imported from the image with type=registry,ref=<image>")
+		}
+	}
+
+	for _, attr := range required {
+		if entry.Attrs[attr] == "" {
+			return fmt.Errorf("%s cache requires %s", entry.Type, attr)
+		}
+	}
+
+	return nil
+}
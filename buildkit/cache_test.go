@@ -0,0 +1,106 @@
+package buildkit
+
+import (
+	"testing"
+
+	"github.com/moby/buildkit/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCacheImports(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    client.CacheOptionsEntry
+		wantErr string
+	}{
+		{
+			name: "local",
+			spec: "type=local,src=/tmp/cache",
+			want: client.CacheOptionsEntry{Type: "local", Attrs: map[string]string{"src": "/tmp/cache"}},
+		},
+		{
+			name: "registry",
+			spec: "type=registry,ref=ghcr.io/org/app:cache",
+			want: client.CacheOptionsEntry{Type: "registry", Attrs: map[string]string{"ref": "ghcr.io/org/app:cache"}},
+		},
+		{
+			name: "registry without type",
+			spec: "ghcr.io/org/app:cache",
+			want: client.CacheOptionsEntry{Type: "registry", Attrs: map[string]string{"ref": "ghcr.io/org/app:cache"}},
+		},
+		{
+			name: "s3 with quoted value",
+			spec: `type=s3,bucket=cache,region=us-east-1,"name=app,worker"`,
+			want: client.CacheOptionsEntry{Type: "s3", Attrs: map[string]string{"bucket": "cache", "region": "us-east-1", "name": "app,worker"}},
+		},
+		{
+			name:    "missing type",
+			spec:    "src=/tmp/cache",
+			wantErr: "type is required",
+		},
+		{
+			name:    "unsupported type",
+			spec:    "type=ftp,src=/tmp/cache",
+			wantErr: `unsupported cache type "ftp"`,
+		},
+		{
+			name:    "local without src",
+			spec:    "type=local,dest=/tmp/cache",
+			wantErr: "local cache requires src",
+		},
+		{
+			name:    "inline import",
+			spec:    "type=inline",
+			wantErr: "inline cache is imported from the image",
+		},
+		{
+			name:    "invalid field",
+			spec:    "type=local,src",
+			wantErr: "expected key=value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCacheImports([]string{tt.spec})
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []client.CacheOptionsEntry{tt.want}, got)
+		})
+	}
+}
+
+func TestParseCacheExports(t *testing.T) {
+	got, err := ParseCacheExports([]string{
+		"type=local,dest=/tmp/cache,mode=max",
+		"type=inline",
+		"type=registry,ref=ghcr.io/org/app:cache",
+		"",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []client.CacheOptionsEntry{
+		{Type: "local", Attrs: map[string]string{"dest": "/tmp/cache", "mode": "max"}},
+		{Type: "inline", Attrs: map[string]string{}},
+		{Type: "registry", Attrs: map[string]string{"ref": "ghcr.io/org/app:cache", "mode": "min"}},
+	}, got)
+
+	_, err = ParseCacheExports([]string{"type=local,src=/tmp/cache"})
+	require.ErrorContains(t, err, "local cache requires dest")
+}
+
+func TestParseCacheGitHubActions(t *testing.T) {
+	t.Setenv("ACTIONS_CACHE_URL", "https://cache.example.com")
+	t.Setenv("ACTIONS_RUNTIME_TOKEN", "token")
+
+	got, err := ParseCacheImports([]string{"type=gha,scope=app"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"scope": "app", "url": "https://cache.example.com", "token": "token"}, got[0].Attrs)
+
+	got, err = ParseCacheImports([]string{"type=gha,url=https://other.example.com,token=other"})
+	require.NoError(t, err)
+	require.Equal(t, "https://other.example.com", got[0].Attrs["url"])
+}
@@ -6,6 +6,7 @@ import (
 	"maps"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/moby/buildkit/client/llb"
 	"github.com/moby/buildkit/util/system"
@@ -28,6 +29,12 @@ type ConvertPlanOptions struct {
 
 	// Token used to make authenticated API requests to GitHub to increase rate limits
 	GitHubToken string
+
+	// Labels added to the image config (e.g. org.opencontainers.image.source)
+	Labels map[string]string
+
+	// Timestamp used for files, exec commands, and the image creation time of reproducible builds
+	SourceDateEpoch *time.Time
 }
 
 const (
@@ -49,6 +56,7 @@ func ConvertPlanToLLB(plan *p.BuildPlan, opts ConvertPlanOptions) (*llb.State, *
 	if err != nil {
 		return nil, nil, err
 	}
+	graph.SourceDateEpoch = opts.SourceDateEpoch
 
 	graphOutput, err := graph.GenerateLLB()
 	if err != nil {
@@ -65,6 +73,7 @@ func ConvertPlanToLLB(plan *p.BuildPlan, opts ConvertPlanOptions) (*llb.State, *
 
 	image := Image{
 		Image: specs.Image{
+			Created: opts.SourceDateEpoch,
 			Platform: specs.Platform{
 				OS:           platform.OS,
 				Architecture: platform.Architecture,
@@ -79,6 +88,7 @@ func ConvertPlanToLLB(plan *p.BuildPlan, opts ConvertPlanOptions) (*llb.State, *
 			WorkingDir: WorkingDir,
 			Entrypoint: []string{"/bin/bash", "-c"},
 			Cmd:        []string{startCommand},
+			Labels:     opts.Labels,
 		},
 	}
 
@@ -0,0 +1,69 @@
+package buildkit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/moby/buildkit/solver/pb"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/railwayapp/railpack/core/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertPlanSourceDateEpoch(t *testing.T) {
+	buildPlan := plan.NewBuildPlan()
+
+	step := plan.NewStep("build")
+	step.Inputs = []plan.Layer{plan.NewImageLayer("debian:bookworm-slim"), plan.NewLocalLayer()}
+	step.Commands = []plan.Command{
+		plan.NewFileCommand("/app/config.json", "config"),
+		plan.NewExecCommand("make build"),
+	}
+	step.Assets = map[string]string{"config": "{}"}
+	buildPlan.AddStep(*step)
+
+	buildPlan.Deploy.Base = plan.NewImageLayer("debian:bookworm-slim")
+	buildPlan.Deploy.Inputs = []plan.Layer{plan.NewStepLayer("build", plan.NewIncludeFilter([]string{"."}))}
+
+	epoch := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	def, imageBytes, err := marshalPlan(context.Background(), buildPlan, specs.Platform{OS: "linux", Architecture: "amd64"}, ConvertPlanOptions{
+		SourceDateEpoch: &epoch,
+	})
+	require.NoError(t, err)
+
+	var image Image
+	require.NoError(t, json.Unmarshal(imageBytes, &image))
+	require.NotNil(t, image.Created)
+	require.True(t, epoch.Equal(*image.Created))
+
+	execs, fileActions := 0, 0
+	for _, dt := range def.Def {
+		var op pb.Op
+		require.NoError(t, op.UnmarshalVT(dt))
+
+		if exec := op.GetExec(); exec != nil {
+			execs++
+			require.Contains(t, exec.Meta.Env, "SOURCE_DATE_EPOCH=1735787045")
+		}
+
+		for _, action := range op.GetFile().GetActions() {
+			switch {
+			case action.GetCopy() != nil:
+				fileActions++
+				require.Equal(t, epoch.UnixNano(), action.GetCopy().Timestamp)
+			case action.GetMkfile() != nil:
+				fileActions++
+				require.Equal(t, epoch.UnixNano(), action.GetMkfile().Timestamp)
+			case action.GetMkdir() != nil:
+				fileActions++
+				require.Equal(t, epoch.UnixNano(), action.GetMkdir().Timestamp)
+			}
+		}
+	}
+
+	require.Equal(t, 1, execs)
+	// Copying the app into the step, creating the config file and its directory, and copying the app into the image
+	require.Equal(t, 4, fileActions)
+}
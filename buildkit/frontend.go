@@ -6,16 +6,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/moby/buildkit/client/llb"
-	"github.com/moby/buildkit/exporter/containerimage/exptypes"
 	"github.com/moby/buildkit/frontend/gateway/client"
 	gw "github.com/moby/buildkit/frontend/gateway/grpcclient"
 	"github.com/moby/buildkit/util/appcontext"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
+	"github.com/railwayapp/railpack/core"
+	"github.com/railwayapp/railpack/core/app"
+	"github.com/railwayapp/railpack/core/logger"
 	"github.com/railwayapp/railpack/core/plan"
 )
 
@@ -24,26 +29,43 @@ const (
 	// This is "dockerfile" because that is commonly used for the config file mount
 	configMountName = "dockerfile"
 
+	// The local mount of the app to build
+	contextMountName = "context"
+
 	// default filename for the serialized Railpack plan
 	defaultRailpackPlan = "railpack-plan.json"
 
+	// The filename docker buildx uses when -f is not passed
+	defaultDockerfile = "Dockerfile"
+
 	// Build arg keys
 	secretsHash = "secrets-hash"
 	cacheKey    = "cache-key"
 	githubToken = "github-token"
+
+	// Comma separated names of the secrets passed with --secret. Only used when the frontend generates the plan
+	secretNames = "secrets"
+
+	// Standard build arg for reproducible builds (https://reproducible-builds.org/docs/source-date-epoch/)
+	sourceDateEpoch = "SOURCE_DATE_EPOCH"
 )
 
-func StartFrontend() {
+func StartFrontend(railpackVersion string) {
 	log.Info("Starting frontend")
 
 	ctx := appcontext.Context()
-	if err := gw.RunFromEnvironment(ctx, Build); err != nil {
+	err := gw.RunFromEnvironment(ctx, func(ctx context.Context, c client.Client) (*client.Result, error) {
+		return Build(ctx, c, railpackVersion)
+	})
+	if err != nil {
 		log.Error("error: %+v\n", err)
 		os.Exit(1)
 	}
 }
 
-func Build(ctx context.Context, c client.Client) (*client.Result, error) {
+// Build builds the railpack plan passed to the frontend.
+// The plan is generated from the build context if no plan file is passed
+func Build(ctx context.Context, c client.Client, railpackVersion string) (*client.Result, error) {
 	opts := c.BuildOpts().Opts
 	buildArgs := parseBuildArgs(opts)
 
@@ -51,63 +73,50 @@ func Build(ctx context.Context, c client.Client) (*client.Result, error) {
 	secretsHash := buildArgs[secretsHash]
 	githubToken := buildArgs[githubToken]
 
-	// TODO: Support building for multiple platforms
-	buildPlatform, err := validatePlatform(opts)
+	buildPlatforms, err := validatePlatforms(opts)
 	if err != nil {
 		return nil, err
 	}
 
-	plan, err := readRailpackPlan(ctx, c)
+	epoch, err := parseSourceDateEpoch(buildArgs[sourceDateEpoch])
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = json.MarshalIndent(plan, "", "  ")
-	if err != nil {
-		return nil, fmt.Errorf("error marshalling plan: %w", err)
-	}
-
-	llbState, image, err := ConvertPlanToLLB(plan, ConvertPlanOptions{
-		BuildPlatform: buildPlatform,
-		SecretsHash:   secretsHash,
-		CacheKey:      cacheKey,
-		SessionID:     c.BuildOpts().SessionID,
-		GitHubToken:   githubToken,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("error converting plan to LLB: %w", err)
-	}
-
-	def, err := llbState.Marshal(ctx)
+	plan, err := readRailpackPlan(ctx, c)
 	if err != nil {
-		return nil, fmt.Errorf("error marshalling LLB state: %w", err)
+		return nil, err
 	}
 
-	imageBytes, err := json.Marshal(image)
-	if err != nil {
-		return nil, fmt.Errorf("error marshalling image: %w", err)
+	if plan == nil {
+		plan, err = generateRailpackPlan(ctx, c, buildArgs, railpackVersion)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	res, err := c.Solve(ctx, client.SolveRequest{
-		Definition: def.ToPB(),
+	return solvePlan(ctx, c, plan, buildPlatforms, nil, ConvertPlanOptions{
+		SecretsHash:     secretsHash,
+		CacheKey:        cacheKey,
+		SessionID:       c.BuildOpts().SessionID,
+		GitHubToken:     githubToken,
+		SourceDateEpoch: epoch,
 	})
-	if err != nil {
-		return nil, err
-	}
-
-	res.AddMeta(exptypes.ExporterImageConfigKey, imageBytes)
-
-	return res, nil
 }
 
+// readRailpackPlan reads the plan file passed to the frontend. Returns nil if there is no plan file
 func readRailpackPlan(ctx context.Context, c client.Client) (*plan.BuildPlan, error) {
 	opts := c.BuildOpts().Opts
 	filename := opts["filename"]
-	if filename == "" {
+	if filename == "" || filename == defaultDockerfile {
 		filename = defaultRailpackPlan
 	}
 
 	fileContents, err := readFile(ctx, c, filename)
+	if errors.Is(err, errFileNotFound) {
+		log.Infof("No %s found. Generating the plan from the build context", filename)
+		return nil, nil
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to read railpack plan")
 	}
@@ -121,22 +130,107 @@ func readRailpackPlan(ctx context.Context, c client.Client) (*plan.BuildPlan, er
 	return plan, nil
 }
 
-// validatePlatform checks if the platform is supported and returns the corresponding specs.Platform
-func validatePlatform(opts map[string]string) (specs.Platform, error) {
+// validatePlatforms checks if the comma separated platforms are supported and returns the corresponding specs.Platform for each
+func validatePlatforms(opts map[string]string) ([]specs.Platform, error) {
 	platformStr := opts["platform"]
 
-	// Error if multiple platforms are specified
-	if strings.Contains(platformStr, ",") {
-		return specs.Platform{}, fmt.Errorf("multiple platforms are not supported, got: %s", platformStr)
+	buildPlatforms, err := ParsePlatforms(platformStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid platform format: %s. Must be one or more of: linux/amd64, linux/arm64, etc", platformStr)
+	}
+
+	return buildPlatforms, nil
+}
+
+var errFileNotFound = errors.New("file not found")
+
+// generateRailpackPlan generates the plan from the build context like `railpack prepare`.
+// RAILPACK_* build args configure the plan like the environment variables do for the CLI
+func generateRailpackPlan(ctx context.Context, c client.Client, buildArgs map[string]string, railpackVersion string) (*plan.BuildPlan, error) {
+	src := llb.Local(contextMountName,
+		llb.SessionID(c.BuildOpts().SessionID),
+		llb.SharedKeyHint("railpack-plan-context"),
+		llb.ExcludePatterns(contextExcludes),
+		llb.WithCustomName("[railpack] load build context"),
+	)
+
+	srcDef, err := src.Marshal(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal build context")
+	}
+
+	res, err := c.Solve(ctx, client.SolveRequest{
+		Definition: srcDef.ToPB(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load build context")
 	}
 
-	// Parse the platform using our helper function
-	platform, err := ParsePlatformWithDefaults(platformStr)
+	ref, err := res.SingleRef()
 	if err != nil {
-		return specs.Platform{}, fmt.Errorf("invalid platform format: %s. Must be one of: linux/amd64, linux/arm64, etc", platformStr)
+		return nil, err
+	}
+
+	// The build context does not include the name of the app directory
+	a := app.NewAppFromFS(newContextFS(ctx, ref), "app")
+
+	env := frontendEnvironment(buildArgs)
+	buildResult := core.GenerateBuildPlan(ctx, a, env, &core.GenerateBuildPlanOptions{
+		RailpackVersion: railpackVersion,
+	})
+
+	for _, msg := range buildResult.Logs {
+		switch msg.Level {
+		case logger.Error:
+			log.Error(msg.Msg)
+		case logger.Warn:
+			log.Warn(msg.Msg)
+		default:
+			log.Info(msg.Msg)
+		}
+	}
+
+	if !buildResult.Success {
+		return nil, fmt.Errorf("failed to generate railpack plan: %s", buildResultErrors(buildResult))
+	}
+
+	// The build args that configure the plan are not secrets that are passed to the build
+	buildResult.Plan.Secrets = slices.DeleteFunc(buildResult.Plan.Secrets, func(secret string) bool {
+		_, ok := buildArgs[secret]
+		return ok
+	})
+
+	return buildResult.Plan, nil
+}
+
+// frontendEnvironment creates the environment to generate the plan with from the RAILPACK_* build args and the names of the secrets
+func frontendEnvironment(buildArgs map[string]string) *app.Environment {
+	env := app.NewEnvironment(nil)
+
+	for name, value := range buildArgs {
+		if strings.HasPrefix(name, "RAILPACK_") {
+			env.SetVariable(name, value)
+		}
+	}
+
+	// The values of secrets are not available to the frontend
+	for _, name := range strings.Split(buildArgs[secretNames], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			env.SetVariable(name, "")
+		}
 	}
 
-	return platform, nil
+	return env
+}
+
+func buildResultErrors(buildResult *core.BuildResult) string {
+	errs := []string{}
+	for _, msg := range buildResult.Logs {
+		if msg.Level == logger.Error {
+			errs = append(errs, msg.Msg)
+		}
+	}
+	return strings.Join(errs, "; ")
 }
 
 // Read a file from the build context
@@ -165,6 +259,10 @@ func readFile(ctx context.Context, c client.Client, filename string) (string, er
 		return "", err
 	}
 
+	if _, err := ref.StatFile(ctx, client.StatRequest{Path: filename}); err != nil {
+		return "", errFileNotFound
+	}
+
 	content, err := ref.ReadFile(ctx, client.ReadRequest{
 		Filename: filename,
 	})
@@ -192,3 +290,18 @@ func parseBuildArgs(opts map[string]string) map[string]string {
 
 	return buildArgs
 }
+
+// parseSourceDateEpoch parses the unix timestamp of the SOURCE_DATE_EPOCH build arg. Returns nil if it is not set
+func parseSourceDateEpoch(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: must be a unix timestamp", sourceDateEpoch, value)
+	}
+
+	epoch := time.Unix(seconds, 0).UTC()
+	return &epoch, nil
+}
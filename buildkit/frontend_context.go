@@ -0,0 +1,180 @@
+package buildkit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/moby/buildkit/frontend/gateway/client"
+	fstypes "github.com/tonistiigi/fsutil/types"
+)
+
+// Directories that are not loaded from the build context because plan generation does not read them
+var contextExcludes = []string{".git", "**/node_modules"}
+
+// contextReader reads the build context from a BuildKit gateway reference
+type contextReader interface {
+	ReadFile(ctx context.Context, req client.ReadRequest) ([]byte, error)
+	ReadDir(ctx context.Context, req client.ReadDirRequest) ([]*fstypes.Stat, error)
+	StatFile(ctx context.Context, req client.StatRequest) (*fstypes.Stat, error)
+}
+
+// contextFS is a file system of the build context that reads files from the gateway reference when they are opened.
+// Symlinks are resolved by BuildKit and cannot point outside of the build context
+type contextFS struct {
+	ctx context.Context
+	ref contextReader
+}
+
+func newContextFS(ctx context.Context, ref contextReader) *contextFS {
+	return &contextFS{ctx: ctx, ref: ref}
+}
+
+func (f *contextFS) Open(name string) (fs.File, error) {
+	info, err := f.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return &contextDir{fs: f, name: name, info: info}, nil
+	}
+
+	data, err := f.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &contextFile{Reader: bytes.NewReader(data), info: info}, nil
+}
+
+func (f *contextFS) Stat(name string) (fs.FileInfo, error) {
+	return f.stat("stat", name)
+}
+
+func (f *contextFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+
+	data, err := f.ref.ReadFile(f.ctx, client.ReadRequest{Filename: name})
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: notExist(err)}
+	}
+
+	return data, nil
+}
+
+func (f *contextFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	stats, err := f.ref.ReadDir(f.ctx, client.ReadDirRequest{Path: name})
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: notExist(err)}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(stats))
+	for _, stat := range stats {
+		entries = append(entries, fs.FileInfoToDirEntry(&contextFileInfo{stat: stat}))
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	return entries, nil
+}
+
+func (f *contextFS) stat(op, name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	stat, err := f.ref.StatFile(f.ctx, client.StatRequest{Path: name})
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: notExist(err)}
+	}
+
+	return &contextFileInfo{stat: stat}, nil
+}
+
+// notExist keeps the error message from BuildKit, but matches fs.ErrNotExist.
+// The gateway does not return typed errors, so any file that cannot be read from the build context is treated as missing
+func notExist(err error) error {
+	return &notExistError{err: err}
+}
+
+type notExistError struct {
+	err error
+}
+
+func (e *notExistError) Error() string { return e.err.Error() }
+
+func (e *notExistError) Is(target error) bool { return target == fs.ErrNotExist }
+
+type contextFileInfo struct {
+	stat *fstypes.Stat
+}
+
+func (i *contextFileInfo) Name() string       { return path.Base(i.stat.Path) }
+func (i *contextFileInfo) Size() int64        { return i.stat.Size }
+func (i *contextFileInfo) Mode() fs.FileMode  { return os.FileMode(i.stat.Mode) }
+func (i *contextFileInfo) ModTime() time.Time { return time.Unix(0, i.stat.ModTime) }
+func (i *contextFileInfo) IsDir() bool        { return i.Mode().IsDir() }
+func (i *contextFileInfo) Sys() any           { return i.stat }
+
+type contextFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *contextFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *contextFile) Close() error               { return nil }
+
+type contextDir struct {
+	fs      *contextFS
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (d *contextDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *contextDir) Close() error               { return nil }
+
+func (d *contextDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: fs.ErrInvalid}
+}
+
+func (d *contextDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		entries, err := d.fs.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.read = true
+	}
+
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
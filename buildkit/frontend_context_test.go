@@ -0,0 +1,81 @@
+package buildkit
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/railwayapp/railpack/core/app"
+	"github.com/stretchr/testify/require"
+	"github.com/tonistiigi/fsutil"
+	fstypes "github.com/tonistiigi/fsutil/types"
+)
+
+// dirContextReader reads a build context from a local directory like a gateway reference does
+type dirContextReader string
+
+func (d dirContextReader) ReadFile(_ context.Context, req client.ReadRequest) ([]byte, error) {
+	return os.ReadFile(filepath.Join(string(d), req.Filename))
+}
+
+func (d dirContextReader) ReadDir(_ context.Context, req client.ReadDirRequest) ([]*fstypes.Stat, error) {
+	entries, err := os.ReadDir(filepath.Join(string(d), req.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := []*fstypes.Stat{}
+	for _, entry := range entries {
+		stat, err := fsutil.Stat(filepath.Join(string(d), req.Path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+func (d dirContextReader) StatFile(_ context.Context, req client.StatRequest) (*fstypes.Stat, error) {
+	return fsutil.Stat(filepath.Join(string(d), req.Path))
+}
+
+func TestContextFS(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "package.json"), []byte(`{"name": "app"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "bin", "start.sh"), []byte("#!/bin/sh"), 0755))
+
+	contextFS := newContextFS(context.Background(), dirContextReader(src))
+	require.NoError(t, fstest.TestFS(contextFS, "package.json", "bin/start.sh"))
+
+	a := app.NewAppFromFS(contextFS, "app")
+	require.True(t, a.HasFile("package.json"))
+	require.False(t, a.HasFile("missing.json"))
+	require.True(t, a.IsFileExecutable("bin/start.sh"))
+
+	files, err := a.FindFiles("**/*.sh")
+	require.NoError(t, err)
+	require.Equal(t, []string{"bin/start.sh"}, files)
+
+	_, err = contextFS.Stat("missing.json")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestFrontendEnvironment(t *testing.T) {
+	env := frontendEnvironment(map[string]string{
+		"RAILPACK_BUILD_CMD": "make",
+		"cache-key":          "key",
+		"NODE_ENV":           "production",
+		"secrets":            "STRIPE_KEY, DATABASE_URL",
+	})
+
+	require.Equal(t, map[string]string{
+		"RAILPACK_BUILD_CMD": "make",
+		"STRIPE_KEY":         "",
+		"DATABASE_URL":       "",
+	}, env.Variables)
+}
@@ -2,6 +2,9 @@ package buildkit
 
 import (
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseBuildArgs(t *testing.T) {
@@ -29,3 +32,16 @@ func TestParseBuildArgs(t *testing.T) {
 		}
 	}
 }
+
+func TestParseSourceDateEpoch(t *testing.T) {
+	epoch, err := parseSourceDateEpoch("")
+	require.NoError(t, err)
+	require.Nil(t, epoch)
+
+	epoch, err = parseSourceDateEpoch("1735787045")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), *epoch)
+
+	_, err = parseSourceDateEpoch("yesterday")
+	require.Error(t, err)
+}
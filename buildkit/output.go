@@ -0,0 +1,246 @@
+package buildkit
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/exporter/containerimage/exptypes"
+)
+
+// Exporters supported as build outputs
+var outputTypes = []string{client.ExporterDocker, client.ExporterOCI, client.ExporterImage, client.ExporterLocal, client.ExporterTar}
+
+// Output is where the built image is exported to
+type Output struct {
+	Type string
+
+	// File or directory to write the output to.
+	// Empty for image outputs, and for docker outputs that are loaded into the local docker daemon
+	Dest string
+
+	// Additional exporter attributes (e.g. compression=zstd)
+	Attrs map[string]string
+}
+
+// ParseOutput parses an output spec (e.g. "type=oci,dest=image.tar").
+// An empty spec loads the image into docker and a spec without a type is a directory to write the filesystem to
+func ParseOutput(spec string, push bool) (*Output, error) {
+	output := &Output{Type: client.ExporterDocker, Attrs: map[string]string{}}
+
+	switch {
+	case spec == "" && push:
+		output.Type = client.ExporterImage
+	case spec == "":
+	case !strings.Contains(spec, "="):
+		output.Type = client.ExporterLocal
+		output.Dest = spec
+	default:
+		attrs, err := parseSpec(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output %q: %w", spec, err)
+		}
+
+		output.Type = attrs["type"]
+		output.Dest = attrs["dest"]
+		delete(attrs, "type")
+		delete(attrs, "dest")
+		output.Attrs = attrs
+	}
+
+	if !slices.Contains(outputTypes, output.Type) {
+		return nil, fmt.Errorf("unsupported output type %q. Must be one of: %s", output.Type, strings.Join(outputTypes, ", "))
+	}
+
+	if push {
+		if output.Type != client.ExporterImage {
+			return nil, fmt.Errorf("--push can only be used with type=image outputs")
+		}
+		output.Attrs["push"] = "true"
+	}
+
+	switch output.Type {
+	case client.ExporterOCI, client.ExporterLocal, client.ExporterTar:
+		if output.Dest == "" {
+			return nil, fmt.Errorf("%s output requires dest", output.Type)
+		}
+	case client.ExporterImage:
+		if output.Dest != "" {
+			return nil, fmt.Errorf("image output does not support dest. Use --name to set the image name")
+		}
+	}
+
+	return output, nil
+}
+
+// checkPlatforms fails for outputs that cannot export the image index that multiple platforms are exported as.
+// The docker exporter does not support image indexes, even when docker uses the containerd image store
+func (o *Output) checkPlatforms(count int) error {
+	if count > 1 && o.Type == client.ExporterDocker {
+		return fmt.Errorf("images for multiple platforms cannot be loaded into docker. Use --push, --output type=image, or --output type=oci,dest=image.tar instead")
+	}
+	return nil
+}
+
+// checkAttestations fails for outputs that cannot export attestations, which are attached to an image index
+func (o *Output) checkAttestations(count int) error {
+	if count > 0 && o.Type != client.ExporterOCI && o.Type != client.ExporterImage {
+		return fmt.Errorf("attestations (--sbom, --provenance) can only be attached to images that are pushed or exported with --output type=image or --output type=oci,dest=image.tar")
+	}
+	return nil
+}
+
+// export is a BuildKit export entry and how to finish it after the build is solved
+type export struct {
+	entry client.ExportEntry
+
+	// Waits for the output to be consumed (e.g. by docker load)
+	wait func() error
+
+	// Message logged after a successful build
+	message string
+}
+
+func newExport(output *Output, imageName string) (*export, error) {
+	attrs := map[string]string{}
+	for k, v := range output.Attrs {
+		attrs[k] = v
+	}
+
+	e := &export{
+		entry: client.ExportEntry{Type: output.Type, Attrs: attrs},
+		wait:  func() error { return nil },
+	}
+
+	switch output.Type {
+	case client.ExporterLocal:
+		if err := os.MkdirAll(output.Dest, 0755); err != nil {
+			return nil, fmt.Errorf("error creating output directory: %w", err)
+		}
+		e.entry.OutputDir = output.Dest
+		e.message = fmt.Sprintf("Saved image filesystem to directory `%s`", output.Dest)
+		return e, nil
+	case client.ExporterTar:
+		e.entry.Output = fileOutput(output.Dest)
+		e.message = fmt.Sprintf("Saved image filesystem to `%s`", output.Dest)
+		return e, nil
+	}
+
+	if _, ok := attrs["name"]; !ok {
+		attrs["name"] = imageName
+	}
+
+	switch output.Type {
+	case client.ExporterImage:
+		if attrs["push"] == "true" {
+			e.message = fmt.Sprintf("Pushed image `%s`", attrs["name"])
+		} else {
+			e.message = fmt.Sprintf("Built image `%s`. Use --push to push it to a registry", attrs["name"])
+		}
+	case client.ExporterOCI:
+		e.entry.Output = fileOutput(output.Dest)
+		e.message = fmt.Sprintf("Saved OCI image to `%s`", output.Dest)
+	case client.ExporterDocker:
+		if output.Dest != "" {
+			e.entry.Output = fileOutput(output.Dest)
+			e.message = fmt.Sprintf("Saved image to `%s`. Load it with `docker load -i %s`", output.Dest, output.Dest)
+		} else {
+			e.entry.Output, e.wait = dockerLoadOutput()
+			e.message = fmt.Sprintf("Run with `docker run -it %s`", attrs["name"])
+		}
+	}
+
+	return e, nil
+}
+
+// fileOutput creates the file when BuildKit starts writing the output, so failed builds do not leave an empty file behind
+func fileOutput(path string) func(map[string]string) (io.WriteCloser, error) {
+	return func(_ map[string]string) (io.WriteCloser, error) {
+		return os.Create(path)
+	}
+}
+
+// dockerLoadOutput pipes the image into `docker load`
+func dockerLoadOutput() (func(map[string]string) (io.WriteCloser, error), func() error) {
+	pipeR, pipeW := io.Pipe()
+	errCh := make(chan error, 1)
+
+	go func() {
+		cmd := exec.Command("docker", "load")
+		cmd.Stdin = pipeR
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		err := cmd.Run()
+		pipeR.Close()
+		errCh <- err
+	}()
+
+	output := func(_ map[string]string) (io.WriteCloser, error) {
+		return pipeW, nil
+	}
+
+	wait := func() error {
+		pipeW.Close()
+		if err := <-errCh; err != nil {
+			return fmt.Errorf("docker load failed: %w", err)
+		}
+		return nil
+	}
+
+	log.Debugf("Loading image into docker")
+
+	return output, wait
+}
+
+// addAnnotations adds the labels as annotations on the image manifests, and on the image index when one is exported.
+// Annotations already set in the output spec are kept
+func (e *export) addAnnotations(labels map[string]string, index bool) {
+	switch e.entry.Type {
+	case client.ExporterImage, client.ExporterOCI, client.ExporterDocker:
+	default:
+		return
+	}
+
+	// annotation.<key> is the same as annotation-manifest.<key>
+	existing := map[string]bool{}
+	for attr := range e.entry.Attrs {
+		if key, ok, err := exptypes.ParseAnnotationKey(attr); ok && err == nil {
+			existing[key.String()] = true
+		}
+	}
+
+	for k, v := range labels {
+		keys := []string{exptypes.AnnotationManifestKey(nil, k)}
+		if index {
+			keys = append(keys, exptypes.AnnotationIndexKey(k))
+		}
+
+		for _, key := range keys {
+			if !existing[key] {
+				e.entry.Attrs[key] = v
+			}
+		}
+	}
+}
+
+// setSourceDateEpoch exports the image with the epoch as the timestamp of the image and its files.
+// Files written by exec commands are rewritten to the epoch as well, so the layers do not depend on when they were built
+func (e *export) setSourceDateEpoch(epoch time.Time) {
+	if _, ok := e.entry.Attrs[string(exptypes.OptKeySourceDateEpoch)]; !ok {
+		e.entry.Attrs[string(exptypes.OptKeySourceDateEpoch)] = strconv.FormatInt(epoch.Unix(), 10)
+	}
+
+	switch e.entry.Type {
+	case client.ExporterImage, client.ExporterOCI, client.ExporterDocker:
+		if _, ok := e.entry.Attrs[string(exptypes.OptKeyRewriteTimestamp)]; !ok {
+			e.entry.Attrs[string(exptypes.OptKeyRewriteTimestamp)] = "true"
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package buildkit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/moby/buildkit/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		push    bool
+		want    *Output
+		wantErr string
+	}{
+		{
+			name: "default loads into docker",
+			spec: "",
+			want: &Output{Type: client.ExporterDocker, Attrs: map[string]string{}},
+		},
+		{
+			name: "directory",
+			spec: "out",
+			want: &Output{Type: client.ExporterLocal, Dest: "out", Attrs: map[string]string{}},
+		},
+		{
+			name: "oci tarball",
+			spec: "type=oci,dest=image.tar,compression=zstd",
+			want: &Output{Type: client.ExporterOCI, Dest: "image.tar", Attrs: map[string]string{"compression": "zstd"}},
+		},
+		{
+			name: "docker tarball",
+			spec: "type=docker,dest=image.tar",
+			want: &Output{Type: client.ExporterDocker, Dest: "image.tar", Attrs: map[string]string{}},
+		},
+		{
+			name: "push",
+			push: true,
+			want: &Output{Type: client.ExporterImage, Attrs: map[string]string{"push": "true"}},
+		},
+		{
+			name: "push with image output",
+			spec: "type=image,oci-mediatypes=true",
+			push: true,
+			want: &Output{Type: client.ExporterImage, Attrs: map[string]string{"oci-mediatypes": "true", "push": "true"}},
+		},
+		{
+			name:    "push with oci output",
+			spec:    "type=oci,dest=image.tar",
+			push:    true,
+			wantErr: "--push can only be used with type=image outputs",
+		},
+		{
+			name:    "oci without dest",
+			spec:    "type=oci",
+			wantErr: "oci output requires dest",
+		},
+		{
+			name:    "image with dest",
+			spec:    "type=image,dest=image.tar",
+			wantErr: "image output does not support dest",
+		},
+		{
+			name:    "unsupported type",
+			spec:    "type=zip,dest=image.zip",
+			wantErr: `unsupported output type "zip"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOutput(tt.spec, tt.push)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOutputCheckPlatforms(t *testing.T) {
+	docker, err := ParseOutput("", false)
+	require.NoError(t, err)
+	require.NoError(t, docker.checkPlatforms(1))
+	require.ErrorContains(t, docker.checkPlatforms(2), "cannot be loaded into docker")
+
+	dockerTarball, err := ParseOutput("type=docker,dest=image.tar", false)
+	require.NoError(t, err)
+	require.Error(t, dockerTarball.checkPlatforms(2))
+
+	for _, spec := range []string{"type=oci,dest=image.tar", "type=image", "out"} {
+		output, err := ParseOutput(spec, false)
+		require.NoError(t, err)
+		require.NoError(t, output.checkPlatforms(2), spec)
+	}
+}
+
+func TestOutputCheckAttestations(t *testing.T) {
+	// The default output with --sbom
+	docker, err := ParseOutput("", false)
+	require.NoError(t, err)
+	require.NoError(t, docker.checkAttestations(0))
+	require.ErrorContains(t, docker.checkAttestations(2), "attestations (--sbom, --provenance) can only be attached")
+
+	for _, spec := range []string{"type=docker,dest=image.tar", "out", "type=tar,dest=fs.tar"} {
+		output, err := ParseOutput(spec, false)
+		require.NoError(t, err)
+		require.Error(t, output.checkAttestations(1), spec)
+	}
+
+	for _, spec := range []string{"type=oci,dest=image.tar", "type=image"} {
+		output, err := ParseOutput(spec, false)
+		require.NoError(t, err)
+		require.NoError(t, output.checkAttestations(1), spec)
+	}
+
+	pushed, err := ParseOutput("", true)
+	require.NoError(t, err)
+	require.NoError(t, pushed.checkAttestations(1))
+}
+
+func TestNewExport(t *testing.T) {
+	dir := t.TempDir()
+
+	local, err := newExport(&Output{Type: client.ExporterLocal, Dest: filepath.Join(dir, "fs")}, "app")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "fs"), local.entry.OutputDir)
+	require.DirExists(t, filepath.Join(dir, "fs"))
+
+	ociPath := filepath.Join(dir, "image.tar")
+	oci, err := newExport(&Output{Type: client.ExporterOCI, Dest: ociPath, Attrs: map[string]string{}}, "app")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"name": "app"}, oci.entry.Attrs)
+	require.NoFileExists(t, ociPath)
+
+	w, err := oci.entry.Output(nil)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.FileExists(t, ociPath)
+	require.NoError(t, os.Remove(ociPath))
+
+	image, err := newExport(&Output{Type: client.ExporterImage, Attrs: map[string]string{"push": "true", "name": "ghcr.io/org/app"}}, "app")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"push": "true", "name": "ghcr.io/org/app"}, image.entry.Attrs)
+	require.Nil(t, image.entry.Output)
+	require.Equal(t, "Pushed image `ghcr.io/org/app`", image.message)
+}
+
+func TestExportAnnotations(t *testing.T) {
+	labels := map[string]string{"org.opencontainers.image.revision": "abc123"}
+
+	image, err := newExport(&Output{Type: client.ExporterImage, Attrs: map[string]string{"annotation.org.opencontainers.image.revision": "custom"}}, "app")
+	require.NoError(t, err)
+	image.addAnnotations(labels, true)
+	require.Equal(t, map[string]string{
+		"name": "app",
+		"annotation.org.opencontainers.image.revision":       "custom",
+		"annotation-index.org.opencontainers.image.revision": "abc123",
+	}, image.entry.Attrs)
+
+	oci, err := newExport(&Output{Type: client.ExporterOCI, Dest: filepath.Join(t.TempDir(), "image.tar"), Attrs: map[string]string{}}, "app")
+	require.NoError(t, err)
+	oci.addAnnotations(labels, false)
+	require.Equal(t, map[string]string{
+		"name": "app",
+		"annotation-manifest.org.opencontainers.image.revision": "abc123",
+	}, oci.entry.Attrs)
+
+	local, err := newExport(&Output{Type: client.ExporterLocal, Dest: filepath.Join(t.TempDir(), "fs")}, "app")
+	require.NoError(t, err)
+	local.addAnnotations(labels, true)
+	require.Empty(t, local.entry.Attrs)
+}
+
+func TestExportSourceDateEpoch(t *testing.T) {
+	epoch := time.Unix(1735787045, 0)
+
+	image, err := newExport(&Output{Type: client.ExporterImage, Attrs: map[string]string{"rewrite-timestamp": "false"}}, "app")
+	require.NoError(t, err)
+	image.setSourceDateEpoch(epoch)
+	require.Equal(t, map[string]string{
+		"name":              "app",
+		"source-date-epoch": "1735787045",
+		"rewrite-timestamp": "false",
+	}, image.entry.Attrs)
+
+	tar, err := newExport(&Output{Type: client.ExporterTar, Dest: filepath.Join(t.TempDir(), "fs.tar"), Attrs: map[string]string{}}, "app")
+	require.NoError(t, err)
+	tar.setSourceDateEpoch(epoch)
+	require.Equal(t, map[string]string{"source-date-epoch": "1735787045"}, tar.entry.Attrs)
+}
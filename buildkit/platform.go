@@ -1,6 +1,8 @@
 package buildkit
 
 import (
+	"strings"
+
 	"github.com/containerd/platforms"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 )
@@ -37,3 +39,38 @@ func ParsePlatformWithDefaults(platformStr string) (specs.Platform, error) {
 	// Parse the user-specified platform string
 	return platforms.Parse(platformStr)
 }
+
+// ParsePlatforms parses a comma separated list of platforms (e.g. "linux/amd64,linux/arm64").
+// Duplicate platforms are removed and an empty string returns the default platform.
+func ParsePlatforms(platformsStr string) ([]specs.Platform, error) {
+	if strings.TrimSpace(platformsStr) == "" {
+		platform, err := ParsePlatformWithDefaults("")
+		if err != nil {
+			return nil, err
+		}
+		return []specs.Platform{platform}, nil
+	}
+
+	result := []specs.Platform{}
+	seen := map[string]bool{}
+
+	for _, platformStr := range strings.Split(platformsStr, ",") {
+		platformStr = strings.TrimSpace(platformStr)
+		if platformStr == "" {
+			continue
+		}
+
+		platform, err := platforms.Parse(platformStr)
+		if err != nil {
+			return nil, err
+		}
+
+		// linux/arm64 and linux/arm64/v8 are the same platform
+		if key := platforms.Format(platforms.Normalize(platform)); !seen[key] {
+			seen[key] = true
+			result = append(result, platform)
+		}
+	}
+
+	return result, nil
+}
@@ -77,37 +77,33 @@ func TestValidatePlatform(t *testing.T) {
 }
 
 func TestValidatePlatformWithMultiplePlatforms(t *testing.T) {
+	defaultPlatform, _ := ParsePlatformWithDefaults("")
+
 	tests := []struct {
 		name     string
 		input    string
-		expected specs.Platform
+		expected []specs.Platform
 		wantErr  bool
 	}{
 		{
-			name:  "empty string returns Linux platform",
-			input: "",
-			expected: func() specs.Platform {
-				platform, _ := ParsePlatformWithDefaults("")
-				return platform
-			}(),
-			wantErr: false,
+			name:     "empty string returns Linux platform",
+			input:    "",
+			expected: []specs.Platform{defaultPlatform},
+			wantErr:  false,
 		},
 		{
 			name:  "linux/arm64/v8",
 			input: "linux/arm64/v8",
-			expected: specs.Platform{
-				OS:           "linux",
-				Architecture: "arm64",
-				Variant:      "v8",
+			expected: []specs.Platform{
+				{OS: "linux", Architecture: "arm64", Variant: "v8"},
 			},
 			wantErr: false,
 		},
 		{
 			name:  "linux/amd64",
 			input: "linux/amd64",
-			expected: specs.Platform{
-				OS:           "linux",
-				Architecture: "amd64",
+			expected: []specs.Platform{
+				{OS: "linux", Architecture: "amd64"},
 			},
 			wantErr: false,
 		},
@@ -117,8 +113,26 @@ func TestValidatePlatformWithMultiplePlatforms(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name:    "multiple platforms not supported",
-			input:   "linux/amd64,linux/arm64",
+			name:  "multiple platforms",
+			input: "linux/amd64,linux/arm64",
+			expected: []specs.Platform{
+				{OS: "linux", Architecture: "amd64"},
+				{OS: "linux", Architecture: "arm64"},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "duplicate platforms and whitespace",
+			input: "linux/amd64, linux/arm64/v8,linux/arm64,",
+			expected: []specs.Platform{
+				{OS: "linux", Architecture: "amd64"},
+				{OS: "linux", Architecture: "arm64", Variant: "v8"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "one invalid platform",
+			input:   "linux/amd64,invalid",
 			wantErr: true,
 		},
 	}
@@ -126,19 +140,24 @@ func TestValidatePlatformWithMultiplePlatforms(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			opts := map[string]string{"platform": tt.input}
-			got, err := validatePlatform(opts)
+			got, err := validatePlatforms(opts)
 			if tt.wantErr {
 				if err == nil {
-					t.Errorf("validatePlatform() expected error but got none")
+					t.Errorf("validatePlatforms() expected error but got none")
 				}
 				return
 			}
 			if err != nil {
-				t.Errorf("validatePlatform() error = %v", err)
+				t.Errorf("validatePlatforms() error = %v", err)
 				return
 			}
-			if got.OS != tt.expected.OS || got.Architecture != tt.expected.Architecture || got.Variant != tt.expected.Variant {
-				t.Errorf("validatePlatform() = %v, want %v", got, tt.expected)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("validatePlatforms() = %v, want %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i].OS != tt.expected[i].OS || got[i].Architecture != tt.expected[i].Architecture || got[i].Variant != tt.expected[i].Variant {
+					t.Errorf("validatePlatforms() = %v, want %v", got, tt.expected)
+				}
 			}
 		})
 	}
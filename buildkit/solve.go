@@ -0,0 +1,104 @@
+package buildkit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/containerd/platforms"
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/exporter/containerimage/exptypes"
+	"github.com/moby/buildkit/frontend/gateway/client"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/railwayapp/railpack/core/plan"
+)
+
+// marshalPlan converts the plan to an LLB definition and image config for a single platform
+func marshalPlan(ctx context.Context, plan *plan.BuildPlan, platform specs.Platform, opts ConvertPlanOptions) (*llb.Definition, []byte, error) {
+	opts.BuildPlatform = platform
+
+	llbState, image, err := ConvertPlanToLLB(plan, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error converting plan to LLB: %w", err)
+	}
+
+	def, err := llbState.Marshal(ctx, llb.Platform(platform))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error marshalling LLB state: %w", err)
+	}
+
+	imageBytes, err := json.Marshal(image)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error marshalling image: %w", err)
+	}
+
+	return def, imageBytes, nil
+}
+
+// solvePlan solves the plan once for every platform.
+// Multiple platforms result in a ref per platform, which the image exporters combine into an image index.
+// Attestations are attached to the image of every platform
+func solvePlan(ctx context.Context, c client.Client, plan *plan.BuildPlan, buildPlatforms []specs.Platform, attestations []Attestation, opts ConvertPlanOptions) (*client.Result, error) {
+	if len(buildPlatforms) == 1 && len(attestations) == 0 {
+		ref, imageBytes, err := solvePlatform(ctx, c, plan, buildPlatforms[0], opts)
+		if err != nil {
+			return nil, err
+		}
+
+		res := client.NewResult()
+		res.SetRef(ref)
+		res.AddMeta(exptypes.ExporterImageConfigKey, imageBytes)
+		return res, nil
+	}
+
+	// Attestations are keyed by platform, so a single platform with attestations is also exported with the platforms mapping
+	res := client.NewResult()
+	expPlatforms := exptypes.Platforms{}
+	ids := []string{}
+
+	for _, platform := range buildPlatforms {
+		ref, imageBytes, err := solvePlatform(ctx, c, plan, platform, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		id := platforms.Format(platform)
+		res.AddRef(id, ref)
+		res.AddMeta(fmt.Sprintf("%s/%s", exptypes.ExporterImageConfigKey, id), imageBytes)
+		expPlatforms.Platforms = append(expPlatforms.Platforms, exptypes.Platform{ID: id, Platform: platform})
+		ids = append(ids, id)
+	}
+
+	platformsBytes, err := json.Marshal(expPlatforms)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling platforms: %w", err)
+	}
+	res.AddMeta(exptypes.ExporterPlatformsKey, platformsBytes)
+
+	if err := addAttestations(ctx, c, res, ids, attestations); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func solvePlatform(ctx context.Context, c client.Client, plan *plan.BuildPlan, platform specs.Platform, opts ConvertPlanOptions) (client.Reference, []byte, error) {
+	def, imageBytes, err := marshalPlan(ctx, plan, platform, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := c.Solve(ctx, client.SolveRequest{
+		Definition: def.ToPB(),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to solve for %s: %w", platforms.Format(platform), err)
+	}
+
+	ref, err := res.SingleRef()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ref, imageBytes, nil
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/railwayapp/railpack/core"
+	"github.com/railwayapp/railpack/core/plan"
+	"github.com/urfave/cli/v3"
+)
+
+var AffectedCommand = &cli.Command{
+	Name:                  "affected",
+	Usage:                 "check if a set of changed files can change the built image",
+	ArgsUsage:             "DIRECTORY",
+	EnableShellCompletion: true,
+	Flags: append([]cli.Flag{
+		&cli.StringSliceFlag{
+			Name:     "changed-files",
+			Usage:    "files that changed, relative to the app directory. use - to read newline separated files from stdin",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "output format. one of: pretty, json",
+			Value: "pretty",
+		},
+	}, commonPlanFlags()...),
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		changedFiles, err := readChangedFiles(cmd.StringSlice("changed-files"))
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		buildResult, app, env, err := GenerateBuildResultForCommand(ctx, cmd)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		if !buildResult.Success {
+			core.PrettyPrintBuildResult(buildResult, core.PrintOptions{Version: Version})
+			os.Exit(1)
+			return nil
+		}
+
+		dockerignore, err := plan.NewDockerignoreContext(app)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		result, err := plan.FindAffected(buildResult.Plan, changedFiles, plan.AffectedOptions{
+			Dockerignore: dockerignore,
+			PlanFiles:    core.PlanFiles(app, env, getGenerateOptionsForCommand(cmd)),
+		})
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		if cmd.String("format") == "json" {
+			serialized, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return cli.Exit(err, 1)
+			}
+			os.Stdout.Write(serialized)
+			os.Stdout.Write([]byte("\n"))
+			return nil
+		}
+
+		os.Stdout.Write([]byte(formatAffectedResult(result)))
+		return nil
+	},
+}
+
+// readChangedFiles expands the changed files flag, reading from stdin when the value is "-"
+func readChangedFiles(values []string) ([]string, error) {
+	files := []string{}
+
+	for _, value := range values {
+		if value != "-" {
+			files = append(files, value)
+			continue
+		}
+
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				files = append(files, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("error reading changed files from stdin: %w", err)
+		}
+	}
+
+	return files, nil
+}
+
+func formatAffectedResult(result *plan.AffectedResult) string {
+	var output strings.Builder
+
+	if result.Affected {
+		output.WriteString("The changed files affect the image\n")
+	} else {
+		output.WriteString("The changed files do not affect the image\n")
+	}
+
+	if len(result.PlanFiles) > 0 {
+		output.WriteString(fmt.Sprintf("\nPlan files changed: %s\n", strings.Join(result.PlanFiles, ", ")))
+	}
+
+	if len(result.Steps) > 0 {
+		output.WriteString("\nInvalidated steps:\n")
+		for _, step := range result.Steps {
+			reasons := []string{}
+			if step.PlanChanged {
+				reasons = append(reasons, "plan changed")
+			}
+			if len(step.Files) > 0 {
+				reasons = append(reasons, fmt.Sprintf("files %s", strings.Join(step.Files, ", ")))
+			}
+			if len(step.Steps) > 0 {
+				reasons = append(reasons, fmt.Sprintf("inputs %s", strings.Join(step.Steps, ", ")))
+			}
+			output.WriteString(fmt.Sprintf("  %s (%s)\n", step.Name, strings.Join(reasons, "; ")))
+		}
+	}
+
+	if len(result.DeployFiles) > 0 {
+		output.WriteString(fmt.Sprintf("\nCopied into the image: %s\n", strings.Join(result.DeployFiles, ", ")))
+	}
+
+	if len(result.IgnoredFiles) > 0 {
+		output.WriteString(fmt.Sprintf("\nIgnored by .dockerignore: %s\n", strings.Join(result.IgnoredFiles, ", ")))
+	}
+
+	return output.String()
+}
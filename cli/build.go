@@ -9,11 +9,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/containerd/platforms"
 	"github.com/railwayapp/railpack/buildkit"
 	"github.com/railwayapp/railpack/core"
 	"github.com/railwayapp/railpack/core/app"
 	"github.com/railwayapp/railpack/core/plan"
+	"github.com/railwayapp/railpack/core/slsa"
 	"github.com/urfave/cli/v3"
 )
 
@@ -30,11 +33,15 @@ var BuildCommand = &cli.Command{
 		},
 		&cli.StringFlag{
 			Name:  "output",
-			Usage: "output the final filesystem to a local directory",
+			Usage: "output the final filesystem to a local directory, or an output spec (e.g. type=oci,dest=image.tar or type=docker,dest=image.tar)",
+		},
+		&cli.BoolFlag{
+			Name:  "push",
+			Usage: "push the image to a registry. credentials are read from the docker config",
 		},
 		&cli.StringFlag{
 			Name:  "platform",
-			Usage: "platform to build for (e.g. linux/amd64, linux/arm64)",
+			Usage: "comma separated platforms to build for (e.g. linux/amd64,linux/arm64). multiple platforms are exported as an image index",
 		},
 		&cli.StringFlag{
 			Name:  "progress",
@@ -50,6 +57,31 @@ var BuildCommand = &cli.Command{
 			Name:  "cache-key",
 			Usage: "Unique id to prefix to cache keys",
 		},
+		&cli.StringSliceFlag{
+			Name:  "cache-from",
+			Usage: "external cache sources (e.g. type=local,src=path/to/dir or type=registry,ref=example.com/app:cache)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "cache-to",
+			Usage: "cache export destinations (e.g. type=local,dest=path/to/dir,mode=max or type=inline)",
+		},
+		&cli.BoolFlag{
+			Name:  "sbom",
+			Usage: "attach SPDX and CycloneDX SBOMs to the image as attestations",
+		},
+		&cli.BoolFlag{
+			Name:  "provenance",
+			Usage: "attach a SLSA provenance attestation that includes the build plan to the image",
+		},
+		&cli.BoolFlag{
+			Name:  "reproducible",
+			Usage: "build a reproducible image by using the time of the checked out git commit as the timestamp of the image and its files",
+		},
+		&cli.IntFlag{
+			Name:    "source-date-epoch",
+			Usage:   "unix timestamp to use as the timestamp of the image and its files. implies --reproducible",
+			Sources: cli.EnvVars("SOURCE_DATE_EPOCH"),
+		},
 		&cli.BoolFlag{
 			Name:   "dump-llb",
 			Hidden: true,
@@ -57,7 +89,7 @@ var BuildCommand = &cli.Command{
 		},
 	}, commonPlanFlags()...),
 	Action: func(ctx context.Context, cmd *cli.Command) error {
-		buildResult, app, env, err := GenerateBuildResultForCommand(cmd)
+		buildResult, app, env, err := GenerateBuildResultForCommand(ctx, cmd)
 		if err != nil {
 			return cli.Exit(err, 1)
 		}
@@ -92,16 +124,41 @@ var BuildCommand = &cli.Command{
 		secretsHash := getSecretsHash(env)
 
 		platformStr := cmd.String("platform")
+		startedOn := time.Now()
+		git := core.ReadGitMetadata(app.Source)
+
+		epoch, err := sourceDateEpoch(cmd, git)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		// Reproducible images are created at the epoch instead of when they are built
+		created := startedOn
+		if epoch != nil {
+			created = *epoch
+		}
+
+		attestations, err := buildAttestations(app, buildResult, git, platformStr, cmd.Bool("sbom"), cmd.Bool("provenance"), startedOn, epoch)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
 		err = buildkit.BuildWithBuildkitClient(app.Source, buildResult.Plan, buildkit.BuildWithBuildkitClientOptions{
-			ImageName:    cmd.String("name"),
-			DumpLLB:      cmd.Bool("dump-llb"),
-			OutputDir:    cmd.String("output"),
-			ProgressMode: cmd.String("progress"),
-			CacheKey:     cmd.String("cache-key"),
-			SecretsHash:  secretsHash,
-			Secrets:      env.Variables,
-			Platform:     platformStr,
-			GitHubToken:  os.Getenv("GITHUB_TOKEN"),
+			ImageName:       cmd.String("name"),
+			DumpLLB:         cmd.Bool("dump-llb"),
+			Output:          cmd.String("output"),
+			Push:            cmd.Bool("push"),
+			ProgressMode:    cmd.String("progress"),
+			CacheKey:        cmd.String("cache-key"),
+			CacheFrom:       cmd.StringSlice("cache-from"),
+			CacheTo:         cmd.StringSlice("cache-to"),
+			SecretsHash:     secretsHash,
+			Secrets:         env.Variables,
+			Platform:        platformStr,
+			GitHubToken:     os.Getenv("GITHUB_TOKEN"),
+			Attestations:    attestations,
+			Labels:          core.ImageLabels(app, buildResult, git, created),
+			SourceDateEpoch: epoch,
 		})
 		if err != nil {
 			return cli.Exit(err, 1)
@@ -111,6 +168,88 @@ var BuildCommand = &cli.Command{
 	},
 }
 
+// sourceDateEpoch returns the timestamp of a reproducible build, or nil if the build is not reproducible.
+// --source-date-epoch takes precedence over the time of the checked out commit
+func sourceDateEpoch(cmd *cli.Command, git *core.GitMetadata) (*time.Time, error) {
+	if cmd.IsSet("source-date-epoch") {
+		epoch := time.Unix(cmd.Int("source-date-epoch"), 0).UTC()
+		return &epoch, nil
+	}
+
+	if !cmd.Bool("reproducible") {
+		return nil, nil
+	}
+
+	if git == nil || git.CommitTime == nil {
+		return nil, fmt.Errorf("--reproducible requires the app to be in a git repository. Use --source-date-epoch to set the timestamp instead")
+	}
+
+	return git.CommitTime, nil
+}
+
+// buildAttestations creates the SBOM and provenance attestations that are enabled.
+// Reproducible builds use the epoch instead of when the build started, so the attestations are the same for every build of a commit
+func buildAttestations(app *app.App, buildResult *core.BuildResult, git *core.GitMetadata, platformStr string, withSBOM bool, withProvenance bool, startedOn time.Time, epoch *time.Time) ([]buildkit.Attestation, error) {
+	created := startedOn
+	if epoch != nil {
+		created = *epoch
+	}
+
+	attestations := []buildkit.Attestation{}
+	if withSBOM {
+		sboms, err := sbomAttestations(app, buildResult, created)
+		if err != nil {
+			return nil, err
+		}
+		attestations = append(attestations, sboms...)
+	}
+
+	if withProvenance {
+		// The provenance of a reproducible build does not record when it started
+		if epoch != nil {
+			startedOn = time.Time{}
+		}
+
+		attestation, err := provenanceAttestation(buildResult, git, platformStr, startedOn)
+		if err != nil {
+			return nil, err
+		}
+		attestations = append(attestations, *attestation)
+	}
+
+	return attestations, nil
+}
+
+// provenanceAttestation creates a SLSA provenance attestation for the image built for the platforms
+func provenanceAttestation(buildResult *core.BuildResult, git *core.GitMetadata, platformStr string, startedOn time.Time) (*buildkit.Attestation, error) {
+	buildPlatforms, err := buildkit.ParsePlatforms(platformStr)
+	if err != nil {
+		return nil, err
+	}
+
+	platformNames := make([]string, len(buildPlatforms))
+	for i, platform := range buildPlatforms {
+		platformNames[i] = platforms.Format(platform)
+	}
+
+	provenance, err := slsa.Generate(buildResult, git, platformNames, startedOn)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := provenance.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	return &buildkit.Attestation{
+		PredicateType: slsa.PredicateType,
+		Name:          "provenance.json",
+		Reason:        "provenance",
+		Predicate:     data,
+	}, nil
+}
+
 func validateSecrets(plan *plan.BuildPlan, env *app.Environment) error {
 	for _, secret := range plan.Secrets {
 		if _, ok := env.Variables[secret]; !ok {
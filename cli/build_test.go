@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/railwayapp/railpack/core"
+	"github.com/railwayapp/railpack/core/app"
+	"github.com/railwayapp/railpack/core/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildAttestationsReproducible(t *testing.T) {
+	a, err := app.NewApp(t.TempDir())
+	require.NoError(t, err)
+
+	p := plan.NewBuildPlan()
+	p.Deploy.StartCmd = "run"
+	buildResult := &core.BuildResult{Plan: p, Success: true, RailpackVersion: "1.0.0"}
+	commitTime := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	git := &core.GitMetadata{Source: "https://github.com/org/app", Revision: "abc123", CommitTime: &commitTime}
+
+	build := func(startedOn time.Time, epoch *time.Time) []string {
+		attestations, err := buildAttestations(a, buildResult, git, "linux/amd64", true, true, startedOn, epoch)
+		require.NoError(t, err)
+		require.Len(t, attestations, 3)
+
+		predicates := []string{}
+		for _, attestation := range attestations {
+			predicates = append(predicates, string(attestation.Predicate))
+		}
+		return predicates
+	}
+
+	first := build(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), &commitTime)
+	second := build(time.Date(2026, 1, 1, 0, 5, 0, 0, time.UTC), &commitTime)
+	require.Equal(t, first, second)
+	require.NotContains(t, first[2], "startedOn")
+
+	notReproducible := build(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), nil)
+	require.Contains(t, notReproducible[2], `"startedOn": "2026-01-01T00:00:00Z"`)
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -36,25 +37,64 @@ func commonPlanFlags() []cli.Flag {
 		},
 		&cli.StringFlag{
 			Name:  "config-file",
-			Usage: "relative path to railpack config file (default: railpack.json)",
+			Usage: "relative path to railpack config file (default: railpack.json, railpack.yaml, or railpack.toml)",
 		},
 		&cli.BoolFlag{
 			Name:  "error-missing-start",
 			Usage: "error if no start command is found",
 		},
+		&cli.BoolFlag{
+			Name:  "locked",
+			Usage: "error if the resolved package versions differ from railpack.lock",
+		},
+		&cli.StringFlag{
+			Name:    "version-index",
+			Usage:   "resolve package versions offline from a version index file or directory (see 'railpack versions sync')",
+			Sources: cli.EnvVars("RAILPACK_VERSION_INDEX"),
+		},
+		&cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "fail if generating the plan takes longer than this (e.g. 30s). 0 means no timeout",
+		},
 	}
 }
 
-func GenerateBuildResultForCommand(cmd *cli.Command) (*core.BuildResult, *a.App, *a.Environment, error) {
+func GenerateBuildResultForCommand(ctx context.Context, cmd *cli.Command) (*core.BuildResult, *a.App, *a.Environment, error) {
+	app, env, err := getAppAndEnvForCommand(cmd)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ctx, cancel := planContext(ctx, cmd)
+	defer cancel()
+
+	buildResult := core.GenerateBuildPlan(ctx, app, env, getGenerateOptionsForCommand(cmd))
+
+	return buildResult, app, env, nil
+}
+
+// planContext limits plan generation to the --timeout of the command
+func planContext(ctx context.Context, cmd *cli.Command) (context.Context, context.CancelFunc) {
+	if timeout := cmd.Duration("timeout"); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+func getAppAndEnvForCommand(cmd *cli.Command) (*a.App, *a.Environment, error) {
 	directory := cmd.Args().First()
 
 	if directory == "" {
-		return nil, nil, nil, cli.Exit("directory argument is required", 1)
+		return nil, nil, cli.Exit("directory argument is required", 1)
 	}
 
+	return getAppAndEnvForDirectory(cmd, directory)
+}
+
+func getAppAndEnvForDirectory(cmd *cli.Command, directory string) (*a.App, *a.Environment, error) {
 	app, err := a.NewApp(directory)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("error creating app: %w", err)
+		return nil, nil, fmt.Errorf("error creating app: %w", err)
 	}
 
 	log.Debugf("Building %s", app.Source)
@@ -63,7 +103,7 @@ func GenerateBuildResultForCommand(cmd *cli.Command) (*core.BuildResult, *a.App,
 
 	env, err := a.FromEnvs(envsArgs)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("error creating env: %w", err)
+		return nil, nil, fmt.Errorf("error creating env: %w", err)
 	}
 
 	// if --verbose is passed as a CLI global argument, enable verbose mise logging so the user don't have to understand
@@ -72,20 +112,23 @@ func GenerateBuildResultForCommand(cmd *cli.Command) (*core.BuildResult, *a.App,
 		env.SetVariable("MISE_VERBOSE", "1")
 	}
 
+	return app, env, nil
+}
+
+func getGenerateOptionsForCommand(cmd *cli.Command) *core.GenerateBuildPlanOptions {
 	previousVersions := utils.ParsePackageWithVersion(cmd.StringSlice("previous"))
 
-	generateOptions := &core.GenerateBuildPlanOptions{
+	return &core.GenerateBuildPlanOptions{
 		RailpackVersion:          Version,
 		BuildCommand:             cmd.String("build-cmd"),
 		StartCommand:             cmd.String("start-cmd"),
 		PreviousVersions:         previousVersions,
 		ConfigFilePath:           cmd.String("config-file"),
 		ErrorMissingStartCommand: cmd.Bool("error-missing-start"),
+		Locked:                   cmd.Bool("locked"),
+		IgnoreLockFile:           cmd.Bool("write-lock"),
+		VersionIndex:             cmd.String("version-index"),
 	}
-
-	buildResult := core.GenerateBuildPlan(app, env, generateOptions)
-
-	return buildResult, app, env, nil
 }
 
 // add $schema link to resulting map JSON for improved IDE experience when manually editing
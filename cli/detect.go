@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/railwayapp/railpack/core"
+	"github.com/urfave/cli/v3"
+)
+
+var DetectCommand = &cli.Command{
+	Name:                  "detect",
+	Usage:                 "list every provider that detects the app with its score and the files it was detected by",
+	ArgsUsage:             "DIRECTORY",
+	EnableShellCompletion: true,
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:    "env",
+			Aliases: []string{"e"},
+			Usage:   "environment variables to set",
+		},
+		&cli.StringFlag{
+			Name:  "config-file",
+			Usage: "relative path to railpack config file (default: railpack.json, railpack.yaml, or railpack.toml)",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "output format. one of: pretty, json",
+			Value: "pretty",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		app, env, err := getAppAndEnvForCommand(cmd)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		detections, err := core.DetectProviders(ctx, app, env, &core.GenerateBuildPlanOptions{
+			ConfigFilePath: cmd.String("config-file"),
+		})
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		if cmd.String("format") == "json" {
+			serialized, err := json.MarshalIndent(detections, "", "  ")
+			if err != nil {
+				return cli.Exit(err, 1)
+			}
+			os.Stdout.Write(serialized)
+			os.Stdout.Write([]byte("\n"))
+			return nil
+		}
+
+		os.Stdout.Write([]byte(core.FormatDetections(detections)))
+		return nil
+	},
+}
@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/railwayapp/railpack/core"
+	"github.com/railwayapp/railpack/core/diff"
+	"github.com/urfave/cli/v3"
+)
+
+var DiffCommand = &cli.Command{
+	Name:                  "diff",
+	Usage:                 "compare two build plans. each side is a plan or build result JSON file, a directory, or a git revision",
+	ArgsUsage:             "BEFORE AFTER",
+	EnableShellCompletion: true,
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "dir",
+			Usage: "app directory used when comparing git revisions",
+			Value: ".",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "output format. one of: pretty, json",
+			Value: "pretty",
+		},
+		&cli.BoolFlag{
+			Name:  "exit-code",
+			Usage: "exit with status 1 if the plans differ",
+		},
+	}, commonPlanFlags()...),
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		if cmd.Args().Len() != 2 {
+			return cli.Exit("BEFORE and AFTER arguments are required", 1)
+		}
+
+		before, err := loadBuildResultForDiff(ctx, cmd, cmd.Args().Get(0))
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		after, err := loadBuildResultForDiff(ctx, cmd, cmd.Args().Get(1))
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		planDiff := diff.Diff(before, after)
+
+		if cmd.String("format") == "json" {
+			serialized, err := json.MarshalIndent(planDiff, "", "  ")
+			if err != nil {
+				return cli.Exit(err, 1)
+			}
+			os.Stdout.Write(serialized)
+			os.Stdout.Write([]byte("\n"))
+		} else {
+			os.Stdout.Write([]byte(diff.Format(planDiff)))
+		}
+
+		if cmd.Bool("exit-code") && !planDiff.IsEmpty() {
+			return cli.Exit("", 1)
+		}
+
+		return nil
+	},
+}
+
+// loadBuildResultForDiff reads a plan from a JSON file, generates it from a directory, or generates it from a git revision
+func loadBuildResultForDiff(ctx context.Context, cmd *cli.Command, source string) (*core.BuildResult, error) {
+	if info, err := os.Stat(source); err == nil {
+		if !info.IsDir() {
+			return diff.LoadBuildResult(source)
+		}
+		return generateBuildResultForDiff(ctx, cmd, source)
+	}
+
+	dir, err := exportGitRevision(ctx, cmd.String("dir"), source)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a file, directory, or git revision: %w", source, err)
+	}
+	defer os.RemoveAll(dir)
+
+	return generateBuildResultForDiff(ctx, cmd, dir)
+}
+
+func generateBuildResultForDiff(ctx context.Context, cmd *cli.Command, directory string) (*core.BuildResult, error) {
+	app, env, err := getAppAndEnvForDirectory(cmd, directory)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := planContext(ctx, cmd)
+	defer cancel()
+
+	buildResult := core.GenerateBuildPlan(ctx, app, env, getGenerateOptionsForCommand(cmd))
+	if !buildResult.Success {
+		core.PrettyPrintBuildResult(buildResult, core.PrintOptions{Version: Version})
+		return nil, fmt.Errorf("failed to generate a plan for %s", directory)
+	}
+
+	return buildResult, nil
+}
+
+// exportGitRevision writes the app directory at a git revision to a temporary directory
+func exportGitRevision(ctx context.Context, directory, revision string) (string, error) {
+	output, err := exec.CommandContext(ctx, "git", "-C", directory, "rev-parse", "--show-toplevel", "--show-prefix").Output()
+	if err != nil {
+		return "", gitError(err)
+	}
+
+	// --show-prefix prints nothing at the root of the repository
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	root, prefix := lines[0], ""
+	if len(lines) > 1 {
+		prefix = lines[1]
+	}
+
+	archive, err := exec.CommandContext(ctx, "git", "-C", root, "archive", "--format=tar", revision+":"+prefix).Output()
+	if err != nil {
+		return "", gitError(err)
+	}
+
+	dest, err := os.MkdirTemp("", "railpack-diff-*")
+	if err != nil {
+		return "", err
+	}
+
+	if err := extractTar(bytes.NewReader(archive), dest); err != nil {
+		os.RemoveAll(dest)
+		return "", err
+	}
+
+	return dest, nil
+}
+
+func extractTar(r io.Reader, dest string) error {
+	tr := tar.NewReader(r)
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dest, header.Name)
+		if !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0777)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(file, tr); err != nil {
+				file.Close()
+				return err
+			}
+			file.Close()
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func gitError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/log"
+	"github.com/railwayapp/railpack/core"
+	"github.com/railwayapp/railpack/dockerfile"
+	"github.com/urfave/cli/v3"
+)
+
+var DockerfileCommand = &cli.Command{
+	Name:                  "dockerfile",
+	Usage:                 "generate a standalone multi-stage Dockerfile for a directory",
+	ArgsUsage:             "DIRECTORY",
+	EnableShellCompletion: true,
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:    "out",
+			Aliases: []string{"o"},
+			Usage:   "output file name",
+		},
+		&cli.StringFlag{
+			Name:  "cache-key",
+			Usage: "Unique id to prefix to cache keys",
+		},
+	}, commonPlanFlags()...),
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		buildResult, _, _, err := GenerateBuildResultForCommand(ctx, cmd)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		if !buildResult.Success {
+			core.PrettyPrintBuildResult(buildResult, core.PrintOptions{Version: Version})
+			os.Exit(1)
+			return nil
+		}
+
+		contents, err := dockerfile.ConvertPlanToDockerfile(buildResult.Plan, dockerfile.ConvertPlanOptions{
+			CacheKey: cmd.String("cache-key"),
+		})
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		output := cmd.String("out")
+		if output == "" {
+			// Write to stdout if no output file specified
+			os.Stdout.Write([]byte(contents))
+			return nil
+		}
+
+		if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		if err := os.WriteFile(output, []byte(contents), 0644); err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		log.Infof("Dockerfile written to %s", output)
+
+		return nil
+	},
+}
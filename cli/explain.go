@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/railwayapp/railpack/core"
+	"github.com/urfave/cli/v3"
+)
+
+var ExplainCommand = &cli.Command{
+	Name:                  "explain",
+	Usage:                 "show where every step, command, variable, and cache in the plan came from",
+	ArgsUsage:             "DIRECTORY [STEP]",
+	EnableShellCompletion: true,
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "output format. one of: pretty, json",
+			Value: "pretty",
+		},
+	}, commonPlanFlags()...),
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		buildResult, _, _, err := GenerateBuildResultForCommand(ctx, cmd)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		if !buildResult.Success {
+			core.PrettyPrintBuildResult(buildResult, core.PrintOptions{Version: Version})
+			os.Exit(1)
+			return nil
+		}
+
+		stepName := cmd.Args().Get(1)
+
+		if cmd.String("format") == "json" {
+			var value any = buildResult.Provenance
+			if stepName == "deploy" {
+				value = buildResult.Provenance.Deploy
+			} else if stepName != "" {
+				step, ok := buildResult.Provenance.Steps[stepName]
+				if !ok {
+					return cli.Exit(fmt.Sprintf("step %q not found in plan", stepName), 1)
+				}
+				value = step
+			}
+
+			serialized, err := json.MarshalIndent(value, "", "  ")
+			if err != nil {
+				return cli.Exit(err, 1)
+			}
+			os.Stdout.Write(serialized)
+			os.Stdout.Write([]byte("\n"))
+			return nil
+		}
+
+		output, err := core.FormatProvenance(buildResult, stepName)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		os.Stdout.Write([]byte(output))
+		return nil
+	},
+}
@@ -11,7 +11,7 @@ var FrontendCommand = &cli.Command{
 	Name:  "frontend",
 	Usage: "Start the BuildKit GRPC frontend server",
 	Action: func(ctx context.Context, cmd *cli.Command) error {
-		buildkit.StartFrontend()
+		buildkit.StartFrontend(Version)
 
 		return nil
 	},
@@ -29,7 +29,7 @@ var InfoCommand = &cli.Command{
 		},
 	}, commonPlanFlags()...),
 	Action: func(ctx context.Context, cmd *cli.Command) error {
-		buildResult, _, _, err := GenerateBuildResultForCommand(cmd)
+		buildResult, _, _, err := GenerateBuildResultForCommand(ctx, cmd)
 		if err != nil {
 			return cli.Exit(err, 1)
 		}
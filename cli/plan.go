@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 
 	"github.com/charmbracelet/log"
+	"github.com/railwayapp/railpack/core"
 	"github.com/urfave/cli/v3"
 )
 
@@ -22,23 +23,69 @@ var PlanCommand = &cli.Command{
 			Aliases: []string{"o"},
 			Usage:   "output file name",
 		},
+		&cli.BoolFlag{
+			Name:  "write-lock",
+			Usage: "resolve the package versions again and write them to railpack.lock",
+		},
+		&cli.BoolFlag{
+			Name:  "all",
+			Usage: "discover every service in a monorepo and output a build result for each one",
+		},
 	}, commonPlanFlags()...),
 	Action: func(ctx context.Context, cmd *cli.Command) error {
-		buildResult, _, _, err := GenerateBuildResultForCommand(cmd)
-		if err != nil {
-			return cli.Exit(err, 1)
-		}
+		var buildResultString []byte
 
-		// Include $schema in the generated plan JSON for editor support
-		planMap, err := addSchemaToPlanMap(buildResult.Plan)
-		if err != nil {
-			return cli.Exit(err, 1)
-		}
-		serializedPlan, err := json.MarshalIndent(planMap, "", "  ")
-		if err != nil {
-			return cli.Exit(err, 1)
+		if cmd.Bool("all") {
+			if cmd.Bool("write-lock") {
+				return cli.Exit("--write-lock cannot be used with --all", 1)
+			}
+
+			app, env, err := getAppAndEnvForCommand(cmd)
+			if err != nil {
+				return cli.Exit(err, 1)
+			}
+
+			ctx, cancel := planContext(ctx, cmd)
+			defer cancel()
+
+			serviceResults, err := core.GenerateServiceBuildPlans(ctx, app, env, getGenerateOptionsForCommand(cmd))
+			if err != nil {
+				return cli.Exit(err, 1)
+			}
+
+			buildResultString, err = json.MarshalIndent(serviceResults, "", "  ")
+			if err != nil {
+				return cli.Exit(err, 1)
+			}
+		} else {
+			buildResult, app, _, err := GenerateBuildResultForCommand(ctx, cmd)
+			if err != nil {
+				return cli.Exit(err, 1)
+			}
+
+			if cmd.Bool("write-lock") {
+				if !buildResult.Success {
+					core.PrettyPrintBuildResult(buildResult, core.PrintOptions{Version: Version})
+					return cli.Exit("failed to generate a plan, railpack.lock was not written", 1)
+				}
+
+				lockFilePath, err := core.WriteLockFile(app, buildResult)
+				if err != nil {
+					return cli.Exit(err, 1)
+				}
+				log.Infof("Lock file written to %s", lockFilePath)
+			}
+
+			// Include $schema in the generated plan JSON for editor support
+			planMap, err := addSchemaToPlanMap(buildResult.Plan)
+			if err != nil {
+				return cli.Exit(err, 1)
+			}
+			buildResultString, err = json.MarshalIndent(planMap, "", "  ")
+			if err != nil {
+				return cli.Exit(err, 1)
+			}
 		}
-		buildResultString := serializedPlan
 
 		output := cmd.String("out")
 		if output == "" {
@@ -51,8 +98,7 @@ var PlanCommand = &cli.Command{
 				return cli.Exit(err, 1)
 			}
 
-			err = os.WriteFile(output, []byte(buildResultString), 0644)
-			if err != nil {
+			if err := os.WriteFile(output, []byte(buildResultString), 0644); err != nil {
 				return cli.Exit(err, 1)
 			}
 
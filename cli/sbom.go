@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/railwayapp/railpack/buildkit"
+	"github.com/railwayapp/railpack/core"
+	a "github.com/railwayapp/railpack/core/app"
+	"github.com/railwayapp/railpack/core/sbom"
+	"github.com/urfave/cli/v3"
+)
+
+// The in-toto predicate type and file name of each SBOM format when attached to an image
+var sbomAttestationTypes = map[string]struct {
+	predicateType string
+	name          string
+}{
+	sbom.FormatSPDX:      {"https://spdx.dev/Document", "sbom.spdx.json"},
+	sbom.FormatCycloneDX: {"https://cyclonedx.org/bom", "sbom.cdx.json"},
+}
+
+var SbomCommand = &cli.Command{
+	Name:                  "sbom",
+	Usage:                 "generate a software bill of materials for the image of an app",
+	ArgsUsage:             "DIRECTORY",
+	EnableShellCompletion: true,
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Usage:   "SBOM format. one of: " + strings.Join(sbom.Formats, ", "),
+			Value:   sbom.FormatSPDX,
+		},
+		&cli.StringFlag{
+			Name:    "out",
+			Aliases: []string{"o"},
+			Usage:   "output file name",
+		},
+	}, commonPlanFlags()...),
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		format := cmd.String("format")
+		if !slices.Contains(sbom.Formats, format) {
+			return cli.Exit(fmt.Sprintf("unknown SBOM format %q. Must be one of: %s", format, strings.Join(sbom.Formats, ", ")), 1)
+		}
+
+		buildResult, app, _, err := GenerateBuildResultForCommand(ctx, cmd)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		if !buildResult.Success {
+			core.PrettyPrintBuildResult(buildResult, core.PrintOptions{Version: Version})
+			return cli.Exit("failed to generate a plan, the SBOM was not generated", 1)
+		}
+
+		doc, err := sbom.Generate(app, buildResult)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		data, err := doc.Marshal(format)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		output := cmd.String("out")
+		if output == "" {
+			os.Stdout.Write(data)
+			os.Stdout.Write([]byte("\n"))
+			return nil
+		}
+
+		if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		if err := os.WriteFile(output, data, 0644); err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		log.Infof("SBOM written to %s", output)
+		return nil
+	},
+}
+
+// sbomAttestations generates the SBOM in every format as attestations to attach to the image created at the given time
+func sbomAttestations(app *a.App, buildResult *core.BuildResult, created time.Time) ([]buildkit.Attestation, error) {
+	doc, err := sbom.Generate(app, buildResult)
+	if err != nil {
+		return nil, err
+	}
+	doc.Created = created.UTC()
+
+	attestations := []buildkit.Attestation{}
+	for _, format := range sbom.Formats {
+		data, err := doc.Marshal(format)
+		if err != nil {
+			return nil, err
+		}
+
+		attestationType := sbomAttestationTypes[format]
+		attestations = append(attestations, buildkit.Attestation{
+			PredicateType: attestationType.predicateType,
+			Name:          attestationType.name,
+			Reason:        "sbom",
+			Predicate:     data,
+		})
+	}
+
+	return attestations, nil
+}
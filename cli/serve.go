@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/railwayapp/railpack/core/mise"
+	"github.com/railwayapp/railpack/server"
+	"github.com/urfave/cli/v3"
+)
+
+var ServeCommand = &cli.Command{
+	Name:                  "serve",
+	Usage:                 "start an HTTP API that generates build plans",
+	EnableShellCompletion: true,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "addr",
+			Usage:   "address to listen on",
+			Value:   ":8080",
+			Sources: cli.EnvVars("RAILPACK_SERVE_ADDR"),
+		},
+		&cli.StringSliceFlag{
+			Name:  "allow-path",
+			Usage: "directory on the server that apps can be planned from by path. apps can only be uploaded as archives if not set",
+		},
+		&cli.StringFlag{
+			Name:    "version-index",
+			Usage:   "resolve package versions offline from a version index file or directory (see 'railpack versions sync')",
+			Sources: cli.EnvVars("RAILPACK_VERSION_INDEX"),
+		},
+		&cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "fail a plan request if generating the plan takes longer than this",
+			Value: server.DefaultTimeout,
+		},
+		&cli.IntFlag{
+			Name:  "max-archive-size",
+			Usage: "largest archive in bytes that can be uploaded",
+			Value: server.DefaultMaxArchiveSize,
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		// Install mise before the first request instead of while planning it
+		if cmd.String("version-index") == "" {
+			if _, err := mise.New(ctx, mise.InstallDir); err != nil {
+				log.Warnf("Failed to install mise: %s", err)
+			}
+		}
+
+		srv := &http.Server{
+			Addr: cmd.String("addr"),
+			Handler: server.New(server.Options{
+				RailpackVersion: Version,
+				AllowedPaths:    cmd.StringSlice("allow-path"),
+				VersionIndex:    cmd.String("version-index"),
+				Timeout:         cmd.Duration("timeout"),
+				MaxArchiveSize:  cmd.Int("max-archive-size"),
+			}),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		errCh := make(chan error, 1)
+		go func() {
+			log.Infof("Listening on %s", srv.Addr)
+			errCh <- srv.ListenAndServe()
+		}()
+
+		select {
+		case err := <-errCh:
+			return cli.Exit(err, 1)
+		case <-ctx.Done():
+		}
+
+		log.Info("Shutting down")
+
+		// Plans that are in progress are given time to finish
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cmd.Duration("timeout"))
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return cli.Exit(err, 1)
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/charmbracelet/log"
+	"github.com/railwayapp/railpack/core/mise"
+	"github.com/railwayapp/railpack/core/resolver"
+	"github.com/urfave/cli/v3"
+)
+
+// packages installed by the providers, synced when no packages are given
+var defaultSyncPackages = []string{
+	"bun", "caddy", "cmake", "deno", "dotnet", "elixir", "erlang", "gleam", "go", "gradle", "java", "maven",
+	"meson", "ninja", "node", "php", "pipx", "pipx:pdm", "pipx:pipenv", "pipx:poetry", "pnpm", "python",
+	"ruby", "rust", "uv", "yarn",
+}
+
+var VersionsCommand = &cli.Command{
+	Name:  "versions",
+	Usage: "manage the version index used to resolve package versions offline",
+	Commands: []*cli.Command{
+		{
+			Name:                  "sync",
+			Usage:                 "fetch the available versions of packages and write them to a version index",
+			ArgsUsage:             "[PACKAGE...]",
+			EnableShellCompletion: true,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "out",
+					Aliases: []string{"o"},
+					Usage:   "version index file to write. existing packages in the file are kept",
+					Value:   resolver.VersionIndexFileName,
+				},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				packages := cmd.Args().Slice()
+				if len(packages) == 0 {
+					packages = defaultSyncPackages
+				}
+
+				out := cmd.String("out")
+				index := resolver.NewVersionIndex()
+				if _, err := os.Stat(out); err == nil {
+					existing, err := resolver.ReadVersionIndex(out)
+					if err != nil {
+						return cli.Exit(err, 1)
+					}
+					index = existing
+				}
+
+				miseInstance, err := mise.New(ctx, mise.InstallDir)
+				if err != nil {
+					return cli.Exit(err, 1)
+				}
+
+				var errs []error
+				for _, pkg := range packages {
+					versions, err := miseInstance.ListVersions(ctx, pkg)
+					if err != nil {
+						errs = append(errs, err)
+						continue
+					}
+
+					log.Infof("Synced %d versions of %s", len(versions), pkg)
+					index.Packages[pkg] = versions
+				}
+
+				if err := index.Write(out); err != nil {
+					return cli.Exit(err, 1)
+				}
+
+				log.Infof("Version index written to %s", out)
+
+				if len(errs) > 0 {
+					return cli.Exit(errors.Join(errs...), 1)
+				}
+
+				return nil
+			},
+		},
+	},
+}
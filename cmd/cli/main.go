@@ -40,7 +40,15 @@ func main() {
 		cli.PrepareCommand,
 		cli.InfoCommand,
 		cli.PlanCommand,
+		cli.DockerfileCommand,
+		cli.AffectedCommand,
+		cli.ExplainCommand,
+		cli.DetectCommand,
+		cli.DiffCommand,
+		cli.SbomCommand,
+		cli.VersionsCommand,
 		cli.SchemaCommand,
+		cli.ServeCommand,
 		cli.FrontendCommand,
 	}
 
@@ -4,10 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/BurntSushi/toml"
 	"github.com/bmatcuk/doublestar/v4"
@@ -16,10 +19,18 @@ import (
 )
 
 type App struct {
-	Source    string
+	// The directory of the app, or the name of an app created from a file system
+	Source string
+
+	fs  fs.FS
+	dir string
+
+	// Apps can be read by concurrent plans (e.g. when discovering services)
+	globMu    sync.RWMutex
 	globCache map[string][]string
 }
 
+// NewApp creates an app from a directory on disk
 func NewApp(path string) (*App, error) {
 	var source string
 
@@ -43,10 +54,62 @@ func NewApp(path string) (*App, error) {
 		return nil, fmt.Errorf("failed to check directory %s: %w", source, err)
 	}
 
+	app := NewAppFromFS(os.DirFS(source), source)
+	app.dir = source
+	return app, nil
+}
+
+// NewAppFromFS creates an app from a file system (e.g. a zip archive, a git tree, or an fstest.MapFS).
+// The name is used as the source of the app
+func NewAppFromFS(fsys fs.FS, name string) *App {
 	return &App{
-		Source:    source,
+		Source:    name,
+		fs:        fsys,
 		globCache: make(map[string][]string),
-	}, nil
+	}
+}
+
+// FS returns the file system of the app
+func (a *App) FS() fs.FS {
+	return a.fs
+}
+
+// Dir returns the directory of an app on disk, or an empty string if the app was created from a file system
+func (a *App) Dir() string {
+	return a.dir
+}
+
+// Sub creates an app from a subdirectory of the app
+func (a *App) Sub(dir string) (*App, error) {
+	if a.dir != "" {
+		return NewApp(filepath.Join(a.dir, dir))
+	}
+
+	name, ok := fsPath(dir)
+	if !ok {
+		return nil, fmt.Errorf("invalid directory %s", dir)
+	}
+
+	info, err := fs.Stat(a.fs, name)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("directory %s does not exist", path.Join(a.Source, name))
+	}
+
+	sub, err := fs.Sub(a.fs, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAppFromFS(sub, path.Join(a.Source, name)), nil
+}
+
+// fsPath converts a path relative to the app to a file system path (e.g. ./src/ is src)
+func fsPath(name string) (string, bool) {
+	name = strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "/")
+	if name == "" {
+		name = "."
+	}
+	return name, fs.ValidPath(name)
 }
 
 // findMatches returns a list of paths matching a glob pattern, filtered by isDir
@@ -59,9 +122,7 @@ func (a *App) findMatches(pattern string, isDir bool) ([]string, error) {
 
 	var paths []string
 	for _, match := range matches {
-		fullPath := filepath.Join(a.Source, match)
-
-		info, err := os.Stat(fullPath)
+		info, err := fs.Stat(a.fs, match)
 		if err != nil {
 			continue
 		}
@@ -85,25 +146,33 @@ func (a *App) FindDirectories(pattern string) ([]string, error) {
 
 // findGlob finds paths matching a glob pattern, with caching
 func (a *App) findGlob(pattern string) ([]string, error) {
-	if cached, ok := a.globCache[pattern]; ok {
+	a.globMu.RLock()
+	cached, ok := a.globCache[pattern]
+	a.globMu.RUnlock()
+	if ok {
 		return cached, nil
 	}
 
-	matches, err := doublestar.Glob(os.DirFS(a.Source), pattern)
+	matches, err := doublestar.Glob(a.fs, pattern)
 	if err != nil {
 		return nil, err
 	}
 
+	a.globMu.Lock()
 	a.globCache[pattern] = matches
+	a.globMu.Unlock()
 	return matches, nil
 }
 
 // Check if a relative file exists in the app's source directory
 func (a *App) HasFile(path string) bool {
-	fullPath := filepath.Join(a.Source, path)
+	name, ok := fsPath(path)
+	if !ok {
+		return false
+	}
 
-	_, err := os.Stat(fullPath)
-	return !os.IsNotExist(err)
+	_, err := fs.Stat(a.fs, name)
+	return !errors.Is(err, fs.ErrNotExist)
 }
 
 // HasMatch checks if a path matching a glob exists (files or directories)
@@ -144,11 +213,14 @@ func (a *App) FindFilesWithContent(pattern string, regex *regexp.Regexp) []strin
 
 // ReadFile reads the contents of a file within the application source directory
 func (a *App) ReadFile(name string) (string, error) {
-	path := filepath.Join(a.Source, name)
-	data, err := os.ReadFile(path)
+	path, ok := fsPath(name)
+	if !ok {
+		return "", fmt.Errorf("error reading %s: %w", name, fs.ErrInvalid)
+	}
+
+	data, err := fs.ReadFile(a.fs, path)
 	if err != nil {
-		relativePath, _ := a.stripSourcePath(path)
-		return "", fmt.Errorf("error reading %s: %w", relativePath, err)
+		return "", fmt.Errorf("error reading %s: %w", path, err)
 	}
 
 	return strings.ReplaceAll(string(data), "\r\n", "\n"), nil
@@ -169,7 +241,7 @@ func (a *App) ReadJSON(name string, v interface{}) error {
 	data = string(jsonBytes)
 
 	if err := json.Unmarshal([]byte(data), v); err != nil {
-		relativePath, _ := a.stripSourcePath(filepath.Join(a.Source, name))
+		relativePath, _ := fsPath(name)
 		return fmt.Errorf("error reading %s as JSON: %w", relativePath, err)
 	}
 
@@ -202,8 +274,12 @@ func (a *App) ReadTOML(name string, v interface{}) error {
 
 // IsFileExecutable checks if a path is an executable file
 func (a *App) IsFileExecutable(name string) bool {
-	path := filepath.Join(a.Source, name)
-	info, err := os.Stat(path)
+	path, ok := fsPath(name)
+	if !ok {
+		return false
+	}
+
+	info, err := fs.Stat(a.fs, path)
 	if err != nil {
 		return false
 	}
@@ -215,12 +291,3 @@ func (a *App) IsFileExecutable(name string) bool {
 	// Check executable bit
 	return info.Mode()&0111 != 0
 }
-
-// StripSourcePath converts an absolute path to a path relative to the app source directory
-func (a *App) stripSourcePath(absPath string) (string, error) {
-	rel, err := filepath.Rel(a.Source, absPath)
-	if err != nil {
-		return "", errors.New("failed to parse source path")
-	}
-	return rel, nil
-}
@@ -3,7 +3,9 @@ package app
 import (
 	"path/filepath"
 	"regexp"
+	"sync"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/require"
 )
@@ -85,3 +87,82 @@ func TestFindFilesWithContent(t *testing.T) {
 	matches = app.FindFilesWithContent("[invalid", regex)
 	require.Empty(t, matches)
 }
+
+func TestAppFromFS(t *testing.T) {
+	app := NewAppFromFS(fstest.MapFS{
+		"package.json":           {Data: []byte(`{"name": "app"}`)},
+		"bin/start.sh":           {Data: []byte("#!/bin/sh"), Mode: 0755},
+		"assets/package.json":    {Data: []byte(`{"name": "assets"}`)},
+		"assets/src/index.ts":    {Data: []byte("export {}")},
+		"assets/src/styles.css":  {Data: []byte("body {}")},
+		"assets/src/windows.txt": {Data: []byte("a\r\nb")},
+	}, "app")
+
+	require.Equal(t, "app", app.Source)
+	require.Empty(t, app.Dir())
+
+	require.True(t, app.HasFile("package.json"))
+	require.True(t, app.HasFile("./bin/start.sh"))
+	require.False(t, app.HasFile("missing.json"))
+	require.False(t, app.HasFile("../package.json"))
+
+	require.True(t, app.IsFileExecutable("bin/start.sh"))
+	require.False(t, app.IsFileExecutable("package.json"))
+
+	var packageJSON PackageJSON
+	require.NoError(t, app.ReadJSON("package.json", &packageJSON))
+	require.Equal(t, "app", packageJSON.Name)
+
+	files, err := app.FindFiles("**/*.ts")
+	require.NoError(t, err)
+	require.Equal(t, []string{"assets/src/index.ts"}, files)
+
+	dirs, err := app.FindDirectories("*")
+	require.NoError(t, err)
+	require.Equal(t, []string{"assets", "bin"}, dirs)
+
+	assets, err := app.Sub("assets")
+	require.NoError(t, err)
+	require.Equal(t, "app/assets", assets.Source)
+	require.True(t, assets.HasFile("src/styles.css"))
+
+	content, err := assets.ReadFile("src/windows.txt")
+	require.NoError(t, err)
+	require.Equal(t, "a\nb", content)
+
+	_, err = app.Sub("missing")
+	require.Error(t, err)
+
+	_, err = app.ReadFile("missing.json")
+	require.ErrorContains(t, err, "error reading missing.json")
+}
+
+func TestAppSubOnDisk(t *testing.T) {
+	app, err := NewApp("../../examples")
+	require.NoError(t, err)
+
+	sub, err := app.Sub("node-bun")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(app.Dir(), "node-bun"), sub.Dir())
+	require.True(t, sub.HasFile("package.json"))
+}
+
+func TestAppConcurrentFindFiles(t *testing.T) {
+	app := NewAppFromFS(fstest.MapFS{
+		"src/index.ts": {Data: []byte("export {}")},
+		"src/app.ts":   {Data: []byte("export {}")},
+		"README.md":    {Data: []byte("# app")},
+	}, "app")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(pattern string) {
+			defer wg.Done()
+			files, err := app.FindFiles(pattern)
+			require.NoError(t, err)
+			require.NotEmpty(t, files)
+		}([]string{"**/*.ts", "*.md"}[i%2])
+	}
+	wg.Wait()
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"reflect"
 
 	"github.com/invopop/jsonschema"
 	"github.com/railwayapp/railpack/core/plan"
@@ -28,12 +29,16 @@ type StepConfig struct {
 
 type Config struct {
 	Provider         *string                `json:"provider,omitempty" jsonschema:"description=The provider to use"`
+	Providers        []string               `json:"providers,omitempty" jsonschema:"description=Multiple providers to compose into a single plan. The first provider is the primary provider and owns the start command"`
+	ProviderScores   map[string]int         `json:"providerScores,omitempty" jsonschema:"description=Replaces the detection score of providers that detect the app. The provider with the highest score is used. A score of 0 ignores the provider"`
+	Plugins          []string               `json:"plugins,omitempty" jsonschema:"description=External provider plugins to use. Either the name of a plugin on RAILPACK_PLUGIN_PATH or the path of an executable in the app"`
 	BuildAptPackages []string               `json:"buildAptPackages,omitempty" jsonschema:"description=List of apt packages to install during the build step"`
 	Steps            map[string]*StepConfig `json:"steps,omitempty" jsonschema:"description=Map of step names to step definitions"`
 	Deploy           *DeployConfig          `json:"deploy,omitempty" jsonschema:"description=Deploy configuration"`
 	Packages         map[string]string      `json:"packages,omitempty" jsonschema:"description=Map of package name to package version"`
 	Caches           map[string]*plan.Cache `json:"caches,omitempty" jsonschema:"description=Map of cache name to cache definitions. The cache key can be referenced in an exec command"`
 	Secrets          []string               `json:"secrets,omitempty" jsonschema:"description=Secrets that should be made available to commands that have useSecrets set to true"`
+	Extends          []string               `json:"extends,omitempty" jsonschema:"description=Config files that this config extends, relative to this config file. Later files and this config take precedence"`
 }
 
 func EmptyConfig() *Config {
@@ -76,6 +81,75 @@ func Merge(configs ...*Config) *Config {
 	return result
 }
 
+// ExpandSpreads replaces "..." in the arrays of the config with the same array of the config that it extends.
+// Arrays that base does not set keep "..." so they still add to the plan of the provider
+func ExpandSpreads(config, base *Config) {
+	expandSpreads(reflect.ValueOf(config), reflect.ValueOf(base))
+}
+
+func expandSpreads(value, base reflect.Value) {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() || base.IsNil() {
+			return
+		}
+		expandSpreads(value.Elem(), base.Elem())
+
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if value.Type().Field(i).IsExported() {
+				expandSpreads(value.Field(i), base.Field(i))
+			}
+		}
+
+	case reflect.Map:
+		if value.IsNil() || base.IsNil() {
+			return
+		}
+
+		// Only pointers in maps can be changed in place (e.g. steps)
+		for _, key := range value.MapKeys() {
+			item, baseItem := value.MapIndex(key), base.MapIndex(key)
+			if baseItem.IsValid() && item.Kind() == reflect.Ptr {
+				expandSpreads(item, baseItem)
+			}
+		}
+
+	case reflect.Slice:
+		if base.IsNil() || !hasSpread(value) {
+			return
+		}
+
+		expanded := reflect.MakeSlice(value.Type(), 0, value.Len()+base.Len())
+		for i := 0; i < value.Len(); i++ {
+			if isSpread(value.Index(i)) {
+				expanded = reflect.AppendSlice(expanded, base)
+			} else {
+				expanded = reflect.Append(expanded, value.Index(i))
+			}
+		}
+		value.Set(expanded)
+	}
+}
+
+func hasSpread(items reflect.Value) bool {
+	for i := 0; i < items.Len(); i++ {
+		if isSpread(items.Index(i)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSpread(item reflect.Value) bool {
+	if item.Kind() == reflect.String {
+		return item.String() == "..."
+	}
+
+	spreadable, ok := item.Interface().(plan.Spreadable)
+	return ok && spreadable.IsSpread()
+}
+
 func (s *StepConfig) UnmarshalJSON(data []byte) error {
 	var temp struct {
 		DeployOutputs []plan.Filter `json:"deployOutputs,omitempty"`
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/railwayapp/railpack/core/plan"
 	"github.com/stretchr/testify/require"
 )
 
@@ -314,3 +315,88 @@ func TestGetJsonSchema(t *testing.T) {
 	require.NoError(t, err)
 	require.NotEmpty(t, schemaJson)
 }
+
+func TestMergeConfigProviders(t *testing.T) {
+	config1 := EmptyConfig()
+	require.NoError(t, json.Unmarshal([]byte(`{"providers": ["python"]}`), config1))
+
+	config2 := EmptyConfig()
+	require.NoError(t, json.Unmarshal([]byte(`{"providers": ["python", "node"]}`), config2))
+
+	result := Merge(config1, config2)
+	require.Equal(t, []string{"python", "node"}, result.Providers)
+
+	result = Merge(config2, EmptyConfig())
+	require.Equal(t, []string{"python", "node"}, result.Providers)
+}
+
+func TestParseFormats(t *testing.T) {
+	jsonConfig, err := Parse([]byte(`{
+		// comments are allowed
+		"steps": {"build": {"inputs": [{"step": "install"}], "commands": ["make"]}},
+	}`), FormatOf("railpack.json"))
+	require.NoError(t, err)
+
+	yamlConfig, err := Parse([]byte(`
+steps:
+  build:
+    inputs:
+      - step: install
+    commands:
+      - make
+`), FormatOf("railpack.YML"))
+	require.NoError(t, err)
+
+	tomlConfig, err := Parse([]byte(`
+[steps.build]
+commands = ["make"]
+
+[[steps.build.inputs]]
+step = "install"
+`), FormatOf("railpack.toml"))
+	require.NoError(t, err)
+
+	if diff := cmp.Diff(jsonConfig, yamlConfig); diff != "" {
+		t.Errorf("YAML config mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(jsonConfig, tomlConfig); diff != "" {
+		t.Errorf("TOML config mismatch (-want +got):\n%s", diff)
+	}
+
+	empty, err := Parse([]byte(""), FormatYAML)
+	require.NoError(t, err)
+	require.Equal(t, EmptyConfig(), empty)
+
+	_, err = Parse([]byte("- make"), FormatYAML)
+	require.Error(t, err)
+}
+
+func TestExpandSpreads(t *testing.T) {
+	base, err := Parse([]byte(`{
+		"buildAptPackages": ["git"],
+		"steps": {"build": {"inputs": [{"step": "install"}], "commands": ["make"]}},
+		"deploy": {"paths": ["/app/bin"]}
+	}`), FormatJSON)
+	require.NoError(t, err)
+
+	config, err := Parse([]byte(`{
+		"buildAptPackages": ["curl", "..."],
+		"steps": {
+			"build": {"inputs": ["...", {"step": "assets"}], "commands": ["...", "make test"]},
+			"test": {"commands": ["...", "npm test"]}
+		},
+		"deploy": {"paths": ["/app/scripts"], "aptPackages": ["...", "ffmpeg"]}
+	}`), FormatJSON)
+	require.NoError(t, err)
+
+	ExpandSpreads(config, base)
+
+	require.Equal(t, []string{"curl", "git"}, config.BuildAptPackages)
+	require.Equal(t, []plan.Layer{plan.NewStepLayer("install"), plan.NewStepLayer("assets")}, config.Steps["build"].Inputs)
+	require.Equal(t, []plan.Command{plan.NewExecShellCommand("make"), plan.NewExecShellCommand("make test")}, config.Steps["build"].Commands)
+	require.Equal(t, []string{"/app/scripts"}, config.Deploy.Paths)
+
+	// Spreads that the base does not set are left for the plan of the provider
+	require.Equal(t, []plan.Command{plan.NewExecShellCommand("..."), plan.NewExecShellCommand("npm test")}, config.Steps["test"].Commands)
+	require.Equal(t, []string{"...", "ffmpeg"}, config.Deploy.AptPackages)
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/railwayapp/railpack/internal/utils"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// FormatOf returns the format of a config file from its extension. Files with any other extension are read as JSON
+func FormatOf(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatJSON
+	}
+}
+
+// Parse reads a config in the given format. YAML and TOML configs have the same schema as JSON configs
+func Parse(data []byte, format string) (*Config, error) {
+	var value any
+
+	switch format {
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+	case FormatTOML:
+		if err := toml.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+	default:
+		standardized, err := utils.StandardizeJSON(data)
+		if err != nil {
+			return nil, err
+		}
+
+		config := EmptyConfig()
+		if err := json.Unmarshal(standardized, config); err != nil {
+			return nil, err
+		}
+		return config, nil
+	}
+
+	if value == nil {
+		return EmptyConfig(), nil
+	}
+
+	return FromValue(value)
+}
+
+// FromValue converts a decoded YAML, TOML, or JSON object to a config.
+// The object is read like a JSON config file, so the custom parsing of commands and steps also applies
+func FromValue(value any) (*Config, error) {
+	value = normalizeValue(value)
+	if _, ok := value.(map[string]any); !ok {
+		return nil, fmt.Errorf("config must be an object, got %T", value)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	config := EmptyConfig()
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// normalizeValue converts the map[interface{}]interface{} objects that YAML decodes to map[string]any so they can be encoded as JSON
+func normalizeValue(value any) any {
+	switch v := value.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = normalizeValue(item)
+		}
+		return m
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, item := range v {
+			m[key] = normalizeValue(item)
+		}
+		return m
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = normalizeValue(item)
+		}
+		return items
+	case []map[string]any:
+		// Arrays of tables in TOML
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = normalizeValue(item)
+		}
+		return items
+	default:
+		return v
+	}
+}
@@ -0,0 +1,300 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/railwayapp/railpack/core/app"
+	c "github.com/railwayapp/railpack/core/config"
+	"github.com/railwayapp/railpack/core/logger"
+)
+
+// The config files that are read when no config file is set. Only the first one that exists is used
+var defaultConfigFileNames = []string{"railpack.json", "railpack.yaml", "railpack.yml", "railpack.toml"}
+
+// embeddedConfig is a railpack section in the manifest of another tool
+type embeddedConfig struct {
+	file string
+	keys []string
+}
+
+// Manifests that can have a railpack section, from the lowest precedence to the highest
+var embeddedConfigs = []embeddedConfig{
+	{file: "package.json", keys: []string{"railpack"}},
+	{file: "pyproject.toml", keys: []string{"tool", "railpack"}},
+	{file: "Cargo.toml", keys: []string{"package", "metadata", "railpack"}},
+}
+
+// section is how the section is written in the manifest (e.g. [tool.railpack])
+func (e embeddedConfig) section() string {
+	if c.FormatOf(e.file) == c.FormatTOML {
+		return "[" + strings.Join(e.keys, ".") + "]"
+	}
+	return fmt.Sprintf("%q key", strings.Join(e.keys, "."))
+}
+
+// configFile is a config read from a file in the app
+type configFile struct {
+	name   string
+	config *c.Config
+
+	// Set when the config is only read because another config extends it
+	extended bool
+}
+
+// readConfigFiles reads the railpack sections of manifests and the config file, from the lowest precedence to the highest
+func readConfigFiles(app *app.App, env *app.Environment, options *GenerateBuildPlanOptions, logger *logger.Logger) ([]configFile, error) {
+	files := []configFile{}
+	extendable := extendablePaths(app, options)
+
+	for _, embedded := range embeddedConfigs {
+		config, err := readEmbeddedConfig(app, embedded)
+		if err != nil {
+			return nil, err
+		}
+		if config == nil {
+			continue
+		}
+
+		logger.LogInfo("Using config from %s in `%s`", embedded.section(), embedded.file)
+		extended, err := extendConfig(app, configFile{name: embedded.file, config: config}, extendable, nil, logger)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, extended...)
+	}
+
+	configFileName, config, err := readConfigFile(app, env, options, logger)
+	if err != nil {
+		return nil, err
+	}
+	if config != nil {
+		logger.LogInfo("Using config file `%s`", configFileName)
+		extended, err := extendConfig(app, configFile{name: configFileName, config: config}, extendable, nil, logger)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, extended...)
+	}
+
+	if len(files) > 0 {
+		logger.LogWarn("The config file format is not yet finalized and subject to change.")
+	}
+
+	return files, nil
+}
+
+// readConfigFile reads the config file that is set by the options or environment, or the first default config file in the app
+func readConfigFile(app *app.App, env *app.Environment, options *GenerateBuildPlanOptions, logger *logger.Logger) (string, *c.Config, error) {
+	names := defaultConfigFileNames
+
+	// always assume config file path is relative to the app source directory
+	// https://github.com/railwayapp/railpack/pull/226
+	if configFileName := getConfigFileName(env, options); configFileName != "" {
+		// if a specific path was specified, we should indicate that it was not found and hard fail
+		if !app.HasFile(configFileName) {
+			return "", nil, fmt.Errorf("config file %q not found", filepath.Join(app.Source, configFileName))
+		}
+		names = []string{configFileName}
+	}
+
+	found := slices.DeleteFunc(slices.Clone(names), func(name string) bool { return !app.HasFile(name) })
+	if len(found) == 0 {
+		return "", nil, nil
+	}
+	if len(found) > 1 {
+		logger.LogWarn("Found multiple config files (%s), only `%s` is used", strings.Join(found, ", "), found[0])
+	}
+
+	// if a config file was provided, we should hard fail if we cannot parse it
+	configFileName := found[0]
+	data, err := app.ReadFile(configFileName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	config, err := parseConfigFile(configFileName, []byte(data), logger)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return configFileName, config, nil
+}
+
+// parseConfigFile parses a config file in the format of its extension
+func parseConfigFile(name string, data []byte, logger *logger.Logger) (*c.Config, error) {
+	format := c.FormatOf(name)
+	config, err := c.Parse(data, format)
+	if err != nil {
+		logger.LogWarn("Failed to read config file `%s`\nUse the following schema to validate your config file: %s\n", name, c.SchemaUrl)
+		return nil, fmt.Errorf("error reading %s as %s: %w", name, strings.ToUpper(format), err)
+	}
+
+	return config, nil
+}
+
+// extendConfig returns the configs that a config file extends, from the lowest precedence to the highest, followed by the config itself.
+// extendable is the directories outside of the app that configs can extend (see extendablePaths).
+// chain is the files that extend the config, so that a file that extends itself fails instead of recursing forever
+func extendConfig(app *app.App, file configFile, extendable []string, chain []string, logger *logger.Logger) ([]configFile, error) {
+	chain = append(slices.Clone(chain), file.name)
+
+	files := []configFile{}
+	for _, extends := range file.config.Extends {
+		name := extends
+		if !path.IsAbs(name) {
+			name = path.Join(path.Dir(file.name), name)
+		}
+
+		if slices.Contains(chain, name) {
+			return nil, fmt.Errorf("config file %s extends itself: %s", name, strings.Join(append(chain, name), " -> "))
+		}
+
+		data, err := readExtendedConfigFile(app, name, extendable)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s extends %s: %w", file.name, extends, err)
+		}
+
+		config, err := parseConfigFile(name, data, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		logger.LogInfo("Using config file `%s` extended by `%s`", name, file.name)
+		extended, err := extendConfig(app, configFile{name: name, config: config, extended: true}, extendable, chain, logger)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, extended...)
+	}
+
+	if len(files) > 0 {
+		base := c.EmptyConfig()
+		for _, extended := range files {
+			base = c.Merge(base, extended.config)
+		}
+		c.ExpandSpreads(file.config, base)
+	}
+	file.config.Extends = nil
+
+	return append(files, file), nil
+}
+
+// readExtendedConfigFile reads a config file that another config extends.
+// Files outside of the app (e.g. presets shared by a monorepo) can only be read when the app is on disk,
+// and only from the extendable directories
+func readExtendedConfigFile(app *app.App, name string, extendable []string) ([]byte, error) {
+	if filepath.IsLocal(name) {
+		if !app.HasFile(name) {
+			return nil, fmt.Errorf("%s not found", name)
+		}
+		data, err := app.ReadFile(name)
+		return []byte(data), err
+	}
+
+	if app.Dir() == "" {
+		return nil, fmt.Errorf("%s is outside of the app", name)
+	}
+
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(app.Dir(), filepath.FromSlash(name))
+	}
+
+	// Symlinks are resolved so that a link in the repository cannot point outside of it
+	resolved, err := filepath.EvalSymlinks(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s not found", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.ContainsFunc(extendable, func(dir string) bool { return isInDir(dir, resolved) }) {
+		return nil, fmt.Errorf("%s is outside of the git repository of the app and the allowed paths", name)
+	}
+
+	return os.ReadFile(resolved)
+}
+
+// extendablePaths returns the directories outside of the app that config files can extend.
+// These are the allowed paths of the options when set, or else the git repository that the app is in
+func extendablePaths(app *app.App, options *GenerateBuildPlanOptions) []string {
+	if options.ExtendsAllowedPaths != nil {
+		return options.ExtendsAllowedPaths
+	}
+
+	if app.Dir() == "" {
+		return nil
+	}
+
+	for dir := app.Dir(); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return []string{dir}
+		}
+		if dir == filepath.Dir(dir) {
+			return nil
+		}
+	}
+}
+
+// isInDir checks if the path is the directory or inside of it, after resolving the symlinks of the directory
+func isInDir(dir string, path string) bool {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+
+	rel, err := filepath.Rel(filepath.Clean(dir), path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// readEmbeddedConfig returns the railpack section of a manifest, or nil if the manifest or section does not exist.
+// Manifests that cannot be parsed are skipped, since the provider that uses them reports the error
+func readEmbeddedConfig(app *app.App, embedded embeddedConfig) (*c.Config, error) {
+	if !app.HasFile(embedded.file) {
+		return nil, nil
+	}
+
+	var manifest any
+	if c.FormatOf(embedded.file) == c.FormatTOML {
+		if err := app.ReadTOML(embedded.file, &manifest); err != nil {
+			return nil, nil
+		}
+	} else if err := app.ReadJSON(embedded.file, &manifest); err != nil {
+		return nil, nil
+	}
+
+	section := manifest
+	for _, key := range embedded.keys {
+		table, ok := section.(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		if section, ok = table[key]; !ok {
+			return nil, nil
+		}
+	}
+
+	config, err := c.FromValue(section)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s in %s: %w", embedded.section(), embedded.file, err)
+	}
+
+	return config, nil
+}
+
+// getConfigFileName returns the config file that is set by the options or environment, or an empty string if none is set
+func getConfigFileName(env *app.Environment, options *GenerateBuildPlanOptions) string {
+	configFileName := options.ConfigFilePath
+
+	if envConfigFileName, _ := env.GetConfigVariable("CONFIG_FILE"); envConfigFileName != "" {
+		configFileName = envConfigFileName
+	}
+
+	return configFileName
+}
@@ -1,29 +1,25 @@
 package core
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"maps"
-	"os"
-	"path/filepath"
 	"slices"
+	"strings"
 
-	"github.com/charmbracelet/log"
 	"github.com/railwayapp/railpack/core/app"
 	c "github.com/railwayapp/railpack/core/config"
 	"github.com/railwayapp/railpack/core/generate"
 	"github.com/railwayapp/railpack/core/logger"
 	"github.com/railwayapp/railpack/core/plan"
 	"github.com/railwayapp/railpack/core/providers"
+	"github.com/railwayapp/railpack/core/providers/plugin"
 	"github.com/railwayapp/railpack/core/providers/procfile"
 	"github.com/railwayapp/railpack/core/resolver"
 	"github.com/railwayapp/railpack/internal/utils"
 )
 
-const (
-	defaultConfigFileName = "railpack.json"
-)
-
 type GenerateBuildPlanOptions struct {
 	RailpackVersion          string
 	BuildCommand             string
@@ -31,92 +27,119 @@ type GenerateBuildPlanOptions struct {
 	PreviousVersions         map[string]string
 	ConfigFilePath           string
 	ErrorMissingStartCommand bool
+
+	// Fail if the resolved packages differ from the lock file
+	Locked bool
+
+	// Resolve the requested versions again instead of using the versions in the lock file, so that the lock file can be updated
+	IgnoreLockFile bool
+
+	// Path to a version index file or directory used to resolve versions without mise
+	VersionIndex string
+
+	// The provider to plan the app with instead of detecting it. A provider in the config file takes precedence
+	Provider string
+
+	// Directories outside of the app that config files can extend.
+	// Defaults to the git repository that the app is in when nil
+	ExtendsAllowedPaths []string
 }
 
 type BuildResult struct {
 	RailpackVersion   string                               `json:"railpackVersion,omitempty"`
 	Plan              *plan.BuildPlan                      `json:"plan,omitempty"`
 	ResolvedPackages  map[string]*resolver.ResolvedPackage `json:"resolvedPackages,omitempty"`
+	AptPackages       map[string][]string                  `json:"aptPackages,omitempty"`
+	Provenance        *generate.Provenance                 `json:"provenance,omitempty"`
 	Metadata          map[string]string                    `json:"metadata,omitempty"`
 	DetectedProviders []string                             `json:"detectedProviders,omitempty"`
+	Detections        []*providers.Detection               `json:"detections,omitempty"`
 	Logs              []logger.Msg                         `json:"logs,omitempty"`
 	Success           bool                                 `json:"success,omitempty"`
-}
-
-func readConfigJSON(path string, v interface{}) error {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return err
-	}
-
-	jsonBytes, err := utils.StandardizeJSON([]byte(data))
-	if err != nil {
-		return err
-	}
-
-	stringData := string(jsonBytes)
-
-	if err := json.Unmarshal([]byte(stringData), v); err != nil {
-		return fmt.Errorf("error reading %s as JSON: %w", path, err)
-	}
 
-	return nil
+	// Why plan generation failed. Matches context.DeadlineExceeded if the deadline expired before the plan was generated
+	Err error `json:"-"`
 }
 
-func GenerateBuildPlan(app *app.App, env *app.Environment, options *GenerateBuildPlanOptions) *BuildResult {
+// GenerateBuildPlan generates the build plan of the app.
+// The mise commands that resolve package versions are stopped when ctx is done, and the result fails with Err set to the context error
+func GenerateBuildPlan(ctx context.Context, app *app.App, env *app.Environment, options *GenerateBuildPlanOptions) *BuildResult {
 	logger := logger.NewLogger()
 
-	config, err := GetConfig(app, env, options, logger)
+	if err := ctx.Err(); err != nil {
+		return failedBuildResult(ctx, logger, err)
+	}
+
+	config, configSources, err := getConfigWithSources(app, env, options, logger)
 	if err != nil {
-		logger.LogError("%s", err.Error())
-		return &BuildResult{Success: false, Logs: logger.Logs}
+		return failedBuildResult(ctx, logger, err)
 	}
 
-	ctx, err := generate.NewGenerateContext(app, env, config, logger)
+	generateCtx, err := newGenerateContext(ctx, app, env, config, options, logger)
 	if err != nil {
-		logger.LogError("%s", err.Error())
-		return &BuildResult{Success: false, Logs: logger.Logs}
+		return failedBuildResult(ctx, logger, err)
 	}
+	generateCtx.Provenance.ConfigSources = configSources
 
 	// Set the previous versions
 	if options.PreviousVersions != nil {
 		for name, version := range options.PreviousVersions {
-			ctx.Resolver.SetPreviousVersion(name, version)
+			generateCtx.Resolver.SetPreviousVersion(name, version)
 		}
 	}
 
+	lockFile, err := ReadLockFile(app)
+	if err != nil {
+		return failedBuildResult(ctx, logger, err)
+	}
+	if lockFile != nil && !options.IgnoreLockFile {
+		logger.LogInfo("Using versions from %s", resolver.LockFileName)
+		generateCtx.Resolver.SetLockFile(lockFile)
+	}
+
+	plugins, err := plugin.Load(app, config.Plugins)
+	if err != nil {
+		return failedBuildResult(ctx, logger, err)
+	}
+
 	// Figure out what providers to use
-	providerToUse, detectedProviderName := getProviders(ctx, config)
-	ctx.Metadata.Set("providers", detectedProviderName)
+	providersToUse, detectedProviderName, detections := getProviders(generateCtx, config, plugins)
+	generateCtx.Metadata.Set("providers", detectedProviderName)
 
 	// TODO: We should indicate if we have packages specified in the config
 	// so that providers can determine if they should include mise in the final image (e.g. for shell script)
 
-	if providerToUse != nil {
-		err = providerToUse.Plan(ctx)
-		if err != nil {
-			logger.LogError("%s", err.Error())
-			return &BuildResult{Success: false, Logs: logger.Logs}
-		}
+	if err := planProviders(generateCtx, providersToUse); err != nil {
+		return failedBuildResult(ctx, logger, err)
+	}
+
+	var providerToUse providers.Provider
+	if len(providersToUse) > 0 {
+		providerToUse = providersToUse[0]
 	}
 
 	// Run the procfile provider to support apps that have a Procfile with a start command
 	procfileProvider := &procfile.ProcfileProvider{}
-	if _, err := procfileProvider.Plan(ctx); err != nil {
-		logger.LogError("%s", err.Error())
-		return &BuildResult{Success: false, Logs: logger.Logs}
+	if _, err := procfileProvider.Plan(generateCtx); err != nil {
+		return failedBuildResult(ctx, logger, err)
 	}
+	generateCtx.Provenance.Track(generateCtx, generate.ProviderSource(procfileProvider.Name()))
 
 	// before `Generate()` any commands provided by railpack.json are *not* merged into the provider-generated
 	// buildPlan. This means providers can't view any of the custom structure provided by the user via a railpack.json
-	buildPlan, resolvedPackages, err := ctx.Generate()
+	buildPlan, resolvedPackages, err := generateCtx.Generate()
 	if err != nil {
-		logger.LogError("%s", err.Error())
-		return &BuildResult{Success: false, Logs: logger.Logs}
+		return failedBuildResult(ctx, logger, err)
 	}
 
-	if providerToUse != nil {
-		providerToUse.CleansePlan(buildPlan)
+	if options.Locked {
+		if err := checkLockFile(lockFile, resolvedPackages); err != nil {
+			return failedBuildResult(ctx, logger, err)
+		}
+	}
+
+	for _, provider := range providersToUse {
+		provider.CleansePlan(buildPlan)
 	}
 
 	if !ValidatePlan(buildPlan, app, logger, &ValidatePlanOptions{
@@ -130,8 +153,11 @@ func GenerateBuildPlan(app *app.App, env *app.Environment, options *GenerateBuil
 		RailpackVersion:   options.RailpackVersion,
 		Plan:              buildPlan,
 		ResolvedPackages:  resolvedPackages,
-		Metadata:          ctx.Metadata.Properties,
+		AptPackages:       aptPackagesInPlan(buildPlan, generateCtx.AptPackages),
+		Provenance:        generateCtx.Provenance.Result(buildPlan),
+		Metadata:          generateCtx.Metadata.Properties,
 		DetectedProviders: []string{detectedProviderName},
+		Detections:        detections,
 		Logs:              logger.Logs,
 		Success:           true,
 	}
@@ -139,57 +165,131 @@ func GenerateBuildPlan(app *app.App, env *app.Environment, options *GenerateBuil
 	return buildResult
 }
 
+// DetectProviders scores every plugin and provider that detects the app, without generating a plan
+func DetectProviders(ctx context.Context, app *app.App, env *app.Environment, options *GenerateBuildPlanOptions) ([]*providers.Detection, error) {
+	logger := logger.NewLogger()
+
+	config, err := GetConfig(app, env, options, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	// Detecting providers does not resolve versions, so mise is not needed
+	generateCtx, err := generate.NewGenerateContextWithResolver(ctx, app, env, config, resolver.NewOfflineResolver(&resolver.VersionIndex{}), logger)
+	if err != nil {
+		return nil, err
+	}
+
+	plugins, err := plugin.Load(app, config.Plugins)
+	if err != nil {
+		return nil, err
+	}
+
+	return detectProviders(generateCtx, config, plugins), nil
+}
+
+// failedBuildResult logs why plan generation failed.
+// If the context is done, the error says that generation timed out or was cancelled instead of how the stopped work failed
+func failedBuildResult(ctx context.Context, logger *logger.Logger, err error) *BuildResult {
+	switch ctxErr := ctx.Err(); {
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		err = fmt.Errorf("plan generation timed out: %w", ctxErr)
+	case ctxErr != nil:
+		err = fmt.Errorf("plan generation was cancelled: %w", ctxErr)
+	}
+
+	logger.LogError("%s", err.Error())
+	return &BuildResult{Success: false, Logs: logger.Logs, Err: err}
+}
+
 // GetConfig merges the options, environment, and file config into a single config
 func GetConfig(app *app.App, env *app.Environment, options *GenerateBuildPlanOptions, logger *logger.Logger) (*c.Config, error) {
+	config, _, err := getConfigWithSources(app, env, options, logger)
+	return config, err
+}
+
+// getConfigWithSources merges the config like GetConfig and also returns where each config key came from
+func getConfigWithSources(app *app.App, env *app.Environment, options *GenerateBuildPlanOptions, logger *logger.Logger) (*c.Config, map[string]generate.Source, error) {
 	optionsConfig := GenerateConfigFromOptions(options)
 
 	envConfig := GenerateConfigFromEnvironment(env)
 
-	fileConfig, err := GenerateConfigFromFile(app, env, options, logger)
+	files, err := readConfigFiles(app, env, options, logger)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	mergedConfig := c.Merge(optionsConfig, envConfig, fileConfig)
+	configs := []*c.Config{optionsConfig, envConfig}
+	for _, file := range files {
+		configs = append(configs, file.config)
+	}
+	mergedConfig := c.Merge(configs...)
+
+	// Recorded in merge order so that later configs take precedence
+	sources := map[string]generate.Source{}
+	recordConfigSources(sources, optionsConfig, optionsConfigSource)
+	recordConfigSources(sources, envConfig, func(key string) generate.Source {
+		return envConfigSource(env, key)
+	})
+	for _, file := range files {
+		recordConfigSources(sources, file.config, func(key string) generate.Source {
+			return generate.Source{Type: generate.SourceTypeConfig, Name: file.name, Detail: key}
+		})
+	}
 
-	return mergedConfig, nil
+	return mergedConfig, sources, nil
 }
 
+// GenerateConfigFromFile reads the config file and the railpack sections of the manifests in the app.
+// The config file takes precedence over the manifests
 func GenerateConfigFromFile(app *app.App, env *app.Environment, options *GenerateBuildPlanOptions, logger *logger.Logger) (*c.Config, error) {
-	config := c.EmptyConfig()
+	files, err := readConfigFiles(app, env, options, logger)
+	if err != nil {
+		return nil, err
+	}
 
-	configFileName := defaultConfigFileName
-	if options.ConfigFilePath != "" {
-		configFileName = options.ConfigFilePath
+	config := c.EmptyConfig()
+	for _, file := range files {
+		config = c.Merge(config, file.config)
 	}
 
-	if envConfigFileName, _ := env.GetConfigVariable("CONFIG_FILE"); envConfigFileName != "" {
-		configFileName = envConfigFileName
+	return config, nil
+}
+
+// PlanFiles returns the files that change the plan itself rather than being inputs to a step,
+// including the config files that are extended by the config of the app
+func PlanFiles(app *app.App, env *app.Environment, options *GenerateBuildPlanOptions) []string {
+	configFileNames := defaultConfigFileNames
+	if configFileName := getConfigFileName(env, options); configFileName != "" {
+		configFileNames = []string{configFileName}
 	}
 
-	// always assume config file path is relative to the app source directory
-	// https://github.com/railwayapp/railpack/pull/226
-	absConfigFileName := filepath.Join(app.Source, configFileName)
+	planFiles := append(slices.Clone(configFileNames), ".dockerignore", resolver.LockFileName)
 
-	if _, err := os.Stat(absConfigFileName); err != nil && os.IsNotExist(err) {
-		// if a specific path was specified, we should indicate that it was not found and hard fail
-		if configFileName != defaultConfigFileName {
-			return nil, fmt.Errorf("config file %q not found", absConfigFileName)
+	// A config that cannot be read fails plan generation, so there are no extended files to add
+	files, _ := readConfigFiles(app, env, options, logger.NewLogger())
+	for _, file := range files {
+		if file.extended && !slices.Contains(planFiles, file.name) {
+			planFiles = append(planFiles, file.name)
 		}
+	}
+
+	return planFiles
+}
 
-		return config, nil
+// newGenerateContext resolves versions from the version index when one is given, otherwise with mise
+func newGenerateContext(ctx context.Context, app *app.App, env *app.Environment, config *c.Config, options *GenerateBuildPlanOptions, logger *logger.Logger) (*generate.GenerateContext, error) {
+	if options.VersionIndex == "" {
+		return generate.NewGenerateContext(ctx, app, env, config, logger)
 	}
 
-	// if a JSON file was provided, we should hard fail if we cannot parse it
-	if err := readConfigJSON(absConfigFileName, config); err != nil {
-		logger.LogWarn("Failed to read config file `%s`\nUse the following schema to validate your config file: %s\n", configFileName, c.SchemaUrl)
+	index, err := resolver.ReadVersionIndex(options.VersionIndex)
+	if err != nil {
 		return nil, err
 	}
 
-	logger.LogInfo("Using config file `%s`", configFileName)
-	logger.LogWarn("The config file format is not yet finalized and subject to change.")
-
-	return config, nil
+	logger.LogInfo("Resolving versions offline from %s", options.VersionIndex)
+	return generate.NewGenerateContextWithResolver(ctx, app, env, config, resolver.NewOfflineResolver(index), logger)
 }
 
 func GenerateConfigFromEnvironment(env *app.Environment) *c.Config {
@@ -256,58 +356,213 @@ func GenerateConfigFromOptions(options *GenerateBuildPlanOptions) *c.Config {
 		config.Deploy.StartCmd = options.StartCommand
 	}
 
+	if options.Provider != "" {
+		config.Provider = &options.Provider
+	}
+
 	return config
 }
 
-func getProviders(ctx *generate.GenerateContext, config *c.Config) (providers.Provider, string) {
-	allProviders := providers.GetLanguageProviders()
-
-	var providerToUse providers.Provider
+// getProviders detects the provider of the app and returns the providers to plan it with.
+// Plugins are detected before the built-in providers and take precedence when looking up a provider by name
+func getProviders(ctx *generate.GenerateContext, config *c.Config, plugins []*plugin.PluginProvider) ([]providers.Provider, string, []*providers.Detection) {
+	var providersToUse []providers.Provider
 	var detectedProvider string
 
 	// Even if there are providers manually specified, we want to detect to see what type of app this is
-	for _, provider := range allProviders {
-		matched, err := provider.Detect(ctx)
-		if err != nil {
-			log.Warnf("Failed to detect provider `%s`: %s", provider.Name(), err.Error())
+	detections := detectProviders(ctx, config, plugins)
+	for _, detection := range detections {
+		provider := detection.Provider
+		detectedProvider = provider.Name()
+
+		// If there are no providers manually specified in the config,
+		if config.Provider == nil && len(config.Providers) == 0 {
+			if err := provider.Initialize(ctx); err != nil {
+				ctx.Logger.LogWarn("Failed to initialize provider `%s`: %s", provider.Name(), err.Error())
+				continue
+			}
+
+			ctx.Logger.LogInfo("Detected %s", utils.CapitalizeFirst(provider.Name()))
+			if len(detections) > 1 {
+				ctx.Logger.LogInfo("%s", formatDetectionChoice(detection, detections))
+			}
+
+			providersToUse = []providers.Provider{provider}
+		}
+
+		break
+	}
+
+	// The list of providers takes precedence over a single provider
+	providerNames := config.Providers
+	if len(providerNames) == 0 && config.Provider != nil {
+		providerNames = []string{*config.Provider}
+	}
+
+	if len(providerNames) == 0 {
+		return providersToUse, detectedProvider, detections
+	}
+
+	configProviders := []providers.Provider{}
+	for _, name := range utils.RemoveDuplicates(providerNames) {
+		provider := getProvider(plugins, name)
+
+		if provider == nil {
+			ctx.Logger.LogWarn("Provider `%s` not found", name)
 			continue
 		}
 
-		if matched {
-			detectedProvider = provider.Name()
+		if err := provider.Initialize(ctx); err != nil {
+			ctx.Logger.LogWarn("Failed to initialize provider `%s`: %s", name, err.Error())
+			continue
+		}
 
-			// If there are no providers manually specified in the config,
-			if config.Provider == nil {
-				if err := provider.Initialize(ctx); err != nil {
-					ctx.Logger.LogWarn("Failed to initialize provider `%s`: %s", provider.Name(), err.Error())
-					continue
-				}
+		ctx.Logger.LogInfo("Using provider %s from config", utils.CapitalizeFirst(name))
+		configProviders = append(configProviders, provider)
+	}
 
-				ctx.Logger.LogInfo("Detected %s", utils.CapitalizeFirst(provider.Name()))
+	if len(configProviders) == 0 {
+		return providersToUse, detectedProvider, detections
+	}
 
-				providerToUse = provider
-			}
+	return configProviders, detectedProvider, detections
+}
+
+// detectProviders scores every plugin and provider that detects the app.
+// The scores in the config replace the score of a provider, and a score of 0 ignores it
+func detectProviders(ctx *generate.GenerateContext, config *c.Config, plugins []*plugin.PluginProvider) []*providers.Detection {
+	allProviders := []providers.Provider{}
+	for _, p := range plugins {
+		allProviders = append(allProviders, p)
+	}
+	allProviders = append(allProviders, providers.GetLanguageProviders()...)
 
-			break
+	detections := providers.DetectAll(ctx, allProviders)
+	if len(config.ProviderScores) == 0 {
+		return detections
+	}
+
+	detections = slices.DeleteFunc(detections, func(detection *providers.Detection) bool {
+		score, ok := config.ProviderScores[detection.Name]
+		if !ok {
+			return false
+		}
+
+		detection.Score = min(score, providers.MaxScore)
+		detection.Reasons = append(detection.Reasons, fmt.Sprintf("score set to %d by providerScores", score))
+		return score <= 0
+	})
+	providers.SortDetections(detections)
+
+	return detections
+}
+
+// formatDetectionChoice explains why a provider was used over the other providers that detected the app
+func formatDetectionChoice(chosen *providers.Detection, detections []*providers.Detection) string {
+	others := []string{}
+	for _, detection := range detections {
+		if detection != chosen {
+			others = append(others, fmt.Sprintf("%s (%d)", detection.Name, detection.Score))
 		}
 	}
 
-	if config.Provider != nil {
-		provider := providers.GetProvider(*config.Provider)
+	return fmt.Sprintf("Using %s with a score of %d (%s) over %s", chosen.Name, chosen.Score, strings.Join(chosen.Reasons, "; "), strings.Join(others, ", "))
+}
 
-		if provider == nil {
-			ctx.Logger.LogWarn("Provider `%s` not found", *config.Provider)
-			return providerToUse, detectedProvider
+func getProvider(plugins []*plugin.PluginProvider, name string) providers.Provider {
+	for _, p := range plugins {
+		if p.Name() == name {
+			return p
 		}
+	}
 
-		if err := provider.Initialize(ctx); err != nil {
-			ctx.Logger.LogWarn("Failed to initialize provider `%s`: %s", *config.Provider, err.Error())
-			return providerToUse, detectedProvider
+	return providers.GetProvider(name)
+}
+
+// planProviders plans every provider into the same context
+// The first provider is the primary provider and plans its steps without a namespace.
+// Every other provider plans its steps under a sub context named after the provider (e.g. `build:node`),
+// shares the mise step, and has its deploy inputs layered on top of the primary deploy.
+func planProviders(ctx *generate.GenerateContext, providersToUse []providers.Provider) error {
+	if len(providersToUse) == 0 {
+		return nil
+	}
+
+	primary := providersToUse[0]
+	if err := primary.Plan(ctx); err != nil {
+		return err
+	}
+	ctx.Provenance.Track(ctx, generate.ProviderSource(primary.Name()))
+
+	for _, provider := range providersToUse[1:] {
+		// Some providers already embed another provider (e.g. Ruby and PHP install Node when a package.json is found)
+		if hasStepsInSubContext(ctx, provider.Name()) {
+			ctx.Logger.LogInfo("%s is already planned by %s", utils.CapitalizeFirst(provider.Name()), utils.CapitalizeFirst(primary.Name()))
+			continue
 		}
 
-		ctx.Logger.LogInfo("Using provider %s from config", utils.CapitalizeFirst(*config.Provider))
-		providerToUse = provider
+		ctx.Logger.LogInfo("Composing %s with %s", utils.CapitalizeFirst(provider.Name()), utils.CapitalizeFirst(primary.Name()))
+
+		deploy := *ctx.Deploy
+		deploy.Variables = maps.Clone(ctx.Deploy.Variables)
+		existingInputs := slices.Clone(ctx.Deploy.DeployInputs)
+
+		ctx.EnterSubContext(provider.Name())
+		err := provider.Plan(ctx)
+		ctx.ExitSubContext()
+		if err != nil {
+			return fmt.Errorf("failed to plan provider `%s`: %w", provider.Name(), err)
+		}
+
+		mergeComposedDeploy(ctx.Deploy, &deploy, existingInputs)
+		ctx.Provenance.Track(ctx, generate.ProviderSource(provider.Name()))
+	}
+
+	return nil
+}
+
+// mergeComposedDeploy keeps the primary deploy settings and layers the inputs of the composed provider on top
+func mergeComposedDeploy(current *generate.DeployBuilder, primary *generate.DeployBuilder, primaryInputs []plan.Layer) {
+	if primary.StartCmd != "" {
+		current.StartCmd = primary.StartCmd
+	}
+	current.Base = primary.Base
+	maps.Copy(current.Variables, primary.Variables)
+
+	// The composed provider may have replaced the inputs, so always keep the primary inputs first
+	seen := map[string]bool{}
+	inputs := []plan.Layer{}
+	for _, layer := range append(primaryInputs, current.DeployInputs...) {
+		key := layer.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		inputs = append(inputs, layer)
 	}
+	current.DeployInputs = inputs
+}
+
+func hasStepsInSubContext(ctx *generate.GenerateContext, subContext string) bool {
+	for _, step := range ctx.Steps {
+		if strings.HasSuffix(step.Name(), ":"+subContext) {
+			return true
+		}
+	}
+	return false
+}
 
-	return providerToUse, detectedProvider
+// aptPackagesInPlan drops the apt packages of steps that were removed from the plan
+func aptPackagesInPlan(buildPlan *plan.BuildPlan, aptPackages map[string][]string) map[string][]string {
+	result := map[string][]string{}
+	for _, step := range buildPlan.Steps {
+		if pkgs, ok := aptPackages[step.Name]; ok && len(pkgs) > 0 {
+			result[step.Name] = pkgs
+		}
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+	return result
 }
@@ -1,13 +1,21 @@
 package core
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/gkampitakis/go-snaps/snaps"
 	"github.com/railwayapp/railpack/core/app"
+	"github.com/railwayapp/railpack/core/generate"
 	"github.com/railwayapp/railpack/core/logger"
+	"github.com/railwayapp/railpack/core/plan"
+	"github.com/railwayapp/railpack/core/providers/plugin"
+	"github.com/railwayapp/railpack/core/resolver"
 	"github.com/stretchr/testify/require"
 )
 
@@ -40,7 +48,7 @@ func TestGenerateBuildPlanForExamples(t *testing.T) {
 			require.NoError(t, err)
 
 			env := app.NewEnvironment(nil)
-			buildResult := GenerateBuildPlan(userApp, env, &GenerateBuildPlanOptions{})
+			buildResult := GenerateBuildPlan(context.Background(), userApp, env, &GenerateBuildPlanOptions{})
 
 			if !buildResult.Success {
 				t.Fatalf("failed to generate build plan for %s: %s", entry.Name(), buildResult.Logs)
@@ -99,9 +107,597 @@ func TestGenerateBuildPlan_DockerignoreMetadata(t *testing.T) {
 	require.NoError(t, err)
 
 	env := app.NewEnvironment(nil)
-	buildResult := GenerateBuildPlan(userApp, env, &GenerateBuildPlanOptions{})
+	buildResult := GenerateBuildPlan(context.Background(), userApp, env, &GenerateBuildPlanOptions{})
 
 	require.True(t, buildResult.Success)
 	require.NotNil(t, buildResult.Metadata)
 	require.Equal(t, "true", buildResult.Metadata["dockerIgnore"])
 }
+
+func TestMergeComposedDeploy(t *testing.T) {
+	primaryInputs := []plan.Layer{
+		plan.NewStepLayer("packages:mise", plan.NewIncludeFilter([]string{"/mise/shims"})),
+		plan.NewStepLayer("build", plan.NewIncludeFilter([]string{"."})),
+	}
+
+	primary := generate.NewDeployBuilder()
+	primary.StartCmd = "gunicorn app:app"
+	primary.Variables["PYTHONUNBUFFERED"] = "1"
+	primary.Variables["SHARED"] = "primary"
+	primary.DeployInputs = primaryInputs
+
+	// the composed provider overrides the start command and replaces the inputs
+	current := generate.NewDeployBuilder()
+	current.Base = plan.NewImageLayer("caddy")
+	current.StartCmd = "npm start"
+	current.Variables["NODE_ENV"] = "production"
+	current.Variables["SHARED"] = "composed"
+	current.DeployInputs = []plan.Layer{
+		plan.NewStepLayer("packages:mise", plan.NewIncludeFilter([]string{"/mise/shims"})),
+		plan.NewStepLayer("build:node", plan.NewFilter([]string{"."}, []string{"node_modules"})),
+	}
+
+	mergeComposedDeploy(current, primary, primaryInputs)
+
+	require.Equal(t, "gunicorn app:app", current.StartCmd)
+	require.Equal(t, primary.Base, current.Base)
+	require.Equal(t, map[string]string{
+		"PYTHONUNBUFFERED": "1",
+		"NODE_ENV":         "production",
+		"SHARED":           "primary",
+	}, current.Variables)
+	require.Equal(t, []plan.Layer{
+		plan.NewStepLayer("packages:mise", plan.NewIncludeFilter([]string{"/mise/shims"})),
+		plan.NewStepLayer("build", plan.NewIncludeFilter([]string{"."})),
+		plan.NewStepLayer("build:node", plan.NewFilter([]string{"."}, []string{"node_modules"})),
+	}, current.DeployInputs)
+}
+
+func TestMergeComposedDeployKeepsComposedStartCommand(t *testing.T) {
+	primary := generate.NewDeployBuilder()
+	current := generate.NewDeployBuilder()
+	current.StartCmd = "npm start"
+
+	mergeComposedDeploy(current, primary, nil)
+
+	require.Equal(t, "npm start", current.StartCmd)
+}
+
+func TestGetConfigWithSources(t *testing.T) {
+	appPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(appPath, "railpack.json"), []byte(`{
+		"steps": {"build": {"commands": ["npm run build"], "variables": {"NODE_ENV": "production"}}}
+	}`), 0644))
+
+	userApp, err := app.NewApp(appPath)
+	require.NoError(t, err)
+
+	env := app.NewEnvironment(&map[string]string{
+		"RAILPACK_INSTALL_CMD": "npm ci",
+		"RAILPACK_START_CMD":   "node server.js",
+	})
+
+	_, sources, err := getConfigWithSources(userApp, env, &GenerateBuildPlanOptions{BuildCommand: "make"}, logger.NewLogger())
+	require.NoError(t, err)
+
+	require.Equal(t, generate.Source{Type: generate.SourceTypeConfig, Name: "railpack.json", Detail: "steps.build.commands"}, sources["steps.build.commands"])
+	require.Equal(t, generate.Source{Type: generate.SourceTypeConfig, Name: "railpack.json", Detail: "steps.build.variables.NODE_ENV"}, sources["steps.build.variables.NODE_ENV"])
+	require.Equal(t, generate.Source{Type: generate.SourceTypeEnv, Name: "RAILPACK_INSTALL_CMD", Detail: "steps.install.commands"}, sources["steps.install.commands"])
+	require.Equal(t, generate.Source{Type: generate.SourceTypeEnv, Name: "RAILPACK_START_CMD", Detail: "deploy.startCommand"}, sources["deploy.startCommand"])
+}
+
+func TestGetConfigFromYAMLAndTOML(t *testing.T) {
+	files := map[string]string{
+		"railpack.yaml": `
+packages:
+  node: "22"
+steps:
+  build:
+    commands: ["npm run build"]
+deploy:
+  startCommand: node server.js
+`,
+		"railpack.toml": `
+[packages]
+node = "22"
+
+[steps.build]
+commands = ["npm run build"]
+
+[deploy]
+startCommand = "node server.js"
+`,
+	}
+
+	for name, contents := range files {
+		t.Run(name, func(t *testing.T) {
+			userApp := app.NewAppFromFS(fstest.MapFS{name: {Data: []byte(contents)}}, "app")
+
+			config, sources, err := getConfigWithSources(userApp, app.NewEnvironment(nil), &GenerateBuildPlanOptions{}, logger.NewLogger())
+			require.NoError(t, err)
+
+			require.Equal(t, "22", config.Packages["node"])
+			require.Equal(t, []plan.Command{plan.NewExecShellCommand("npm run build")}, config.Steps["build"].Commands)
+			require.Equal(t, "node server.js", config.Deploy.StartCmd)
+			require.Equal(t, name, sources["deploy.startCommand"].Name)
+		})
+	}
+}
+
+func TestGetConfigFromManifests(t *testing.T) {
+	userApp := app.NewAppFromFS(fstest.MapFS{
+		"package.json": {Data: []byte(`{
+			"name": "app",
+			"railpack": {"packages": {"node": "20"}, "deploy": {"startCommand": "npm start"}}
+		}`)},
+		"pyproject.toml": {Data: []byte(`
+[project]
+name = "app"
+
+[tool.railpack]
+buildAptPackages = ["libpq-dev"]
+
+[tool.railpack.deploy]
+startCommand = "gunicorn app:app"
+`)},
+		"Cargo.toml": {Data: []byte(`
+[package]
+name = "app"
+
+[package.metadata.railpack.packages]
+rust = "1.85"
+`)},
+		"railpack.yaml": {Data: []byte("packages:\n  node: \"22\"\n")},
+	}, "app")
+
+	env := app.NewEnvironment(&map[string]string{"RAILPACK_START_CMD": "./start.sh"})
+
+	config, sources, err := getConfigWithSources(userApp, env, &GenerateBuildPlanOptions{}, logger.NewLogger())
+	require.NoError(t, err)
+
+	// The config file takes precedence over the manifests, and the manifests over the environment
+	require.Equal(t, map[string]string{"node": "22", "rust": "1.85"}, config.Packages)
+	require.Equal(t, []string{"libpq-dev"}, config.BuildAptPackages)
+	require.Equal(t, "gunicorn app:app", config.Deploy.StartCmd)
+
+	require.Equal(t, "railpack.yaml", sources["packages.node"].Name)
+	require.Equal(t, "Cargo.toml", sources["packages.rust"].Name)
+	require.Equal(t, "pyproject.toml", sources["deploy.startCommand"].Name)
+}
+
+func TestGetConfigFromManifestsErrors(t *testing.T) {
+	// Manifests that cannot be parsed are left to the providers
+	userApp := app.NewAppFromFS(fstest.MapFS{
+		"pyproject.toml": {Data: []byte(`[tool.railpack`)},
+		"package.json":   {Data: []byte(`{"railpack": {"deploy": {"startCommand": "npm start"}}}`)},
+	}, "app")
+
+	config, err := GenerateConfigFromFile(userApp, app.NewEnvironment(nil), &GenerateBuildPlanOptions{}, logger.NewLogger())
+	require.NoError(t, err)
+	require.Equal(t, "npm start", config.Deploy.StartCmd)
+
+	// A railpack section that is not a config fails
+	userApp = app.NewAppFromFS(fstest.MapFS{
+		"package.json": {Data: []byte(`{"railpack": "node"}`)},
+	}, "app")
+
+	_, err = GenerateConfigFromFile(userApp, app.NewEnvironment(nil), &GenerateBuildPlanOptions{}, logger.NewLogger())
+	require.ErrorContains(t, err, `"railpack" key in package.json`)
+}
+
+func TestGetConfigExtends(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"shared/python.yaml": `
+buildAptPackages: [libpq-dev]
+secrets: [SENTRY_DSN]
+caches:
+  pip: {directory: /root/.cache/pip, type: shared}
+`,
+		"app/base.railpack.json": `{
+			"extends": ["../shared/python.yaml"],
+			"steps": {"build": {"commands": ["make"]}},
+			"deploy": {"startCommand": "gunicorn app:app"}
+		}`,
+		"app/railpack.json": `{
+			"extends": ["./base.railpack.json"],
+			"buildAptPackages": ["...", "curl"],
+			"steps": {"build": {"commands": ["...", "make test"]}, "install": {"commands": ["...", "pip check"]}}
+		}`,
+	}
+	for name, contents := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(contents), 0644))
+	}
+
+	// Files outside of the app can be extended from the git repository the app is in
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+
+	userApp, err := app.NewApp(filepath.Join(root, "app"))
+	require.NoError(t, err)
+
+	config, sources, err := getConfigWithSources(userApp, app.NewEnvironment(nil), &GenerateBuildPlanOptions{}, logger.NewLogger())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"libpq-dev", "curl"}, config.BuildAptPackages)
+	require.Equal(t, []string{"SENTRY_DSN"}, config.Secrets)
+	require.Equal(t, "/root/.cache/pip", config.Caches["pip"].Directory)
+	require.Equal(t, "gunicorn app:app", config.Deploy.StartCmd)
+	require.Equal(t, []plan.Command{plan.NewExecShellCommand("make"), plan.NewExecShellCommand("make test")}, config.Steps["build"].Commands)
+	require.Nil(t, config.Extends)
+
+	// Arrays that are not in the extended config still add to the plan of the provider
+	require.Equal(t, []plan.Command{plan.NewExecShellCommand("..."), plan.NewExecShellCommand("pip check")}, config.Steps["install"].Commands)
+
+	require.Equal(t, "../shared/python.yaml", sources["caches.pip"].Name)
+	require.Equal(t, "base.railpack.json", sources["deploy.startCommand"].Name)
+	require.Equal(t, "railpack.json", sources["steps.build.commands"].Name)
+
+	// A change to an extended config changes the plan of every app that extends it
+	planFiles := PlanFiles(userApp, app.NewEnvironment(nil), &GenerateBuildPlanOptions{})
+	require.Contains(t, planFiles, "base.railpack.json")
+	require.Contains(t, planFiles, "../shared/python.yaml")
+}
+
+func TestGetConfigExtendsErrors(t *testing.T) {
+	getConfig := func(files fstest.MapFS) error {
+		_, err := GetConfig(app.NewAppFromFS(files, "app"), app.NewEnvironment(nil), &GenerateBuildPlanOptions{}, logger.NewLogger())
+		return err
+	}
+
+	err := getConfig(fstest.MapFS{
+		"railpack.json":        {Data: []byte(`{"extends": ["config/a.json"]}`)},
+		"config/a.json":        {Data: []byte(`{"extends": ["b.yaml"]}`)},
+		"config/b.yaml":        {Data: []byte(`extends: [../railpack.json]`)},
+		"config/unrelated.txt": {Data: []byte(``)},
+	})
+	require.ErrorContains(t, err, "config file railpack.json extends itself: railpack.json -> config/a.json -> config/b.yaml -> railpack.json")
+
+	err = getConfig(fstest.MapFS{
+		"railpack.json": {Data: []byte(`{"extends": ["./missing.json"]}`)},
+	})
+	require.ErrorContains(t, err, "config file railpack.json extends ./missing.json: missing.json not found")
+
+	// Apps that are not on disk cannot read files outside of the app
+	err = getConfig(fstest.MapFS{
+		"pyproject.toml": {Data: []byte("[tool.railpack]\nextends = [\"../shared.json\"]")},
+	})
+	require.ErrorContains(t, err, "config file pyproject.toml extends ../shared.json: ../shared.json is outside of the app")
+
+	err = getConfig(fstest.MapFS{
+		"railpack.json": {Data: []byte(`{"extends": ["base.json"]}`)},
+		"base.json":     {Data: []byte(`{"steps": `)},
+	})
+	require.ErrorContains(t, err, "error reading base.json as JSON")
+}
+
+func TestGetConfigExtendsOutsideOfRepository(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"shared.json":            `{"secrets": ["SENTRY_DSN"]}`,
+		"repo/shared.json":       `{"secrets": ["SENTRY_DSN"]}`,
+		"repo/app/railpack.json": `{"extends": ["../../shared.json"]}`,
+	}
+	for name, contents := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(contents), 0644))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(root, "repo", ".git"), 0755))
+	require.NoError(t, os.Symlink(filepath.Join(root, "shared.json"), filepath.Join(root, "repo", "link.json")))
+
+	getConfig := func(configFile string, options *GenerateBuildPlanOptions) error {
+		userApp, err := app.NewApp(filepath.Join(root, "repo", "app"))
+		require.NoError(t, err)
+		options.ConfigFilePath = configFile
+		_, err = GetConfig(userApp, app.NewEnvironment(nil), options, logger.NewLogger())
+		return err
+	}
+
+	err := getConfig("railpack.json", &GenerateBuildPlanOptions{})
+	require.ErrorContains(t, err, "extends ../../shared.json: "+filepath.Join(root, "shared.json")+" is outside of the git repository of the app")
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "repo", "app", "link.railpack.json"), []byte(`{"extends": ["../link.json"]}`), 0644))
+	err = getConfig("link.railpack.json", &GenerateBuildPlanOptions{})
+	require.ErrorContains(t, err, "extends ../link.json: "+filepath.Join(root, "repo", "link.json")+" is outside")
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "repo", "app", "repo.railpack.json"), []byte(`{"extends": ["../shared.json"]}`), 0644))
+	require.NoError(t, getConfig("repo.railpack.json", &GenerateBuildPlanOptions{}))
+
+	// The allowed paths replace the git repository
+	err = getConfig("repo.railpack.json", &GenerateBuildPlanOptions{ExtendsAllowedPaths: []string{}})
+	require.ErrorContains(t, err, "extends ../shared.json: "+filepath.Join(root, "repo", "shared.json")+" is outside")
+	require.NoError(t, getConfig("railpack.json", &GenerateBuildPlanOptions{ExtendsAllowedPaths: []string{root}}))
+}
+
+func TestGenerateConfigFromFile_Formats(t *testing.T) {
+	userApp := app.NewAppFromFS(fstest.MapFS{
+		"railpack.json":        {Data: []byte(`{"deploy": {"startCommand": "from json"}}`)},
+		"railpack.toml":        {Data: []byte(`deploy = { startCommand = "from toml" }`)},
+		"config/railpack.yml":  {Data: []byte(`deploy: {startCommand: from yaml}`)},
+		"config/malformed.yml": {Data: []byte(`deploy: [`)},
+	}, "app")
+	env := app.NewEnvironment(nil)
+
+	// Only the first default config file is used
+	config, err := GenerateConfigFromFile(userApp, env, &GenerateBuildPlanOptions{}, logger.NewLogger())
+	require.NoError(t, err)
+	require.Equal(t, "from json", config.Deploy.StartCmd)
+
+	config, err = GenerateConfigFromFile(userApp, env, &GenerateBuildPlanOptions{ConfigFilePath: "config/railpack.yml"}, logger.NewLogger())
+	require.NoError(t, err)
+	require.Equal(t, "from yaml", config.Deploy.StartCmd)
+
+	_, err = GenerateConfigFromFile(userApp, env, &GenerateBuildPlanOptions{ConfigFilePath: "config/malformed.yml"}, logger.NewLogger())
+	require.ErrorContains(t, err, "as YAML")
+
+	require.Equal(t, []string{"railpack.json", "railpack.yaml", "railpack.yml", "railpack.toml", ".dockerignore", resolver.LockFileName}, PlanFiles(userApp, env, &GenerateBuildPlanOptions{}))
+	require.Equal(t, []string{"config/railpack.yml", ".dockerignore", resolver.LockFileName}, PlanFiles(userApp, env, &GenerateBuildPlanOptions{ConfigFilePath: "config/railpack.yml"}))
+}
+
+func TestOptionsConfigSource(t *testing.T) {
+	require.Equal(t, "--build-cmd", optionsConfigSource("steps.build.commands").Name)
+	require.Equal(t, "--start-cmd", optionsConfigSource("deploy.startCommand").Name)
+}
+
+func TestLockFile(t *testing.T) {
+	appPath := t.TempDir()
+	userApp, err := app.NewApp(appPath)
+	require.NoError(t, err)
+
+	lockFile, err := ReadLockFile(userApp)
+	require.NoError(t, err)
+	require.Nil(t, lockFile)
+	require.ErrorContains(t, checkLockFile(lockFile, nil), "railpack.lock not found")
+
+	requested, resolved := "22", "22.11.0"
+	buildResult := &BuildResult{ResolvedPackages: map[string]*resolver.ResolvedPackage{
+		"node": {Name: "node", RequestedVersion: &requested, ResolvedVersion: &resolved},
+	}}
+
+	path, err := WriteLockFile(userApp, buildResult)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(userApp.Source, resolver.LockFileName), path)
+
+	lockFile, err = ReadLockFile(userApp)
+	require.NoError(t, err)
+	require.NoError(t, checkLockFile(lockFile, buildResult.ResolvedPackages))
+
+	newer := "22.12.0"
+	err = checkLockFile(lockFile, map[string]*resolver.ResolvedPackage{
+		"node": {Name: "node", RequestedVersion: &requested, ResolvedVersion: &newer},
+	})
+	require.ErrorContains(t, err, "railpack.lock is out of date")
+	require.ErrorContains(t, err, "node is locked to 22.11.0 but resolved to 22.12.0")
+}
+
+func TestGenerateBuildPlanUpdatesLockFile(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), resolver.VersionIndexFileName)
+	index := &resolver.VersionIndex{Packages: map[string][]string{
+		"node": {"20.18.1", "20.19.0"},
+	}}
+	require.NoError(t, index.Write(indexPath))
+
+	userApp := app.NewAppFromFS(fstest.MapFS{
+		"package.json":      {Data: []byte(`{"name": "app", "engines": {"node": "20"}, "scripts": {"start": "node index.js"}}`)},
+		"package-lock.json": {Data: []byte(`{"lockfileVersion": 3, "packages": {}}`)},
+		"railpack.lock":     {Data: []byte(`{"packages": {"node": {"requested": "20", "resolved": "20.18.1"}}}`)},
+	}, "app")
+
+	// The locked version is used until the lock file is updated
+	buildResult := GenerateBuildPlan(context.Background(), userApp, app.NewEnvironment(nil), &GenerateBuildPlanOptions{VersionIndex: indexPath})
+	require.True(t, buildResult.Success, buildResult.Logs)
+	require.Equal(t, "20.18.1", *buildResult.ResolvedPackages["node"].ResolvedVersion)
+
+	buildResult = GenerateBuildPlan(context.Background(), userApp, app.NewEnvironment(nil), &GenerateBuildPlanOptions{VersionIndex: indexPath, IgnoreLockFile: true})
+	require.True(t, buildResult.Success, buildResult.Logs)
+	require.Equal(t, "20.19.0", *buildResult.ResolvedPackages["node"].ResolvedVersion)
+}
+
+func TestGenerateBuildPlanOffline(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), resolver.VersionIndexFileName)
+	index := &resolver.VersionIndex{Packages: map[string][]string{
+		"node": {"22.12.0", "23.4.0", "23.5.0", "23.6.0"},
+	}}
+	require.NoError(t, index.Write(indexPath))
+
+	userApp, err := app.NewApp("../examples/node-npm")
+	require.NoError(t, err)
+
+	buildResult := GenerateBuildPlan(context.Background(), userApp, app.NewEnvironment(nil), &GenerateBuildPlanOptions{VersionIndex: indexPath})
+	require.True(t, buildResult.Success, buildResult.Logs)
+	require.Equal(t, "23.5.0", *buildResult.ResolvedPackages["node"].ResolvedVersion)
+}
+
+func TestGenerateBuildPlanFromFS(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), resolver.VersionIndexFileName)
+	index := &resolver.VersionIndex{Packages: map[string][]string{
+		"node": {"20.18.1", "22.12.0"},
+	}}
+	require.NoError(t, index.Write(indexPath))
+
+	userApp := app.NewAppFromFS(fstest.MapFS{
+		"package.json":      {Data: []byte(`{"name": "app", "engines": {"node": "20"}, "scripts": {"start": "node index.js"}}`)},
+		"package-lock.json": {Data: []byte(`{"lockfileVersion": 3, "packages": {}}`)},
+		"index.js":          {Data: []byte(`console.log("hello")`)},
+		"railpack.json":     {Data: []byte(`{"deploy": {"startCommand": "node server.js"}}`)},
+	}, "app")
+
+	buildResult := GenerateBuildPlan(context.Background(), userApp, app.NewEnvironment(nil), &GenerateBuildPlanOptions{VersionIndex: indexPath})
+	require.True(t, buildResult.Success, buildResult.Logs)
+	require.Equal(t, []string{"node"}, buildResult.DetectedProviders)
+	require.Equal(t, "20.18.1", *buildResult.ResolvedPackages["node"].ResolvedVersion)
+	require.Equal(t, "node server.js", buildResult.Plan.Deploy.StartCmd)
+
+	_, err := WriteLockFile(userApp, buildResult)
+	require.Error(t, err)
+}
+
+func TestGenerateBuildPlanDeadline(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), resolver.VersionIndexFileName)
+	index := &resolver.VersionIndex{Packages: map[string][]string{
+		"node": {"22.12.0"},
+	}}
+	require.NoError(t, index.Write(indexPath))
+
+	userApp, err := app.NewApp("../examples/node-npm")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	buildResult := GenerateBuildPlan(ctx, userApp, app.NewEnvironment(nil), &GenerateBuildPlanOptions{VersionIndex: indexPath})
+	require.False(t, buildResult.Success)
+	require.ErrorIs(t, buildResult.Err, context.DeadlineExceeded)
+	require.Equal(t, "plan generation timed out: context deadline exceeded", buildResult.Logs[len(buildResult.Logs)-1].Msg)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	buildResult = GenerateBuildPlan(ctx, userApp, app.NewEnvironment(nil), &GenerateBuildPlanOptions{VersionIndex: indexPath})
+	require.False(t, buildResult.Success)
+	require.ErrorIs(t, buildResult.Err, context.Canceled)
+}
+
+func TestGenerateBuildPlanConcurrent(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), resolver.VersionIndexFileName)
+	index := &resolver.VersionIndex{Packages: map[string][]string{
+		"node":   {"22.12.0", "23.5.0"},
+		"python": {"3.11.11", "3.12.1", "3.13.1"},
+		"uv":     {"0.5.0"},
+		"go":     {"1.23.4", "1.25.3"},
+	}}
+	require.NoError(t, index.Write(indexPath))
+
+	examples := []string{"node-npm", "python-uv", "go-mod"}
+
+	// Plans of the same app are generated at the same time to share as much as possible
+	var wg sync.WaitGroup
+	for i := 0; i < 3*len(examples); i++ {
+		example := examples[i%len(examples)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			userApp, err := app.NewApp(filepath.Join("../examples", example))
+			require.NoError(t, err)
+
+			buildResult := GenerateBuildPlan(context.Background(), userApp, app.NewEnvironment(nil), &GenerateBuildPlanOptions{VersionIndex: indexPath})
+			require.True(t, buildResult.Success, "%s: %v", example, buildResult.Logs)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestGenerateBuildPlanComposed(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), resolver.VersionIndexFileName)
+	index := &resolver.VersionIndex{Packages: map[string][]string{
+		"node":   {"22.12.0"},
+		"bun":    {"1.2.0"},
+		"python": {"3.13.1"},
+	}}
+	require.NoError(t, index.Write(indexPath))
+
+	userApp, err := app.NewApp("../examples/python-vite-composed")
+	require.NoError(t, err)
+
+	buildResult := GenerateBuildPlan(context.Background(), userApp, app.NewEnvironment(nil), &GenerateBuildPlanOptions{VersionIndex: indexPath})
+	require.True(t, buildResult.Success, buildResult.Logs)
+
+	// The Vite assets are built for the Python app, which serves them instead of Caddy
+	stepNames := []string{}
+	for _, step := range buildResult.Plan.Steps {
+		stepNames = append(stepNames, step.Name)
+	}
+	require.Contains(t, stepNames, "build:node")
+	require.NotContains(t, stepNames, "caddy:node")
+	require.NotContains(t, stepNames, "packages:caddy:node")
+	require.Equal(t, "gunicorn --bind 0.0.0.0:${PORT:-8000} main:app", buildResult.Plan.Deploy.StartCmd)
+}
+
+func TestGenerateBuildPlanWithPlugin(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), resolver.VersionIndexFileName)
+	index := &resolver.VersionIndex{Packages: map[string][]string{
+		"node": {"22.12.0"},
+	}}
+	require.NoError(t, index.Write(indexPath))
+
+	pluginDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(pluginDir, plugin.ExecutablePrefix+"acme"), []byte(`#!/bin/sh
+case "$(cat)" in
+  *'"method":"info"'*) echo '{}' ;;
+  *'"method":"detect"'*) echo '{"detected": true}' ;;
+  *'"method":"plan"'*) echo '{"plan": {"packages": {"node": "22"}, "steps": {"build": {"commands": ["npx acme build"]}}, "deploy": {"startCommand": "npx acme start"}}}' ;;
+esac
+`), 0755))
+	t.Setenv(plugin.PluginPathEnvVar, pluginDir)
+
+	// The plugin is detected before the node provider
+	userApp := app.NewAppFromFS(fstest.MapFS{
+		"package.json": {Data: []byte(`{"name": "app"}`)},
+		"acme.config":  {Data: []byte(``)},
+	}, "app")
+
+	buildResult := GenerateBuildPlan(context.Background(), userApp, app.NewEnvironment(nil), &GenerateBuildPlanOptions{VersionIndex: indexPath})
+	require.True(t, buildResult.Success, buildResult.Logs)
+	require.Equal(t, []string{"acme"}, buildResult.DetectedProviders)
+	require.Equal(t, "22.12.0", *buildResult.ResolvedPackages["node"].ResolvedVersion)
+	require.Equal(t, "npx acme start", buildResult.Plan.Deploy.StartCmd)
+
+	var build *plan.Step
+	for _, step := range buildResult.Plan.Steps {
+		if step.Name == "build" {
+			build = &step
+		}
+	}
+	require.NotNil(t, build)
+	require.Equal(t, plan.NewExecShellCommand("npx acme build"), build.Commands[len(build.Commands)-1])
+	buildSource := buildResult.Provenance.Steps["build"].Sources[0]
+	require.Equal(t, generate.SourceTypeProvider, buildSource.Type)
+	require.Equal(t, "acme", buildSource.Name)
+
+	// Declared plugins must exist
+	userApp = app.NewAppFromFS(fstest.MapFS{
+		"railpack.json": {Data: []byte(`{"plugins": ["missing"]}`)},
+	}, "app")
+	buildResult = GenerateBuildPlan(context.Background(), userApp, app.NewEnvironment(nil), &GenerateBuildPlanOptions{VersionIndex: indexPath})
+	require.False(t, buildResult.Success)
+	require.ErrorContains(t, buildResult.Err, "plugin `missing` not found")
+}
+
+func TestGenerateBuildPlanDetections(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), resolver.VersionIndexFileName)
+	index := &resolver.VersionIndex{Packages: map[string][]string{
+		"node": {"22.12.0"},
+		"go":   {"1.23.4"},
+	}}
+	require.NoError(t, index.Write(indexPath))
+
+	files := fstest.MapFS{
+		"go.mod":       {Data: []byte("module app\n\ngo 1.23")},
+		"main.go":      {Data: []byte("package main\n\nfunc main() {}")},
+		"package.json": {Data: []byte(`{"name": "app", "scripts": {"start": "node index.js"}}`)},
+		"index.js":     {Data: []byte(`console.log("hello")`)},
+		"src/a.js":     {Data: []byte(``)},
+	}
+
+	buildResult := GenerateBuildPlan(context.Background(), app.NewAppFromFS(files, "app"), app.NewEnvironment(nil), &GenerateBuildPlanOptions{VersionIndex: indexPath})
+	require.True(t, buildResult.Success, buildResult.Logs)
+	require.Equal(t, []string{"node"}, buildResult.DetectedProviders)
+	require.Len(t, buildResult.Detections, 2)
+	require.Equal(t, "node", buildResult.Detections[0].Name)
+	require.Equal(t, "golang", buildResult.Detections[1].Name)
+
+	// The config can override the score of a match
+	files["railpack.json"] = &fstest.MapFile{Data: []byte(`{"providerScores": {"golang": 100}}`)}
+	buildResult = GenerateBuildPlan(context.Background(), app.NewAppFromFS(files, "app"), app.NewEnvironment(nil), &GenerateBuildPlanOptions{VersionIndex: indexPath})
+	require.True(t, buildResult.Success, buildResult.Logs)
+	require.Equal(t, []string{"golang"}, buildResult.DetectedProviders)
+	require.Contains(t, buildResult.Detections[0].Reasons, "score set to 100 by providerScores")
+
+	// A score of 0 ignores the provider
+	files["railpack.json"] = &fstest.MapFile{Data: []byte(`{"providerScores": {"node": 0}}`)}
+	detections, err := DetectProviders(context.Background(), app.NewAppFromFS(files, "app"), app.NewEnvironment(nil), &GenerateBuildPlanOptions{})
+	require.NoError(t, err)
+	require.Len(t, detections, 1)
+	require.Equal(t, "golang", detections[0].Name)
+}
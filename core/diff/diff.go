@@ -0,0 +1,427 @@
+// semantic diff of two build results that ignores step and map ordering
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/railwayapp/railpack/core"
+	"github.com/railwayapp/railpack/core/plan"
+)
+
+const (
+	LineAdded   = "+"
+	LineRemoved = "-"
+)
+
+// LineChange is a line that was added to or removed from an ordered list such as the commands of a step
+type LineChange struct {
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// ValueChange is a keyed value that changed. Before is empty when the key was added and After is empty when it was removed
+type ValueChange struct {
+	Name   string `json:"name"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+type StepDiff struct {
+	Name      string        `json:"name"`
+	Inputs    []LineChange  `json:"inputs,omitempty"`
+	Commands  []LineChange  `json:"commands,omitempty"`
+	Variables []ValueChange `json:"variables,omitempty"`
+	Assets    []ValueChange `json:"assets,omitempty"`
+	Caches    []LineChange  `json:"caches,omitempty"`
+	Secrets   []LineChange  `json:"secrets,omitempty"`
+}
+
+type DeployDiff struct {
+	Base      *ValueChange  `json:"base,omitempty"`
+	StartCmd  *ValueChange  `json:"startCommand,omitempty"`
+	Inputs    []LineChange  `json:"inputs,omitempty"`
+	Variables []ValueChange `json:"variables,omitempty"`
+	Paths     []LineChange  `json:"paths,omitempty"`
+}
+
+type PlanDiff struct {
+	AddedSteps   []string      `json:"addedSteps,omitempty"`
+	RemovedSteps []string      `json:"removedSteps,omitempty"`
+	ChangedSteps []StepDiff    `json:"changedSteps,omitempty"`
+	Packages     []ValueChange `json:"packages,omitempty"`
+	Caches       []ValueChange `json:"caches,omitempty"`
+	Secrets      []LineChange  `json:"secrets,omitempty"`
+	Deploy       *DeployDiff   `json:"deploy,omitempty"`
+}
+
+func (d *PlanDiff) IsEmpty() bool {
+	return len(d.AddedSteps) == 0 && len(d.RemovedSteps) == 0 && len(d.ChangedSteps) == 0 &&
+		len(d.Packages) == 0 && len(d.Caches) == 0 && len(d.Secrets) == 0 && d.Deploy == nil
+}
+
+// LoadBuildResult reads a build result or a bare build plan from a JSON file
+func LoadBuildResult(path string) (*core.BuildResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("error reading %s as JSON: %w", path, err)
+	}
+
+	if _, ok := fields["plan"]; ok {
+		var buildResult core.BuildResult
+		if err := json.Unmarshal(data, &buildResult); err != nil {
+			return nil, fmt.Errorf("error reading %s as a build result: %w", path, err)
+		}
+		return &buildResult, nil
+	}
+
+	var buildPlan plan.BuildPlan
+	if err := json.Unmarshal(data, &buildPlan); err != nil {
+		return nil, fmt.Errorf("error reading %s as a build plan: %w", path, err)
+	}
+
+	return &core.BuildResult{Plan: &buildPlan, Success: true}, nil
+}
+
+// Diff compares two build results. Steps are matched by name and maps are compared by key
+func Diff(before, after *core.BuildResult) *PlanDiff {
+	beforePlan, afterPlan := before.Plan, after.Plan
+	if beforePlan == nil {
+		beforePlan = plan.NewBuildPlan()
+	}
+	if afterPlan == nil {
+		afterPlan = plan.NewBuildPlan()
+	}
+
+	d := &PlanDiff{}
+
+	beforeSteps := stepsByName(beforePlan)
+	afterSteps := stepsByName(afterPlan)
+
+	for _, step := range beforePlan.Steps {
+		if _, ok := afterSteps[step.Name]; !ok {
+			d.RemovedSteps = append(d.RemovedSteps, step.Name)
+		}
+	}
+
+	for _, step := range afterPlan.Steps {
+		beforeStep, ok := beforeSteps[step.Name]
+		if !ok {
+			d.AddedSteps = append(d.AddedSteps, step.Name)
+			continue
+		}
+
+		if stepDiff := diffStep(beforeStep, step); stepDiff != nil {
+			d.ChangedSteps = append(d.ChangedSteps, *stepDiff)
+		}
+	}
+
+	d.Packages = diffMaps(packageVersions(before), packageVersions(after))
+	d.Caches = diffMaps(cacheDescriptions(beforePlan), cacheDescriptions(afterPlan))
+	d.Secrets = diffSets(beforePlan.Secrets, afterPlan.Secrets)
+	d.Deploy = diffDeploy(beforePlan.Deploy, afterPlan.Deploy)
+
+	return d
+}
+
+func diffStep(before, after plan.Step) *StepDiff {
+	d := &StepDiff{
+		Name:      after.Name,
+		Inputs:    diffLines(layerStrings(before.Inputs), layerStrings(after.Inputs)),
+		Commands:  diffLines(commandStrings(before.Commands), commandStrings(after.Commands)),
+		Variables: diffMaps(before.Variables, after.Variables),
+		Assets:    diffMaps(before.Assets, after.Assets),
+		Caches:    diffSets(before.Caches, after.Caches),
+		Secrets:   diffSets(before.Secrets, after.Secrets),
+	}
+
+	if len(d.Inputs) == 0 && len(d.Commands) == 0 && len(d.Variables) == 0 &&
+		len(d.Assets) == 0 && len(d.Caches) == 0 && len(d.Secrets) == 0 {
+		return nil
+	}
+
+	return d
+}
+
+func diffDeploy(before, after plan.Deploy) *DeployDiff {
+	d := &DeployDiff{
+		Base:      diffValue("base", layerString(before.Base), layerString(after.Base)),
+		StartCmd:  diffValue("startCommand", before.StartCmd, after.StartCmd),
+		Inputs:    diffLines(layerStrings(before.Inputs), layerStrings(after.Inputs)),
+		Variables: diffMaps(before.Variables, after.Variables),
+		Paths:     diffLines(before.Paths, after.Paths),
+	}
+
+	if d.Base == nil && d.StartCmd == nil && len(d.Inputs) == 0 && len(d.Variables) == 0 && len(d.Paths) == 0 {
+		return nil
+	}
+
+	return d
+}
+
+func diffValue(name, before, after string) *ValueChange {
+	if before == after {
+		return nil
+	}
+	return &ValueChange{Name: name, Before: before, After: after}
+}
+
+// diffMaps compares two maps by key, sorted by key
+func diffMaps(before, after map[string]string) []ValueChange {
+	changes := []ValueChange{}
+
+	keys := slices.Collect(maps.Keys(before))
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		beforeValue, inBefore := before[key]
+		afterValue, inAfter := after[key]
+		if inBefore && inAfter && beforeValue == afterValue {
+			continue
+		}
+		changes = append(changes, ValueChange{Name: key, Before: beforeValue, After: afterValue})
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// diffSets compares two unordered lists
+func diffSets(before, after []string) []LineChange {
+	before = slices.Sorted(slices.Values(before))
+	after = slices.Sorted(slices.Values(after))
+	return diffLines(slices.Compact(before), slices.Compact(after))
+}
+
+// diffLines compares two ordered lists using their longest common subsequence
+func diffLines(before, after []string) []LineChange {
+	// lcs[i][j] is the length of the longest common subsequence of before[i:] and after[j:]
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	changes := []LineChange{}
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			i++
+			j++
+		case i < len(before) && (j == len(after) || lcs[i+1][j] >= lcs[i][j+1]):
+			changes = append(changes, LineChange{Op: LineRemoved, Value: before[i]})
+			i++
+		default:
+			changes = append(changes, LineChange{Op: LineAdded, Value: after[j]})
+			j++
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+func stepsByName(p *plan.BuildPlan) map[string]plan.Step {
+	steps := map[string]plan.Step{}
+	for _, step := range p.Steps {
+		steps[step.Name] = step
+	}
+	return steps
+}
+
+func packageVersions(br *core.BuildResult) map[string]string {
+	versions := map[string]string{}
+	for name, pkg := range br.ResolvedPackages {
+		if pkg.ResolvedVersion != nil {
+			versions[name] = *pkg.ResolvedVersion
+		} else if pkg.RequestedVersion != nil {
+			versions[name] = *pkg.RequestedVersion
+		}
+	}
+	return versions
+}
+
+func cacheDescriptions(p *plan.BuildPlan) map[string]string {
+	caches := map[string]string{}
+	for name, cache := range p.Caches {
+		caches[name] = fmt.Sprintf("%s (%s)", cache.Directory, cache.Type)
+	}
+	return caches
+}
+
+func layerStrings(layers []plan.Layer) []string {
+	values := []string{}
+	for _, layer := range layers {
+		values = append(values, layerString(layer))
+	}
+	return values
+}
+
+func layerString(layer plan.Layer) string {
+	if layer.IsEmpty() {
+		return ""
+	}
+
+	value := layer.DisplayName()
+	if layer.Local {
+		value = "local"
+	}
+
+	if len(layer.Include) > 0 {
+		value += fmt.Sprintf(" include=%s", strings.Join(layer.Include, ","))
+	}
+	if len(layer.Exclude) > 0 {
+		value += fmt.Sprintf(" exclude=%s", strings.Join(layer.Exclude, ","))
+	}
+
+	return value
+}
+
+func commandStrings(commands []plan.Command) []string {
+	values := []string{}
+	for _, cmd := range commands {
+		values = append(values, commandString(cmd))
+	}
+	return values
+}
+
+func commandString(cmd plan.Command) string {
+	switch cmd := cmd.(type) {
+	case plan.ExecCommand:
+		return fmt.Sprintf("$ %s", cmd.Cmd)
+	case plan.PathCommand:
+		return fmt.Sprintf("PATH %s", cmd.Path)
+	case plan.CopyCommand:
+		if cmd.Image != "" {
+			return fmt.Sprintf("COPY %s:%s %s", cmd.Image, cmd.Src, cmd.Dest)
+		}
+		return fmt.Sprintf("COPY %s %s", cmd.Src, cmd.Dest)
+	case plan.FileCommand:
+		if cmd.Mode != 0 {
+			return fmt.Sprintf("FILE %s from %s (%o)", cmd.Path, cmd.Name, cmd.Mode)
+		}
+		return fmt.Sprintf("FILE %s from %s", cmd.Path, cmd.Name)
+	}
+	return cmd.CommandType()
+}
+
+// Format renders the diff as plain text that can be pasted into a pull request comment
+func Format(d *PlanDiff) string {
+	if d.IsEmpty() {
+		return "No changes\n"
+	}
+
+	var output strings.Builder
+
+	for _, name := range d.AddedSteps {
+		output.WriteString(fmt.Sprintf("+ step %s\n", name))
+	}
+	for _, name := range d.RemovedSteps {
+		output.WriteString(fmt.Sprintf("- step %s\n", name))
+	}
+
+	for _, step := range d.ChangedSteps {
+		output.WriteString(fmt.Sprintf("~ step %s\n", step.Name))
+		formatLines(&output, "inputs", step.Inputs)
+		formatLines(&output, "commands", step.Commands)
+		formatValues(&output, "variables", step.Variables)
+		formatValues(&output, "assets", step.Assets)
+		formatLines(&output, "caches", step.Caches)
+		formatLines(&output, "secrets", step.Secrets)
+	}
+
+	if len(d.Packages) > 0 {
+		output.WriteString("~ packages\n")
+		formatValues(&output, "", d.Packages)
+	}
+
+	if len(d.Caches) > 0 {
+		output.WriteString("~ caches\n")
+		formatValues(&output, "", d.Caches)
+	}
+
+	if len(d.Secrets) > 0 {
+		output.WriteString("~ secrets\n")
+		formatLines(&output, "", d.Secrets)
+	}
+
+	if d.Deploy != nil {
+		output.WriteString("~ deploy\n")
+		for _, change := range []*ValueChange{d.Deploy.Base, d.Deploy.StartCmd} {
+			if change != nil {
+				formatValues(&output, "", []ValueChange{*change})
+			}
+		}
+		formatLines(&output, "inputs", d.Deploy.Inputs)
+		formatValues(&output, "variables", d.Deploy.Variables)
+		formatLines(&output, "paths", d.Deploy.Paths)
+	}
+
+	return output.String()
+}
+
+func formatLines(output *strings.Builder, title string, changes []LineChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	indent := "    "
+	if title != "" {
+		output.WriteString(fmt.Sprintf("    %s:\n", title))
+		indent = "      "
+	}
+
+	for _, change := range changes {
+		output.WriteString(fmt.Sprintf("%s%s %s\n", indent, change.Op, change.Value))
+	}
+}
+
+func formatValues(output *strings.Builder, title string, changes []ValueChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	indent := "    "
+	if title != "" {
+		output.WriteString(fmt.Sprintf("    %s:\n", title))
+		indent = "      "
+	}
+
+	for _, change := range changes {
+		switch {
+		case change.Before == "":
+			output.WriteString(fmt.Sprintf("%s+ %s: %s\n", indent, change.Name, change.After))
+		case change.After == "":
+			output.WriteString(fmt.Sprintf("%s- %s: %s\n", indent, change.Name, change.Before))
+		default:
+			output.WriteString(fmt.Sprintf("%s~ %s: %s -> %s\n", indent, change.Name, change.Before, change.After))
+		}
+	}
+}
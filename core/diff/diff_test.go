@@ -0,0 +1,119 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/railwayapp/railpack/core"
+	"github.com/railwayapp/railpack/core/plan"
+	"github.com/railwayapp/railpack/core/resolver"
+	"github.com/stretchr/testify/require"
+)
+
+func createBuildResult(nodeVersion string, buildCmd string) *core.BuildResult {
+	p := plan.NewBuildPlan()
+
+	install := plan.NewStep("install")
+	install.Inputs = []plan.Layer{plan.NewStepLayer("packages:mise")}
+	install.AddCommands([]plan.Command{plan.NewCopyCommand("package.json"), plan.NewExecCommand("npm ci")})
+
+	build := plan.NewStep("build")
+	build.Inputs = []plan.Layer{plan.NewStepLayer("install"), plan.NewLocalLayer()}
+	build.AddCommands([]plan.Command{plan.NewExecCommand(buildCmd)})
+
+	p.Steps = []plan.Step{*install, *build}
+	p.Caches["npm"] = plan.NewCache("/root/.npm")
+	p.Deploy = plan.Deploy{
+		Base:      plan.NewImageLayer(plan.RailpackRuntimeImage),
+		StartCmd:  "npm start",
+		Variables: map[string]string{"PORT": "3000"},
+	}
+
+	return &core.BuildResult{
+		Plan: p,
+		ResolvedPackages: map[string]*resolver.ResolvedPackage{
+			"node": {Name: "node", ResolvedVersion: &nodeVersion},
+		},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	t.Run("identical", func(t *testing.T) {
+		d := Diff(createBuildResult("22.1.0", "npm run build"), createBuildResult("22.1.0", "npm run build"))
+		require.True(t, d.IsEmpty())
+		require.Equal(t, "No changes\n", Format(d))
+	})
+
+	t.Run("ignores step order", func(t *testing.T) {
+		before := createBuildResult("22.1.0", "npm run build")
+		after := createBuildResult("22.1.0", "npm run build")
+		after.Plan.Steps = []plan.Step{after.Plan.Steps[1], after.Plan.Steps[0]}
+
+		require.True(t, Diff(before, after).IsEmpty())
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		before := createBuildResult("22.1.0", "npm run build")
+		after := createBuildResult("22.2.0", "npm run build:prod")
+
+		lint := plan.NewStep("lint")
+		after.Plan.Steps = append(after.Plan.Steps, *lint)
+		after.Plan.Steps[1].Inputs[1].Exclude = []string{"node_modules"}
+		after.Plan.Deploy.StartCmd = "node server.js"
+		after.Plan.Deploy.Variables = map[string]string{"PORT": "8080", "NODE_ENV": "production"}
+
+		d := Diff(before, after)
+
+		require.Equal(t, []string{"lint"}, d.AddedSteps)
+		require.Empty(t, d.RemovedSteps)
+		require.Equal(t, []StepDiff{{
+			Name: "build",
+			Inputs: []LineChange{
+				{Op: LineRemoved, Value: "local include=."},
+				{Op: LineAdded, Value: "local include=. exclude=node_modules"},
+			},
+			Commands: []LineChange{
+				{Op: LineRemoved, Value: "$ npm run build"},
+				{Op: LineAdded, Value: "$ npm run build:prod"},
+			},
+		}}, d.ChangedSteps)
+		require.Equal(t, []ValueChange{{Name: "node", Before: "22.1.0", After: "22.2.0"}}, d.Packages)
+		require.Equal(t, &ValueChange{Name: "startCommand", Before: "npm start", After: "node server.js"}, d.Deploy.StartCmd)
+		require.Equal(t, []ValueChange{
+			{Name: "NODE_ENV", After: "production"},
+			{Name: "PORT", Before: "3000", After: "8080"},
+		}, d.Deploy.Variables)
+
+		formatted := Format(d)
+		require.Contains(t, formatted, "+ step lint\n")
+		require.Contains(t, formatted, "~ step build\n    inputs:\n      - local include=.\n      + local include=. exclude=node_modules\n")
+		require.Contains(t, formatted, "      ~ PORT: 3000 -> 8080\n")
+	})
+}
+
+func TestDiffLines(t *testing.T) {
+	require.Nil(t, diffLines([]string{"a", "b"}, []string{"a", "b"}))
+	require.Equal(t, []LineChange{
+		{Op: LineRemoved, Value: "b"},
+		{Op: LineAdded, Value: "d"},
+	}, diffLines([]string{"a", "b", "c"}, []string{"a", "c", "d"}))
+}
+
+func TestLoadBuildResult(t *testing.T) {
+	dir := t.TempDir()
+
+	planPath := filepath.Join(dir, "plan.json")
+	require.NoError(t, os.WriteFile(planPath, []byte(`{"$schema": "https://schema.railpack.com", "steps": [{"name": "build", "commands": ["npm run build"]}]}`), 0644))
+
+	resultPath := filepath.Join(dir, "result.json")
+	require.NoError(t, os.WriteFile(resultPath, []byte(`{"plan": {"steps": [{"name": "build"}]}, "resolvedPackages": {"node": {"name": "node", "resolvedVersion": "22.1.0", "source": "railpack default"}}}`), 0644))
+
+	fromPlan, err := LoadBuildResult(planPath)
+	require.NoError(t, err)
+	require.Equal(t, "build", fromPlan.Plan.Steps[0].Name)
+
+	fromResult, err := LoadBuildResult(resultPath)
+	require.NoError(t, err)
+	require.Equal(t, "22.1.0", *fromResult.ResolvedPackages["node"].ResolvedVersion)
+}
@@ -0,0 +1,72 @@
+package generate
+
+import (
+	"context"
+
+	a "github.com/railwayapp/railpack/core/app"
+	"github.com/railwayapp/railpack/core/logger"
+	"github.com/railwayapp/railpack/core/plan"
+)
+
+// ProviderAPIVersion is the version of ProviderContext.
+// Methods are only removed or changed when the version is increased. New methods can be added in the same version
+const ProviderAPIVersion = 1
+
+// ProviderContext is the part of GenerateContext that providers outside of railpack can rely on.
+// The rest of GenerateContext is used by the built-in providers and can change in any release.
+//
+// The builders it returns are the ones the built-in providers use. ProviderAPIVersion covers their methods,
+// except the methods that take a *GenerateContext. Their exported fields are not covered and can change in any release
+type ProviderContext interface {
+	// Done when plan generation is cancelled or times out
+	Context() context.Context
+
+	// The files of the app being planned
+	GetApp() *a.App
+
+	// The environment variables of the app
+	GetEnv() *a.Environment
+
+	// Messages shown in the build output
+	GetLogger() *logger.Logger
+
+	// The step that installs packages with mise (e.g. node or python)
+	GetMiseStepBuilder() *MiseStepBuilder
+
+	// Adds a step that runs commands. The name is namespaced when the provider is composed with another provider
+	NewCommandStep(name string) *CommandStepBuilder
+
+	// A layer with the app source, excluding the files in .dockerignore
+	NewLocalLayer() plan.Layer
+
+	// The start command, variables, and inputs of the final image
+	GetDeploy() *DeployBuilder
+
+	// Caches that can be used by the steps of the plan
+	GetCaches() *CacheContext
+
+	// Properties of the plan reported with the build result
+	GetMetadata() *Metadata
+}
+
+var _ ProviderContext = (*GenerateContext)(nil)
+
+func (c *GenerateContext) GetApp() *a.App {
+	return c.App
+}
+
+func (c *GenerateContext) GetEnv() *a.Environment {
+	return c.Env
+}
+
+func (c *GenerateContext) GetDeploy() *DeployBuilder {
+	return c.Deploy
+}
+
+func (c *GenerateContext) GetCaches() *CacheContext {
+	return c.Caches
+}
+
+func (c *GenerateContext) GetMetadata() *Metadata {
+	return c.Metadata
+}
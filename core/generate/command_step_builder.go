@@ -18,6 +18,15 @@ type CommandStepBuilder struct {
 	Secrets     []string
 	app         *a.App
 	env         *a.Environment
+
+	// The provider functions that created the step and added each command
+	createdBy      string
+	commandCallers []commandCaller
+}
+
+type commandCaller struct {
+	command plan.Command
+	caller  string
 }
 
 func (c *GenerateContext) NewCommandStep(name string) *CommandStepBuilder {
@@ -31,6 +40,7 @@ func (c *GenerateContext) NewCommandStep(name string) *CommandStepBuilder {
 		Secrets:     []string{"*"},
 		app:         c.App,
 		env:         c.Env,
+		createdBy:   callerFunction(),
 	}
 
 	// Remove any existing step with the same name
@@ -76,6 +86,21 @@ func (b *CommandStepBuilder) AddCommands(commands []plan.Command) {
 		b.Commands = []plan.Command{}
 	}
 	b.Commands = append(b.Commands, commands...)
+
+	caller := callerFunction()
+	for _, command := range commands {
+		b.commandCallers = append(b.commandCallers, commandCaller{command: command, caller: caller})
+	}
+}
+
+// commandCaller returns the function that added the command to the step
+func (b *CommandStepBuilder) commandCaller(command plan.Command) string {
+	for _, c := range b.commandCallers {
+		if c.command == command {
+			return c.caller
+		}
+	}
+	return b.createdBy
 }
 
 func (b *CommandStepBuilder) AddEnvVars(envVars map[string]string) {
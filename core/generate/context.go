@@ -1,6 +1,7 @@
 package generate
 
 import (
+	"context"
 	"fmt"
 	"maps"
 	"slices"
@@ -20,6 +21,9 @@ import (
 type BuildStepOptions struct {
 	ResolvedPackages map[string]*resolver.ResolvedPackage
 	Caches           *CacheContext
+
+	// The apt packages installed by each step
+	AptPackages map[string][]string
 }
 
 type StepBuilder interface {
@@ -28,6 +32,8 @@ type StepBuilder interface {
 }
 
 type GenerateContext struct {
+	ctx context.Context
+
 	App             *a.App
 	Env             *a.Environment
 	Config          *config.Config
@@ -45,6 +51,10 @@ type GenerateContext struct {
 	Metadata        *Metadata
 	Resolver        *resolver.Resolver
 	MiseStepBuilder *MiseStepBuilder
+	Provenance      *ProvenanceTracker
+
+	// The apt packages installed by each step. Set by Generate
+	AptPackages map[string][]string
 
 	Logger *logger.Logger
 }
@@ -64,12 +74,19 @@ func (c CommandWrapper) IsSpread() bool {
 	return false
 }
 
-func NewGenerateContext(app *a.App, env *a.Environment, config *config.Config, logger *logger.Logger) (*GenerateContext, error) {
-	resolver, err := resolver.NewResolver(mise.InstallDir)
+// NewGenerateContext creates a context that resolves package versions with mise.
+// The mise commands are stopped when ctx is done
+func NewGenerateContext(ctx context.Context, app *a.App, env *a.Environment, config *config.Config, logger *logger.Logger) (*GenerateContext, error) {
+	resolver, err := resolver.NewResolver(ctx, mise.InstallDir)
 	if err != nil {
 		return nil, err
 	}
 
+	return NewGenerateContextWithResolver(ctx, app, env, config, resolver, logger)
+}
+
+// NewGenerateContextWithResolver creates a context that resolves package versions with the given resolver
+func NewGenerateContextWithResolver(ctx context.Context, app *a.App, env *a.Environment, config *config.Config, resolver *resolver.Resolver, logger *logger.Logger) (*GenerateContext, error) {
 	dockerignoreCtx, err := plan.NewDockerignoreContext(app)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse .dockerignore: %w", err)
@@ -82,7 +99,8 @@ func NewGenerateContext(app *a.App, env *a.Environment, config *config.Config, l
 		log.Debugf("Dockerignore include patterns: %v", dockerignoreCtx.Includes)
 	}
 
-	ctx := &GenerateContext{
+	c := &GenerateContext{
+		ctx:             ctx,
 		App:             app,
 		Env:             env,
 		Config:          config,
@@ -92,17 +110,29 @@ func NewGenerateContext(app *a.App, env *a.Environment, config *config.Config, l
 		Secrets:         []string{},
 		Metadata:        NewMetadata(),
 		Resolver:        resolver,
+		Provenance:      NewProvenanceTracker(),
 		Logger:          logger,
 		dockerignoreCtx: dockerignoreCtx,
 	}
 
-	ctx.applyPackagesFromConfig()
+	c.applyPackagesFromConfig()
+	c.Provenance.Track(c, func(key string) Source {
+		return Source{Type: SourceTypeRailpack, Name: "railpack"}
+	})
 
 	if dockerignoreCtx.HasFile {
-		ctx.Metadata.SetBool("dockerIgnore", true)
+		c.Metadata.SetBool("dockerIgnore", true)
 	}
 
-	return ctx, nil
+	return c, nil
+}
+
+// Context is used to cancel work that runs outside of railpack while generating the plan (e.g. mise commands)
+func (c *GenerateContext) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
 }
 
 func (c *GenerateContext) GetMiseStepBuilder() *MiseStepBuilder {
@@ -122,6 +152,11 @@ func (c *GenerateContext) ExitSubContext() *GenerateContext {
 	return c
 }
 
+// IsComposed checks if a provider is planned after the primary provider, which owns the start command and deploy of the image
+func (c *GenerateContext) IsComposed() bool {
+	return len(c.SubContexts) > 0
+}
+
 func (c *GenerateContext) GetStepName(name string) string {
 	subContextNames := strings.Join(c.SubContexts, ":")
 	if subContextNames != "" {
@@ -140,12 +175,13 @@ func (c *GenerateContext) GetStepByName(name string) *StepBuilder {
 }
 
 func (c *GenerateContext) ResolvePackages() (map[string]*resolver.ResolvedPackage, error) {
-	return c.Resolver.ResolvePackages()
+	return c.Resolver.ResolvePackages(c.Context())
 }
 
 // Generate a build plan from the context
 func (c *GenerateContext) Generate() (*plan.BuildPlan, map[string]*resolver.ResolvedPackage, error) {
 	c.applyConfig()
+	c.Provenance.Track(c, c.Provenance.ConfigSource)
 
 	// Resolve all package versions into a fully qualified and valid version
 	resolvedPackages, err := c.ResolvePackages()
@@ -159,6 +195,7 @@ func (c *GenerateContext) Generate() (*plan.BuildPlan, map[string]*resolver.Reso
 	buildStepOptions := &BuildStepOptions{
 		ResolvedPackages: resolvedPackages,
 		Caches:           c.Caches,
+		AptPackages:      map[string][]string{},
 	}
 
 	for _, stepBuilder := range c.Steps {
@@ -172,16 +209,22 @@ func (c *GenerateContext) Generate() (*plan.BuildPlan, map[string]*resolver.Reso
 	buildPlan.Caches = c.Caches.Caches
 	buildPlan.Secrets = utils.RemoveDuplicates(c.Secrets)
 	c.Deploy.Build(buildPlan, buildStepOptions)
+	c.AptPackages = buildStepOptions.AptPackages
 
 	buildPlan.Normalize()
 
 	return buildPlan, resolvedPackages, nil
 }
 
-func (o *BuildStepOptions) NewAptInstallCommand(pkgs []string) plan.Command {
+func (o *BuildStepOptions) NewAptInstallCommand(stepName string, pkgs []string) plan.Command {
 	pkgs = utils.RemoveDuplicates(pkgs)
 	sort.Strings(pkgs)
 
+	if o.AptPackages == nil {
+		o.AptPackages = map[string][]string{}
+	}
+	o.AptPackages[stepName] = append(o.AptPackages[stepName], pkgs...)
+
 	// sh -c is required because && is a shell operator that needs a shell to interpret it
 	return plan.NewExecCommand("sh -c 'apt-get update && apt-get install -y "+strings.Join(pkgs, " ")+"'", plan.ExecOptions{
 		CustomName: "install apt packages: " + strings.Join(pkgs, " "),
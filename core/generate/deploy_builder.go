@@ -1,6 +1,8 @@
 package generate
 
 import (
+	"maps"
+
 	"github.com/railwayapp/railpack/core/plan"
 )
 
@@ -51,6 +53,18 @@ func (b *DeployBuilder) AddAptPackages(packages []string) {
 	b.AptPackages = append(b.AptPackages, packages...)
 }
 
+func (b *DeployBuilder) SetStartCmd(startCmd string) {
+	b.StartCmd = startCmd
+}
+
+func (b *DeployBuilder) AddVariables(variables map[string]string) {
+	maps.Copy(b.Variables, variables)
+}
+
+func (b *DeployBuilder) AddPaths(paths []string) {
+	b.Paths = append(b.Paths, paths...)
+}
+
 func (b *DeployBuilder) Build(p *plan.BuildPlan, options *BuildStepOptions) {
 	baseLayer := b.Base
 
@@ -58,7 +72,7 @@ func (b *DeployBuilder) Build(p *plan.BuildPlan, options *BuildStepOptions) {
 		runtimeAptStep := plan.NewStep("packages:apt:runtime")
 		runtimeAptStep.Inputs = []plan.Layer{baseLayer}
 		runtimeAptStep.AddCommands([]plan.Command{
-			options.NewAptInstallCommand(b.AptPackages),
+			options.NewAptInstallCommand(runtimeAptStep.Name, b.AptPackages),
 		})
 		runtimeAptStep.Caches = options.Caches.GetAptCaches()
 		runtimeAptStep.Secrets = []string{}
@@ -96,3 +96,15 @@ func TestHasIncludeForStep(t *testing.T) {
 		})
 	}
 }
+
+func TestDeployBuilderRecordsAptPackages(t *testing.T) {
+	deploy := NewDeployBuilder()
+	deploy.AddAptPackages([]string{"libpq5", "curl", "libpq5"})
+
+	p := plan.NewBuildPlan()
+	options := &BuildStepOptions{Caches: NewCacheContext()}
+	deploy.Build(p, options)
+
+	assert.Equal(t, map[string][]string{"packages:apt:runtime": {"curl", "libpq5"}}, options.AptPackages)
+	assert.Equal(t, "packages:apt:runtime", p.Deploy.Base.Step)
+}
@@ -60,7 +60,7 @@ func (b *ImageStepBuilder) Build(p *plan.BuildPlan, options *BuildStepOptions) e
 
 	if len(b.AptPackages) > 0 {
 		step.Commands = []plan.Command{
-			options.NewAptInstallCommand(b.AptPackages),
+			options.NewAptInstallCommand(step.Name, b.AptPackages),
 		}
 	}
 
@@ -0,0 +1,137 @@
+package generate
+
+import (
+	"bufio"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	a "github.com/railwayapp/railpack/core/app"
+	"github.com/railwayapp/railpack/core/mise"
+)
+
+// The tools of idiomatic version files that mise reads (see mise.IdiomaticVersionFileTools)
+var idiomaticVersionFiles = map[string]string{
+	".python-version": "python",
+	".node-version":   "node",
+	".nvmrc":          "node",
+}
+
+// readMiseConfigVersions reads the package versions of the mise config files in the app without the mise binary.
+// Only the simple forms of the files are supported, so the files that cannot be read are returned instead of failing
+func readMiseConfigVersions(app *a.App) (map[string]*MisePackageInfo, []string) {
+	packages := map[string]*MisePackageInfo{}
+	skipped := []string{}
+
+	// mise.toml takes precedence over .tool-versions, which takes precedence over the idiomatic version files
+	for _, file := range []string{".python-version", ".node-version", ".nvmrc", ".tool-versions", "mise.toml"} {
+		if !app.HasFile(file) {
+			continue
+		}
+
+		content, err := app.ReadFile(file)
+		if err != nil {
+			skipped = append(skipped, file)
+			continue
+		}
+
+		var versions map[string]string
+		switch file {
+		case "mise.toml":
+			versions, err = parseMiseToml(content)
+		case ".tool-versions":
+			versions, err = parseToolVersions(content)
+		default:
+			versions, err = parseIdiomaticVersionFile(idiomaticVersionFiles[file], content)
+		}
+		if err != nil {
+			skipped = append(skipped, file)
+			continue
+		}
+
+		for name, version := range versions {
+			packages[name] = &MisePackageInfo{Version: version, Source: file}
+		}
+	}
+
+	return packages, skipped
+}
+
+func parseMiseToml(content string) (map[string]string, error) {
+	var config struct {
+		Tools map[string]any `toml:"tools"`
+	}
+	if _, err := toml.Decode(content, &config); err != nil {
+		return nil, err
+	}
+
+	versions := map[string]string{}
+	for name, value := range config.Tools {
+		// A tool can be a version, a list of versions where the first is used, or a table with a version
+		switch v := value.(type) {
+		case string:
+			versions[name] = v
+		case []any:
+			if len(v) > 0 {
+				if version, ok := v[0].(string); ok {
+					versions[name] = version
+				}
+			}
+		case map[string]any:
+			if version, ok := v["version"].(string); ok {
+				versions[name] = version
+			}
+		}
+
+		if versions[name] == "" {
+			return nil, fmt.Errorf("unsupported version for %s", name)
+		}
+	}
+
+	return versions, nil
+}
+
+func parseToolVersions(content string) (map[string]string, error) {
+	versions := map[string]string{}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("no version for %s", fields[0])
+		}
+
+		// Later versions on the line are fallbacks, so the first one is used
+		versions[fields[0]] = fields[1]
+	}
+
+	return versions, scanner.Err()
+}
+
+func parseIdiomaticVersionFile(tool string, content string) (map[string]string, error) {
+	if !slices.Contains(strings.Split(mise.IdiomaticVersionFileTools, ","), tool) {
+		return nil, nil
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		version := strings.TrimPrefix(strings.TrimSpace(line), "v")
+		if version == "" {
+			continue
+		}
+
+		// Aliases like lts/iron are resolved by mise
+		if strings.Contains(version, "/") || !strings.ContainsAny(version[:1], "0123456789") {
+			return nil, fmt.Errorf("unsupported version %s", version)
+		}
+
+		return map[string]string{tool: version}, nil
+	}
+
+	return nil, nil
+}
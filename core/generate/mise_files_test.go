@@ -0,0 +1,56 @@
+package generate
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/railwayapp/railpack/core/app"
+	"github.com/railwayapp/railpack/core/config"
+	"github.com/railwayapp/railpack/core/logger"
+	"github.com/railwayapp/railpack/core/resolver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadMiseConfigVersions(t *testing.T) {
+	files := fstest.MapFS{
+		".python-version": {Data: []byte("# pinned\n3.11\n")},
+		".nvmrc":          {Data: []byte("v20.11.1\n")},
+		".tool-versions":  {Data: []byte("python 3.12.1 3.11 # fallback\nuv 0.7\n")},
+		"mise.toml":       {Data: []byte("[tools]\nuv = { version = \"0.8\" }\nruby = [\"3.3\", \"3.2\"]\n")},
+	}
+
+	packages, skipped := readMiseConfigVersions(app.NewAppFromFS(files, "app"))
+	require.Empty(t, skipped)
+	require.Equal(t, map[string]*MisePackageInfo{
+		"python": {Version: "3.12.1", Source: ".tool-versions"},
+		"node":   {Version: "20.11.1", Source: ".nvmrc"},
+		"uv":     {Version: "0.8", Source: "mise.toml"},
+		"ruby":   {Version: "3.3", Source: "mise.toml"},
+	}, packages)
+
+	packages, skipped = readMiseConfigVersions(app.NewAppFromFS(fstest.MapFS{
+		".nvmrc":    {Data: []byte("lts/iron\n")},
+		"mise.toml": {Data: []byte("[tools\n")},
+	}, "app"))
+	require.Empty(t, packages)
+	require.Equal(t, []string{".nvmrc", "mise.toml"}, skipped)
+}
+
+func TestUseMiseVersionsOffline(t *testing.T) {
+	files := fstest.MapFS{
+		".tool-versions": {Data: []byte("node 20\n")},
+		".nvmrc":         {Data: []byte("lts/*\n")},
+	}
+
+	ctx, err := NewGenerateContextWithResolver(context.Background(), app.NewAppFromFS(files, "app"), app.NewEnvironment(nil), config.EmptyConfig(), resolver.NewOfflineResolver(&resolver.VersionIndex{}), logger.NewLogger())
+	require.NoError(t, err)
+
+	miseStep := ctx.GetMiseStepBuilder()
+	miseStep.Default("node", "22")
+	miseStep.UseMiseVersions(ctx, []string{"node"})
+
+	require.Equal(t, "20", ctx.Resolver.Get("node").Version)
+	require.Equal(t, ".tool-versions", ctx.Resolver.Get("node").Source)
+	require.Contains(t, ctx.Logger.Logs, logger.Msg{Level: logger.Warn, Msg: "Ignoring the package versions in .nvmrc, which cannot be read when resolving offline"})
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"maps"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -116,13 +118,22 @@ func (b *MiseStepBuilder) SkipMiseInstall(name resolver.PackageRef) {
 // GetMisePackageVersions gets all package versions from mise that are defined in the app directory environment
 // this can include additional packages defined outside the app directory, but we filter those out
 func (b *MiseStepBuilder) GetMisePackageVersions(ctx *GenerateContext) (map[string]*MisePackageInfo, error) {
-	miseInstance, err := mise.New(mise.InstallDir)
+	miseInstance, err := mise.New(ctx.Context(), mise.InstallDir)
 	if err != nil {
 		return nil, err
 	}
 
-	appDir := ctx.GetAppSource()
-	output, err := miseInstance.GetCurrentList(appDir)
+	appDir := ctx.App.Dir()
+	if appDir == "" {
+		// Mise reads config files from disk, so the config files of apps that are not on disk are written to a temporary directory
+		appDir, err = writeMiseConfigFiles(ctx.App)
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(appDir)
+	}
+
+	output, err := miseInstance.GetCurrentList(ctx.Context(), appDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get package versions: %w", err)
 	}
@@ -169,10 +180,21 @@ func (b *MiseStepBuilder) GetMisePackageVersions(ctx *GenerateContext) (map[stri
 
 // Use mise-specified versions for all packages in the input list
 func (b *MiseStepBuilder) UseMiseVersions(ctx *GenerateContext, packages []string) {
-	miseVersions, err := b.GetMisePackageVersions(ctx)
-	if err != nil {
-		ctx.Logger.LogWarn("Failed to get package versions from mise: %s", err.Error())
-		return
+	var miseVersions map[string]*MisePackageInfo
+	if b.Resolver.IsOffline() {
+		// The mise binary is not installed offline, so the simple forms of the config files are read directly
+		versions, skipped := readMiseConfigVersions(ctx.App)
+		for _, file := range skipped {
+			ctx.Logger.LogWarn("Ignoring the package versions in %s, which cannot be read when resolving offline", file)
+		}
+		miseVersions = versions
+	} else {
+		versions, err := b.GetMisePackageVersions(ctx)
+		if err != nil {
+			ctx.Logger.LogWarn("Failed to get package versions from mise: %s", err.Error())
+			return
+		}
+		miseVersions = versions
 	}
 
 	if miseVersions == nil {
@@ -222,7 +244,7 @@ func (b *MiseStepBuilder) Build(p *plan.BuildPlan, options *BuildStepOptions) er
 		aptStep := plan.NewStep("packages:apt:build")
 		aptStep.Inputs = []plan.Layer{baseLayer}
 		aptStep.AddCommands([]plan.Command{
-			options.NewAptInstallCommand(b.SupportingAptPackages),
+			options.NewAptInstallCommand(aptStep.Name, b.SupportingAptPackages),
 		})
 		aptStep.Caches = options.Caches.GetAptCaches()
 		aptStep.Secrets = []string{}
@@ -318,6 +340,33 @@ var miseConfigFiles = []string{
 	".bun-version",
 }
 
+// writeMiseConfigFiles writes the mise config files of the app to a temporary directory
+func writeMiseConfigFiles(app *a.App) (string, error) {
+	dir, err := os.MkdirTemp("", "railpack-mise-config-")
+	if err != nil {
+		return "", err
+	}
+
+	for _, file := range miseConfigFiles {
+		if !app.HasFile(file) {
+			continue
+		}
+
+		content, err := app.ReadFile(file)
+		if err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+	}
+
+	return dir, nil
+}
+
 func (b *MiseStepBuilder) GetSupportingMiseConfigFiles(path string) []string {
 	files := []string{}
 
@@ -1,6 +1,7 @@
 package generate
 
 import (
+	"context"
 	"os"
 	"strings"
 	"testing"
@@ -19,7 +20,7 @@ func TestGetPackageVersions(t *testing.T) {
 	ctx := CreateTestContext(t, "../../examples/python-uv-tool-versions")
 
 	// Create a resolver
-	resolver, err := resolver.NewResolver(tempDir)
+	resolver, err := resolver.NewResolver(context.Background(), tempDir)
 	require.NoError(t, err)
 
 	builder := &MiseStepBuilder{
@@ -73,7 +74,7 @@ func TestGetPackageVersionsWithNoToolVersions(t *testing.T) {
 	ctx := CreateTestContext(t, "../../examples/node-tanstack-start")
 
 	// Create a resolver
-	resolver, err := resolver.NewResolver(tempDir)
+	resolver, err := resolver.NewResolver(context.Background(), tempDir)
 	require.NoError(t, err)
 
 	builder := &MiseStepBuilder{
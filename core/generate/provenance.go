@@ -0,0 +1,295 @@
+// records where every step, command, variable, and cache in the plan came from
+package generate
+
+import (
+	"fmt"
+	"maps"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/railwayapp/railpack/core/plan"
+)
+
+const (
+	SourceTypeProvider = "provider"
+	SourceTypeConfig   = "config"
+	SourceTypeEnv      = "env"
+	SourceTypeCLI      = "cli"
+	SourceTypeRailpack = "railpack"
+
+	modulePath = "github.com/railwayapp/railpack/"
+)
+
+// Source describes where part of the plan came from
+type Source struct {
+	// One of provider, config, env, cli, or railpack
+	Type string `json:"type"`
+
+	// The provider name, config file, environment variable, or CLI flag
+	Name string `json:"name"`
+
+	// The provider function or config key
+	Detail string `json:"detail,omitempty"`
+}
+
+func (s Source) String() string {
+	if s.Detail == "" {
+		return fmt.Sprintf("%s %s", s.Type, s.Name)
+	}
+	return fmt.Sprintf("%s %s (%s)", s.Type, s.Name, s.Detail)
+}
+
+type StepProvenance struct {
+	// The source that created the step followed by every source that modified it
+	Sources []Source `json:"sources"`
+
+	// The source of each command, in the same order as the step commands
+	Commands []Source `json:"commands,omitempty"`
+
+	Variables map[string]Source `json:"variables,omitempty"`
+	Caches    map[string]Source `json:"caches,omitempty"`
+}
+
+type DeployProvenance struct {
+	StartCmd  *Source           `json:"startCommand,omitempty"`
+	Variables map[string]Source `json:"variables,omitempty"`
+}
+
+type Provenance struct {
+	Steps  map[string]*StepProvenance `json:"steps,omitempty"`
+	Deploy DeployProvenance           `json:"deploy"`
+	Caches map[string]Source          `json:"caches,omitempty"`
+}
+
+type commandProvenance struct {
+	command plan.Command
+	source  Source
+}
+
+type stepTracker struct {
+	provenance *StepProvenance
+	commands   []commandProvenance
+	variables  map[string]string
+}
+
+// ProvenanceTracker attributes changes to the generate context to the source that made them
+type ProvenanceTracker struct {
+	// Where each config key came from, keyed by paths such as `steps.build.commands`
+	ConfigSources map[string]Source
+
+	steps        map[string]*stepTracker
+	caches       map[string]*plan.Cache
+	cacheSources map[string]Source
+	deploy       DeployProvenance
+	startCmd     string
+	variables    map[string]string
+}
+
+func NewProvenanceTracker() *ProvenanceTracker {
+	return &ProvenanceTracker{
+		ConfigSources: map[string]Source{},
+		steps:         map[string]*stepTracker{},
+		caches:        map[string]*plan.Cache{},
+		cacheSources:  map[string]Source{},
+		deploy:        DeployProvenance{Variables: map[string]Source{}},
+		variables:     map[string]string{},
+	}
+}
+
+// ProviderSource returns a resolver that attributes every change to a provider
+func ProviderSource(name string) func(key string) Source {
+	return func(key string) Source {
+		return Source{Type: SourceTypeProvider, Name: name}
+	}
+}
+
+// ConfigSource returns where a config key came from, falling back to the closest parent key
+func (t *ProvenanceTracker) ConfigSource(key string) Source {
+	for k := key; k != ""; {
+		if source, ok := t.ConfigSources[k]; ok {
+			return source
+		}
+
+		idx := strings.LastIndex(k, ".")
+		if idx == -1 {
+			break
+		}
+		k = k[:idx]
+	}
+
+	return Source{Type: SourceTypeConfig, Name: "config", Detail: key}
+}
+
+// Track attributes everything that changed since the last call to the source returned for its key
+func (t *ProvenanceTracker) Track(ctx *GenerateContext, source func(key string) Source) {
+	for _, stepBuilder := range ctx.Steps {
+		name := stepBuilder.Name()
+
+		step, exists := t.steps[name]
+		if !exists {
+			stepSource := source("steps." + name)
+			if csb, ok := stepBuilder.(*CommandStepBuilder); ok && stepSource.Type == SourceTypeProvider {
+				stepSource.Detail = csb.createdBy
+			}
+
+			step = &stepTracker{
+				provenance: &StepProvenance{
+					Sources:   []Source{stepSource},
+					Variables: map[string]Source{},
+					Caches:    map[string]Source{},
+				},
+				variables: map[string]string{},
+			}
+			t.steps[name] = step
+		}
+
+		csb, ok := stepBuilder.(*CommandStepBuilder)
+		if !ok {
+			continue
+		}
+
+		if t.trackCommandStep(step, csb, source) && exists {
+			modifiedBy := source("steps." + name)
+			if !slices.Contains(step.provenance.Sources, modifiedBy) {
+				step.provenance.Sources = append(step.provenance.Sources, modifiedBy)
+			}
+		}
+	}
+
+	for name, cache := range ctx.Caches.Caches {
+		if t.caches[name] != cache {
+			t.caches[name] = cache
+			t.cacheSources[name] = source("caches." + name)
+		}
+	}
+
+	if ctx.Deploy.StartCmd != t.startCmd {
+		t.startCmd = ctx.Deploy.StartCmd
+		startSource := source("deploy.startCommand")
+		t.deploy.StartCmd = &startSource
+	}
+
+	for name, value := range ctx.Deploy.Variables {
+		if previous, ok := t.variables[name]; !ok || previous != value {
+			t.variables[name] = value
+			t.deploy.Variables[name] = source("deploy.variables." + name)
+		}
+	}
+}
+
+// trackCommandStep attributes new commands, variables, and caches of a step, returning true if anything changed
+func (t *ProvenanceTracker) trackCommandStep(step *stepTracker, csb *CommandStepBuilder, source func(key string) Source) bool {
+	changed := false
+	name := csb.Name()
+
+	// Commands are matched by value so that reordering (e.g. spreading config commands) keeps their source
+	previous := slices.Clone(step.commands)
+	commands := make([]commandProvenance, 0, len(csb.Commands))
+	for _, cmd := range csb.Commands {
+		idx := slices.IndexFunc(previous, func(p commandProvenance) bool { return p.command == cmd })
+		if idx != -1 {
+			commands = append(commands, previous[idx])
+			previous = slices.Delete(previous, idx, idx+1)
+			continue
+		}
+
+		commandSource := source("steps." + name + ".commands")
+		if commandSource.Type == SourceTypeProvider {
+			commandSource.Detail = csb.commandCaller(cmd)
+		}
+
+		commands = append(commands, commandProvenance{command: cmd, source: commandSource})
+		changed = true
+	}
+	step.commands = commands
+	changed = changed || len(previous) > 0
+
+	for name, value := range csb.Variables {
+		if previous, ok := step.variables[name]; !ok || previous != value {
+			step.variables[name] = value
+			step.provenance.Variables[name] = source("steps." + csb.Name() + ".variables." + name)
+			changed = true
+		}
+	}
+
+	for _, cache := range csb.Caches {
+		if _, ok := step.provenance.Caches[cache]; !ok {
+			step.provenance.Caches[cache] = source("steps." + csb.Name() + ".caches")
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// Result returns the provenance of every step in the generated plan
+func (t *ProvenanceTracker) Result(p *plan.BuildPlan) *Provenance {
+	result := &Provenance{
+		Steps: map[string]*StepProvenance{},
+		Deploy: DeployProvenance{
+			StartCmd:  t.deploy.StartCmd,
+			Variables: map[string]Source{},
+		},
+		Caches: map[string]Source{},
+	}
+
+	for _, step := range p.Steps {
+		tracked, ok := t.steps[step.Name]
+		if !ok {
+			// Steps such as the runtime apt packages are only created when the plan is generated
+			result.Steps[step.Name] = &StepProvenance{Sources: []Source{{Type: SourceTypeRailpack, Name: "railpack"}}}
+			continue
+		}
+
+		provenance := *tracked.provenance
+		provenance.Variables = maps.Clone(tracked.provenance.Variables)
+		provenance.Caches = maps.Clone(tracked.provenance.Caches)
+
+		if len(tracked.commands) == len(step.Commands) {
+			for _, cmd := range tracked.commands {
+				provenance.Commands = append(provenance.Commands, cmd.source)
+			}
+		} else {
+			// Commands of other step builders are only created when the plan is generated
+			for range step.Commands {
+				provenance.Commands = append(provenance.Commands, provenance.Sources[0])
+			}
+		}
+
+		result.Steps[step.Name] = &provenance
+	}
+
+	for name := range p.Deploy.Variables {
+		if source, ok := t.deploy.Variables[name]; ok {
+			result.Deploy.Variables[name] = source
+		}
+	}
+
+	for name := range p.Caches {
+		if source, ok := t.cacheSources[name]; ok {
+			result.Caches[name] = source
+		}
+	}
+
+	return result
+}
+
+// callerFunction returns the first function on the stack outside of the generate and plan packages
+func callerFunction() string {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		function := strings.TrimPrefix(frame.Function, modulePath)
+		if !strings.HasPrefix(function, "core/generate.") && !strings.HasPrefix(function, "core/plan.") {
+			return strings.TrimPrefix(function, "core/providers/")
+		}
+
+		if !more {
+			return ""
+		}
+	}
+}
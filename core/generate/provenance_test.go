@@ -0,0 +1,83 @@
+package generate
+
+import (
+	"testing"
+
+	"github.com/railwayapp/railpack/core/config"
+	"github.com/railwayapp/railpack/core/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenanceTracker(t *testing.T) {
+	ctx := CreateTestContext(t, "../../examples/node-npm")
+	provider := &TestProvider{}
+	require.NoError(t, provider.Plan(ctx))
+	ctx.Deploy.StartCmd = "npm start"
+	ctx.Provenance.Track(ctx, ProviderSource("test"))
+
+	buildConfig := &config.StepConfig{Step: *plan.NewStep("build")}
+	buildConfig.Commands = []plan.Command{
+		plan.NewExecCommand("..."),
+		plan.NewExecCommand("npm run lint"),
+	}
+	buildConfig.Variables = map[string]string{"NODE_ENV": "production"}
+	ctx.Config.Steps["build"] = buildConfig
+	ctx.Config.Deploy.StartCmd = "node server.js"
+
+	envSource := Source{Type: SourceTypeEnv, Name: "RAILPACK_START_CMD", Detail: "deploy.startCommand"}
+	ctx.Provenance.ConfigSources = map[string]Source{
+		"steps.build":         {Type: SourceTypeConfig, Name: "railpack.json", Detail: "steps.build"},
+		"deploy.startCommand": envSource,
+	}
+	ctx.applyConfig()
+	ctx.Provenance.Track(ctx, ctx.Provenance.ConfigSource)
+
+	buildPlan := plan.NewBuildPlan()
+	for _, step := range ctx.Steps {
+		if csb, ok := step.(*CommandStepBuilder); ok {
+			require.NoError(t, csb.Build(buildPlan, &BuildStepOptions{}))
+		}
+	}
+	buildPlan.Deploy.StartCmd = ctx.Deploy.StartCmd
+
+	result := ctx.Provenance.Result(buildPlan)
+
+	providerSource := Source{Type: SourceTypeProvider, Name: "test"}
+	configSource := Source{Type: SourceTypeConfig, Name: "railpack.json", Detail: "steps.build"}
+
+	install := result.Steps["install"]
+	require.Equal(t, []Source{providerSource}, withoutDetails(install.Sources))
+	require.Equal(t, []Source{providerSource}, withoutDetails(install.Commands))
+
+	build := result.Steps["build"]
+	require.Equal(t, []Source{providerSource, configSource}, withoutDetails(build.Sources))
+	require.Equal(t, []Source{providerSource, configSource}, withoutDetails(build.Commands))
+	require.Equal(t, configSource, build.Variables["NODE_ENV"])
+
+	require.Equal(t, &envSource, result.Deploy.StartCmd)
+}
+
+func TestProvenanceConfigSource(t *testing.T) {
+	tracker := NewProvenanceTracker()
+	tracker.ConfigSources["steps.build"] = Source{Type: SourceTypeEnv, Name: "RAILPACK_BUILD_CMD"}
+
+	require.Equal(t, "RAILPACK_BUILD_CMD", tracker.ConfigSource("steps.build.commands").Name)
+	require.Equal(t, Source{Type: SourceTypeConfig, Name: "config", Detail: "deploy.startCommand"}, tracker.ConfigSource("deploy.startCommand"))
+}
+
+func TestSourceString(t *testing.T) {
+	require.Equal(t, "provider node", Source{Type: SourceTypeProvider, Name: "node"}.String())
+	require.Equal(t, "env RAILPACK_BUILD_CMD (steps.build.commands)", Source{Type: SourceTypeEnv, Name: "RAILPACK_BUILD_CMD", Detail: "steps.build.commands"}.String())
+}
+
+// withoutDetails removes the provider function from provider sources since it depends on the caller
+func withoutDetails(sources []Source) []Source {
+	result := []Source{}
+	for _, source := range sources {
+		if source.Type == SourceTypeProvider {
+			source.Detail = ""
+		}
+		result = append(result, source)
+	}
+	return result
+}
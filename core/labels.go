@@ -0,0 +1,121 @@
+package core
+
+import (
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/railwayapp/railpack/core/app"
+)
+
+// Standard OCI image annotations (https://github.com/opencontainers/image-spec/blob/main/annotations.md)
+const (
+	LabelSource   = "org.opencontainers.image.source"
+	LabelRevision = "org.opencontainers.image.revision"
+	LabelCreated  = "org.opencontainers.image.created"
+	LabelTitle    = "org.opencontainers.image.title"
+
+	LabelRailpackVersion  = "com.railpack.version"
+	LabelRailpackProvider = "com.railpack.provider"
+)
+
+type GitMetadata struct {
+	// URL of the origin remote (e.g. https://github.com/railwayapp/railpack)
+	Source string `json:"source,omitempty"`
+
+	// The commit that is checked out
+	Revision string `json:"revision,omitempty"`
+
+	// When the checked out commit was committed. Used as the SOURCE_DATE_EPOCH of reproducible builds
+	CommitTime *time.Time `json:"commitTime,omitempty"`
+}
+
+var scpLikeRemoteRegex = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// ReadGitMetadata reads the origin remote and checked out commit of the git repository the directory is in.
+// Returns nil if the directory is not in a git repository
+func ReadGitMetadata(dir string) *GitMetadata {
+	revision, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return nil
+	}
+
+	metadata := &GitMetadata{Revision: strings.TrimSpace(string(revision))}
+
+	if commitTime, err := exec.Command("git", "-C", dir, "log", "-1", "--format=%ct").Output(); err == nil {
+		if seconds, err := strconv.ParseInt(strings.TrimSpace(string(commitTime)), 10, 64); err == nil {
+			t := time.Unix(seconds, 0).UTC()
+			metadata.CommitTime = &t
+		}
+	}
+
+	if remote, err := exec.Command("git", "-C", dir, "remote", "get-url", "origin").Output(); err == nil {
+		metadata.Source = normalizeGitRemote(strings.TrimSpace(string(remote)))
+	}
+
+	return metadata
+}
+
+// normalizeGitRemote converts a git remote to a browsable URL without credentials
+// (e.g. git@github.com:org/app.git is https://github.com/org/app)
+func normalizeGitRemote(remote string) string {
+	if remote == "" {
+		return ""
+	}
+
+	if !strings.Contains(remote, "://") {
+		match := scpLikeRemoteRegex.FindStringSubmatch(remote)
+		if match == nil {
+			return ""
+		}
+		remote = "https://" + match[1] + "/" + match[2]
+	}
+
+	scheme, rest, _ := strings.Cut(remote, "://")
+	switch scheme {
+	case "ssh", "git", "git+ssh":
+		scheme = "https"
+	case "file":
+		return ""
+	}
+
+	host, path, _ := strings.Cut(rest, "/")
+	if i := strings.LastIndex(host, "@"); i != -1 {
+		host = host[i+1:]
+	}
+	if scheme == "https" {
+		// The port of an ssh remote is not the port of the web URL
+		host, _, _ = strings.Cut(host, ":")
+	}
+
+	return scheme + "://" + host + "/" + strings.TrimSuffix(strings.TrimSuffix(path, "/"), ".git")
+}
+
+// ImageLabels returns the OCI labels and annotations for the image built from the build result
+func ImageLabels(app *app.App, result *BuildResult, git *GitMetadata, created time.Time) map[string]string {
+	labels := map[string]string{
+		LabelTitle:   filepath.Base(app.Source),
+		LabelCreated: created.UTC().Format(time.RFC3339),
+	}
+
+	if git != nil {
+		setLabel(labels, LabelSource, git.Source)
+		setLabel(labels, LabelRevision, git.Revision)
+	}
+
+	if result != nil {
+		setLabel(labels, LabelRailpackVersion, result.RailpackVersion)
+		setLabel(labels, LabelRailpackProvider, result.Metadata["providers"])
+	}
+
+	return labels
+}
+
+func setLabel(labels map[string]string, key, value string) {
+	if value != "" {
+		labels[key] = value
+	}
+}
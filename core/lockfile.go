@@ -0,0 +1,60 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/railwayapp/railpack/core/app"
+	"github.com/railwayapp/railpack/core/resolver"
+)
+
+// ReadLockFile reads the lock file from the app, returning nil if it does not exist
+func ReadLockFile(app *app.App) (*resolver.LockFile, error) {
+	if !app.HasFile(resolver.LockFileName) {
+		return nil, nil
+	}
+
+	lockFile := &resolver.LockFile{}
+	if err := app.ReadJSON(resolver.LockFileName, lockFile); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", resolver.LockFileName, err)
+	}
+
+	return lockFile, nil
+}
+
+// WriteLockFile writes the resolved packages of a build result to the lock file in the app
+func WriteLockFile(app *app.App, buildResult *BuildResult) (string, error) {
+	lockFile := resolver.NewLockFile(buildResult.ResolvedPackages)
+
+	data, err := json.MarshalIndent(lockFile, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	if app.Dir() == "" {
+		return "", fmt.Errorf("cannot write %s to an app that is not on disk", resolver.LockFileName)
+	}
+
+	path := filepath.Join(app.Dir(), resolver.LockFileName)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// checkLockFile returns an error describing every difference between the lock file and the resolved packages
+func checkLockFile(lockFile *resolver.LockFile, resolvedPackages map[string]*resolver.ResolvedPackage) error {
+	if lockFile == nil {
+		return fmt.Errorf("%s not found. Run `railpack plan --write-lock` to create it", resolver.LockFileName)
+	}
+
+	if differences := lockFile.Diff(resolvedPackages); len(differences) > 0 {
+		return fmt.Errorf("%s is out of date. Run `railpack plan --write-lock` to update it\n  %s", resolver.LockFileName, strings.Join(differences, "\n  "))
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
+	"context"
 	_ "embed"
 	"fmt"
 	"io"
@@ -13,6 +14,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/charmbracelet/log"
 )
@@ -72,8 +74,14 @@ func getBinaryPath(cacheDir string) string {
 	return filepath.Join(cacheDir, getBinaryName())
 }
 
+// installMu prevents concurrent plans from downloading mise at the same time
+var installMu sync.Mutex
+
 // ensures the mise binary (at the pinned version) is installed and returns its path
-func ensureInstalled(cacheDir string) (string, error) {
+func ensureInstalled(ctx context.Context, cacheDir string) (string, error) {
+	installMu.Lock()
+	defer installMu.Unlock()
+
 	binaryPath := getBinaryPath(cacheDir)
 
 	if _, err := os.Stat(binaryPath); err == nil {
@@ -87,11 +95,11 @@ func ensureInstalled(cacheDir string) (string, error) {
 		return "", fmt.Errorf("failed to create cache directory: %w", err)
 	}
 
-	if err := downloadAndInstall(cacheDir); err != nil {
+	if err := downloadAndInstall(ctx, cacheDir); err != nil {
 		return "", fmt.Errorf("failed to download and install: %w", err)
 	}
 
-	if err := validateInstallation(cacheDir); err != nil {
+	if err := validateInstallation(ctx, cacheDir); err != nil {
 		return "", fmt.Errorf("failed to validate installation: %w", err)
 	}
 
@@ -100,7 +108,7 @@ func ensureInstalled(cacheDir string) (string, error) {
 	return binaryPath, nil
 }
 
-func downloadAndInstall(cacheDir string) error {
+func downloadAndInstall(ctx context.Context, cacheDir string) error {
 	assetName, err := getAssetName()
 	if err != nil {
 		return err
@@ -111,7 +119,12 @@ func downloadAndInstall(cacheDir string) error {
 
 	log.Debugf("Downloading mise from %s", url)
 
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create download request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to download mise: %w", err)
 	}
@@ -238,9 +251,9 @@ func extractZip(archivePath, binaryPath string) error {
 	return fmt.Errorf("binary not found in archive")
 }
 
-func validateInstallation(cacheDir string) error {
+func validateInstallation(ctx context.Context, cacheDir string) error {
 	binaryPath := getBinaryPath(cacheDir)
-	cmd := exec.Command(binaryPath, "--version")
+	cmd := exec.CommandContext(ctx, binaryPath, "--version")
 	output, err := cmd.Output()
 	if err != nil {
 		return fmt.Errorf("failed to run version check: %w", err)
@@ -6,11 +6,14 @@ package mise
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/alexflint/go-filemutex"
@@ -34,8 +37,11 @@ const (
 	ErrMiseGetLatestVersion = "failed to resolve version %s of %s"
 )
 
-func New(cacheDir string) (*Mise, error) {
-	binaryPath, err := ensureInstalled(cacheDir)
+// How often a locked package is checked while waiting for another mise command to finish
+const lockRetryInterval = 25 * time.Millisecond
+
+func New(ctx context.Context, cacheDir string) (*Mise, error) {
+	binaryPath, err := ensureInstalled(ctx, cacheDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to ensure mise is installed: %w", err)
 	}
@@ -51,8 +57,8 @@ func New(cacheDir string) (*Mise, error) {
 }
 
 // gets the latest version of a package matching the version constraint
-func (m *Mise) GetLatestVersion(pkg, version string) (string, error) {
-	_, unlock, err := m.createAndLock(pkg)
+func (m *Mise) GetLatestVersion(ctx context.Context, pkg, version string) (string, error) {
+	_, unlock, err := m.createAndLock(ctx, pkg)
 	if err != nil {
 		return "", err
 	}
@@ -62,13 +68,13 @@ func (m *Mise) GetLatestVersion(pkg, version string) (string, error) {
 	query := fmt.Sprintf("%s@%s", pkg, semverVersion)
 
 	// Try with extracted semver version first
-	output, err := m.runCmdWithEnv([]string{"MISE_NO_CONFIG=1", "MISE_PARANOID=1"}, "latest", query)
+	output, err := m.runCmdWithEnv(ctx, []string{"MISE_NO_CONFIG=1", "MISE_PARANOID=1"}, "latest", query)
 
 	// If semver extraction fails, try with original version
 	// https://github.com/railwayapp/railpack/issues/203
 	if (err != nil || strings.TrimSpace(output) == "") && semverVersion != version {
 		query = fmt.Sprintf("%s@%s", pkg, version)
-		output, err = m.runCmdWithEnv([]string{"MISE_NO_CONFIG=1", "MISE_PARANOID=1"}, "latest", query)
+		output, err = m.runCmdWithEnv(ctx, []string{"MISE_NO_CONFIG=1", "MISE_PARANOID=1"}, "latest", query)
 	}
 
 	if err != nil {
@@ -87,8 +93,8 @@ func (m *Mise) GetLatestVersion(pkg, version string) (string, error) {
 	return latestVersion, nil
 }
 
-func (m *Mise) GetAllVersions(pkg, version string) ([]string, error) {
-	_, unlock, err := m.createAndLock(pkg)
+func (m *Mise) GetAllVersions(ctx context.Context, pkg, version string) ([]string, error) {
+	_, unlock, err := m.createAndLock(ctx, pkg)
 	if err != nil {
 		return nil, err
 	}
@@ -97,13 +103,13 @@ func (m *Mise) GetAllVersions(pkg, version string) ([]string, error) {
 	// Try with extracted semver version first
 	semverVersion := utils.ExtractSemverVersion(version)
 	query := fmt.Sprintf("%s@%s", pkg, semverVersion)
-	output, err := m.runCmdWithEnv([]string{"MISE_NO_CONFIG=1", "MISE_PARANOID=1"}, "ls-remote", query)
+	output, err := m.runCmdWithEnv(ctx, []string{"MISE_NO_CONFIG=1", "MISE_PARANOID=1"}, "ls-remote", query)
 
 	// If semver extraction fails, try with original version
 	// https://github.com/railwayapp/railpack/issues/203
 	if (err != nil || strings.TrimSpace(output) == "") && semverVersion != version {
 		query = fmt.Sprintf("%s@%s", pkg, version)
-		output, err = m.runCmdWithEnv([]string{"MISE_NO_CONFIG=1", "MISE_PARANOID=1"}, "ls-remote", query)
+		output, err = m.runCmdWithEnv(ctx, []string{"MISE_NO_CONFIG=1", "MISE_PARANOID=1"}, "ls-remote", query)
 	}
 
 	if err != nil {
@@ -127,8 +133,35 @@ func (m *Mise) GetAllVersions(pkg, version string) ([]string, error) {
 	return versions, nil
 }
 
+// lists every version of a package, ordered from oldest to newest
+func (m *Mise) ListVersions(ctx context.Context, pkg string) ([]string, error) {
+	_, unlock, err := m.createAndLock(ctx, pkg)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	output, err := m.runCmdWithEnv(ctx, []string{"MISE_NO_CONFIG=1", "MISE_PARANOID=1"}, "ls-remote", pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if version := strings.TrimSpace(line); version != "" {
+			versions = append(versions, version)
+		}
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no versions found for %s", pkg)
+	}
+
+	return versions, nil
+}
+
 // returns the JSON output of 'mise list --current --json' for the app
-func (m *Mise) GetCurrentList(appDir string) (string, error) {
+func (m *Mise) GetCurrentList(ctx context.Context, appDir string) (string, error) {
 	// MISE_TRUSTED_CONFIG_PATHS allows mise to use configs in the app directory without a trust warning
 	trustedConfigEnv := fmt.Sprintf("MISE_TRUSTED_CONFIG_PATHS=%s", appDir)
 
@@ -143,7 +176,7 @@ func (m *Mise) GetCurrentList(appDir string) (string, error) {
 	// eliminates the need to have custom .python-version, etc parsing logic for each provider
 	enabledIdiomaticEnv := fmt.Sprintf("MISE_IDIOMATIC_VERSION_FILE_ENABLE_TOOLS=%s", IdiomaticVersionFileTools)
 
-	return m.runCmdWithEnv([]string{
+	return m.runCmdWithEnv(ctx, []string{
 		trustedConfigEnv,
 		ceilingPathsEnv,
 		enabledIdiomaticEnv,
@@ -152,14 +185,15 @@ func (m *Mise) GetCurrentList(appDir string) (string, error) {
 	}, "--cd", appDir, "list", "--current", "--json")
 }
 
-// runCmdWithEnv runs a mise command with additional environment variables
-func (m *Mise) runCmdWithEnv(extraEnv []string, args ...string) (string, error) {
+// runCmdWithEnv runs a mise command with additional environment variables.
+// The command is killed if the context is done before it exits
+func (m *Mise) runCmdWithEnv(ctx context.Context, extraEnv []string, args ...string) (string, error) {
 	cacheDir := filepath.Join(m.cacheDir, "cache")
 	dataDir := filepath.Join(m.cacheDir, "data")
 	stateDir := filepath.Join(m.cacheDir, "state")
 	systemDir := filepath.Join(m.cacheDir, "system")
 
-	cmd := exec.Command(m.binaryPath, args...)
+	cmd := exec.CommandContext(ctx, m.binaryPath, args...)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -191,6 +225,11 @@ func (m *Mise) runCmdWithEnv(extraEnv []string, args ...string) (string, error)
 	log.Debugf("Running mise command with env: %v", cmd.Env)
 
 	if err := cmd.Run(); err != nil {
+		// The error of a killed command does not say why it was killed
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", fmt.Errorf("mise command '%s' did not finish: %w", strings.Join(args, " "), ctxErr)
+		}
+
 		return "", fmt.Errorf("failed to run mise command '%s': %w\n%s\n\n%s",
 			strings.Join(append([]string{m.binaryPath}, args...), " "),
 			err,
@@ -229,20 +268,37 @@ func GenerateMiseToml(packages map[string]string) (string, error) {
 	return buf.String(), nil
 }
 
-// lock ensuring mise does not work on the same package concurrently
-func (m *Mise) createAndLock(pkg string) (*filemutex.FileMutex, func(), error) {
+// lock ensuring mise does not work on the same package concurrently, including in other processes that share the cache directory.
+// Waiting for the lock stops when the context is done
+func (m *Mise) createAndLock(ctx context.Context, pkg string) (*filemutex.FileMutex, func(), error) {
 	fileLockPath := filepath.Join(m.cacheDir, fmt.Sprintf("lock-%s", strings.ReplaceAll(pkg, "/", "-")))
 	mu, err := filemutex.New(fileLockPath)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create mutex: %w", err)
 	}
 
-	if err := mu.Lock(); err != nil {
-		return nil, nil, fmt.Errorf("failed to acquire lock: %w", err)
+	for {
+		err := mu.TryLock()
+		if err == nil {
+			break
+		}
+
+		if !errors.Is(err, filemutex.AlreadyLocked) {
+			mu.Close()
+			return nil, nil, fmt.Errorf("failed to acquire lock: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			mu.Close()
+			return nil, nil, fmt.Errorf("failed to acquire lock for %s: %w", pkg, ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
 	}
 
+	// Closing the mutex releases the lock and the file descriptor
 	unlock := func() {
-		if err := mu.Unlock(); err != nil {
+		if err := mu.Close(); err != nil {
 			log.Printf("failed to release lock: %v", err)
 		}
 	}
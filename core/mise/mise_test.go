@@ -1,9 +1,12 @@
 package mise
 
 import (
+	"context"
 	"os"
+	"os/exec"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -15,7 +18,7 @@ func TestMistGetLatestVersion(t *testing.T) {
 	}
 	defer os.RemoveAll(tempDir)
 
-	mise, err := New(tempDir)
+	mise, err := New(context.Background(), tempDir)
 	if err != nil {
 		t.Fatalf("failed to create mise: %v", err)
 	}
@@ -48,7 +51,7 @@ func TestMistGetLatestVersion(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := mise.GetLatestVersion(tt.runtime, tt.version)
+			got, err := mise.GetLatestVersion(context.Background(), tt.runtime, tt.version)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetLatestVersion() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -72,7 +75,7 @@ func TestMiseGetAllVersions(t *testing.T) {
 	}
 	defer os.RemoveAll(tempDir)
 
-	mise, err := New(tempDir)
+	mise, err := New(context.Background(), tempDir)
 	if err != nil {
 		t.Fatalf("failed to create mise: %v", err)
 	}
@@ -112,7 +115,7 @@ func TestMiseGetAllVersions(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := mise.GetAllVersions(tt.runtime, tt.version)
+			got, err := mise.GetAllVersions(context.Background(), tt.runtime, tt.version)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetAllVersions() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -124,3 +127,40 @@ func TestMiseGetAllVersions(t *testing.T) {
 		})
 	}
 }
+
+func TestMiseCommandDeadline(t *testing.T) {
+	sleepPath, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep is not available")
+	}
+
+	// The sleep binary stands in for a mise command that hangs
+	mise := &Mise{binaryPath: sleepPath, cacheDir: t.TempDir()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = mise.runCmdWithEnv(ctx, nil, "10")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestMiseLockCancelled(t *testing.T) {
+	mise := &Mise{cacheDir: t.TempDir()}
+
+	_, unlock, err := mise.createAndLock(context.Background(), "node")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, _, err = mise.createAndLock(ctx, "node")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	unlock()
+
+	_, unlock, err = mise.createAndLock(context.Background(), "node")
+	require.NoError(t, err)
+	unlock()
+}
@@ -0,0 +1,257 @@
+package plan
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/moby/patternmatcher"
+)
+
+type AffectedOptions struct {
+	// The parsed .dockerignore of the build context. Ignored files never reach the build
+	Dockerignore *DockerignoreContext
+
+	// Files that are used to generate the plan itself (e.g. railpack.json).
+	// A change to any of them invalidates every step
+	PlanFiles []string
+}
+
+type AffectedStep struct {
+	Name string `json:"name"`
+
+	// Changed files that are copied into the step from the build context
+	Files []string `json:"files,omitempty"`
+
+	// Invalidated steps that this step uses as an input
+	Steps []string `json:"steps,omitempty"`
+
+	// Set when the step is invalidated because the plan itself may change
+	PlanChanged bool `json:"planChanged,omitempty"`
+}
+
+type AffectedResult struct {
+	// Whether the change set can change the built image
+	Affected bool `json:"affected"`
+
+	// Changed files that are excluded from the build context by .dockerignore
+	IgnoredFiles []string `json:"ignoredFiles,omitempty"`
+
+	// Changed files that are used to generate the plan
+	PlanFiles []string `json:"planFiles,omitempty"`
+
+	// Steps that would be invalidated, in plan order
+	Steps []AffectedStep `json:"steps"`
+
+	// Changed files that are copied into the deploy image from the build context
+	DeployFiles []string `json:"deployFiles,omitempty"`
+}
+
+// FindAffected determines which steps of the plan would be invalidated by a set of changed files
+// Changed files are paths relative to the root of the build context
+func FindAffected(p *BuildPlan, changedFiles []string, options AffectedOptions) (*AffectedResult, error) {
+	result := &AffectedResult{Steps: []AffectedStep{}}
+
+	ignored, err := newIgnoreMatcher(options.Dockerignore)
+	if err != nil {
+		return nil, err
+	}
+
+	contextFiles := []string{}
+	for _, file := range changedFiles {
+		file = normalizeContextPath(file)
+		if file == "." {
+			continue
+		}
+
+		if slices.ContainsFunc(options.PlanFiles, func(planFile string) bool { return normalizeContextPath(planFile) == file }) {
+			result.PlanFiles = append(result.PlanFiles, file)
+			continue
+		}
+
+		if ignored != nil {
+			if isIgnored, err := ignored.MatchesOrParentMatches(file); err != nil {
+				return nil, err
+			} else if isIgnored {
+				result.IgnoredFiles = append(result.IgnoredFiles, file)
+				continue
+			}
+		}
+
+		contextFiles = append(contextFiles, file)
+	}
+
+	planChanged := len(result.PlanFiles) > 0
+	affectedSteps := map[string]bool{}
+
+	// Steps can reference each other in any order, so iterate until nothing new is invalidated
+	for changed := true; changed; {
+		changed = false
+
+		for _, step := range p.Steps {
+			if affectedSteps[step.Name] {
+				continue
+			}
+
+			affected := AffectedStep{Name: step.Name, PlanChanged: planChanged}
+
+			for _, input := range step.Inputs {
+				if input.Local {
+					files, err := filesInLayer(input, contextFiles)
+					if err != nil {
+						return nil, err
+					}
+					affected.Files = append(affected.Files, files...)
+				} else if input.Step != "" && affectedSteps[input.Step] {
+					affected.Steps = append(affected.Steps, input.Step)
+				}
+			}
+
+			for _, cmd := range step.Commands {
+				if copyCmd, ok := cmd.(CopyCommand); ok && copyCmd.Image == "" {
+					for _, file := range contextFiles {
+						if matches, err := pathIncludes(copyCmd.Src, file); err != nil {
+							return nil, err
+						} else if matches {
+							affected.Files = append(affected.Files, file)
+						}
+					}
+				}
+			}
+
+			if affected.PlanChanged || len(affected.Files) > 0 || len(affected.Steps) > 0 {
+				affected.Files = uniqueSorted(affected.Files)
+				affectedSteps[step.Name] = true
+				result.Steps = append(result.Steps, affected)
+				changed = true
+			}
+		}
+	}
+
+	// Keep the result in plan order regardless of how many passes it took
+	slices.SortStableFunc(result.Steps, func(a, b AffectedStep) int {
+		return slices.IndexFunc(p.Steps, func(s Step) bool { return s.Name == a.Name }) -
+			slices.IndexFunc(p.Steps, func(s Step) bool { return s.Name == b.Name })
+	})
+
+	result.Affected = planChanged
+	for _, input := range append([]Layer{p.Deploy.Base}, p.Deploy.Inputs...) {
+		if input.Local {
+			files, err := filesInLayer(input, contextFiles)
+			if err != nil {
+				return nil, err
+			}
+			result.DeployFiles = append(result.DeployFiles, files...)
+		} else if input.Step != "" && affectedSteps[input.Step] {
+			result.Affected = true
+		}
+	}
+
+	result.DeployFiles = uniqueSorted(result.DeployFiles)
+	if len(result.DeployFiles) > 0 {
+		result.Affected = true
+	}
+
+	return result, nil
+}
+
+// filesInLayer returns the files that a local layer copies from the build context
+func filesInLayer(layer Layer, files []string) ([]string, error) {
+	matched := []string{}
+
+	for _, file := range files {
+		for _, include := range layer.Include {
+			included, err := pathIncludes(include, file)
+			if err != nil {
+				return nil, err
+			}
+			if !included {
+				continue
+			}
+
+			// Exclude patterns are relative to the included path
+			excluded, err := patternmatcher.MatchesOrParentMatches(relativeToInclude(include, file), layer.Exclude)
+			if err != nil {
+				return nil, fmt.Errorf("invalid exclude pattern in layer %s: %w", layer.DisplayName(), err)
+			}
+			if !excluded {
+				matched = append(matched, file)
+				break
+			}
+		}
+	}
+
+	return matched, nil
+}
+
+// pathIncludes checks if a file is copied when copying the include path from the build context
+func pathIncludes(include, file string) (bool, error) {
+	include = normalizeContextPath(include)
+	if include == "." {
+		return true, nil
+	}
+
+	matches, err := patternmatcher.MatchesOrParentMatches(file, []string{include})
+	if err != nil {
+		return false, fmt.Errorf("invalid include path %s: %w", include, err)
+	}
+
+	return matches, nil
+}
+
+func relativeToInclude(include, file string) string {
+	include = normalizeContextPath(include)
+	if include == "." || strings.ContainsAny(include, "*?[") {
+		return file
+	}
+
+	if rel := strings.TrimPrefix(file, include+"/"); rel != file {
+		return rel
+	}
+
+	return path.Base(file)
+}
+
+func newIgnoreMatcher(dockerignore *DockerignoreContext) (*patternmatcher.PatternMatcher, error) {
+	if dockerignore == nil || len(dockerignore.Excludes) == 0 {
+		return nil, nil
+	}
+
+	// The order matters, since a later pattern overrides the earlier ones that match the same file
+	patterns := dockerignore.Patterns
+	if patterns == nil {
+		patterns = slices.Clone(dockerignore.Excludes)
+		for _, include := range dockerignore.Includes {
+			patterns = append(patterns, "!"+include)
+		}
+	}
+
+	matcher, err := patternmatcher.New(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid .dockerignore pattern: %w", err)
+	}
+
+	return matcher, nil
+}
+
+// normalizeContextPath converts a path to be relative to the root of the build context
+func normalizeContextPath(p string) string {
+	p = path.Clean(strings.ReplaceAll(strings.TrimSpace(p), "\\", "/"))
+	if p == "/" || p == "/app" {
+		return "."
+	}
+
+	p = strings.TrimPrefix(p, "/app/")
+	return strings.TrimPrefix(p, "/")
+}
+
+func uniqueSorted(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	values = slices.Clone(values)
+	slices.Sort(values)
+	return slices.Compact(values)
+}
@@ -0,0 +1,164 @@
+package plan
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/railwayapp/railpack/core/app"
+	"github.com/stretchr/testify/require"
+)
+
+func createAffectedTestPlan() *BuildPlan {
+	p := NewBuildPlan()
+
+	packages := NewStep("packages:mise")
+	packages.Inputs = []Layer{NewImageLayer(RailpackBuilderImage)}
+
+	install := NewStep("install")
+	install.Inputs = []Layer{NewStepLayer(packages.Name)}
+	install.AddCommands([]Command{
+		NewCopyCommand("package.json"),
+		NewCopyCommand("package-lock.json"),
+		NewExecShellCommand("npm ci"),
+	})
+
+	build := NewStep("build")
+	build.Inputs = []Layer{
+		NewStepLayer(install.Name),
+		{Local: true, Filter: NewFilter([]string{"."}, []string{"node_modules", "*.md"})},
+	}
+	build.AddCommands([]Command{NewExecShellCommand("npm run build")})
+
+	// Declared before the steps it depends on
+	lint := NewStep("lint")
+	lint.Inputs = []Layer{NewStepLayer(build.Name)}
+
+	p.Steps = []Step{*lint, *packages, *install, *build}
+	p.Deploy = Deploy{
+		Base: NewImageLayer(RailpackRuntimeImage),
+		Inputs: []Layer{
+			NewStepLayer(packages.Name, NewIncludeFilter([]string{"/mise/installs"})),
+			NewStepLayer(build.Name, NewIncludeFilter([]string{"."})),
+			{Local: true, Filter: NewIncludeFilter([]string{"public"})},
+		},
+	}
+
+	return p
+}
+
+func TestFindAffected(t *testing.T) {
+	p := createAffectedTestPlan()
+	dockerignore := &DockerignoreContext{
+		Excludes: []string{"docs", "*.log"},
+		Includes: []string{"docs/keep.txt"},
+		HasFile:  true,
+	}
+
+	t.Run("source change", func(t *testing.T) {
+		result, err := FindAffected(p, []string{"src/index.ts"}, AffectedOptions{Dockerignore: dockerignore})
+		require.NoError(t, err)
+
+		require.True(t, result.Affected)
+		require.Equal(t, []AffectedStep{
+			{Name: "lint", Steps: []string{"build"}},
+			{Name: "build", Files: []string{"src/index.ts"}},
+		}, result.Steps)
+	})
+
+	t.Run("dependency change", func(t *testing.T) {
+		result, err := FindAffected(p, []string{"./package-lock.json"}, AffectedOptions{Dockerignore: dockerignore})
+		require.NoError(t, err)
+
+		require.True(t, result.Affected)
+		require.Equal(t, []string{"lint", "install", "build"}, stepNames(result.Steps))
+		require.Equal(t, []string{"package-lock.json"}, result.Steps[1].Files)
+	})
+
+	t.Run("ignored and excluded files", func(t *testing.T) {
+		result, err := FindAffected(p, []string{"docs/guide.txt", "debug.log", "README.md", "node_modules/x/index.js"}, AffectedOptions{Dockerignore: dockerignore})
+		require.NoError(t, err)
+
+		require.False(t, result.Affected)
+		require.Empty(t, result.Steps)
+		require.Equal(t, []string{"docs/guide.txt", "debug.log"}, result.IgnoredFiles)
+	})
+
+	t.Run("negated dockerignore pattern", func(t *testing.T) {
+		result, err := FindAffected(p, []string{"docs/keep.txt"}, AffectedOptions{Dockerignore: dockerignore})
+		require.NoError(t, err)
+
+		require.True(t, result.Affected)
+		require.Empty(t, result.IgnoredFiles)
+	})
+
+	t.Run("deploy only change", func(t *testing.T) {
+		p := createAffectedTestPlan()
+		p.Steps[3].Inputs[1].Exclude = append(p.Steps[3].Inputs[1].Exclude, "public")
+
+		result, err := FindAffected(p, []string{"public/logo.png"}, AffectedOptions{})
+		require.NoError(t, err)
+
+		require.True(t, result.Affected)
+		require.Empty(t, result.Steps)
+		require.Equal(t, []string{"public/logo.png"}, result.DeployFiles)
+	})
+
+	t.Run("plan file change", func(t *testing.T) {
+		result, err := FindAffected(p, []string{"railpack.json"}, AffectedOptions{PlanFiles: []string{"railpack.json"}})
+		require.NoError(t, err)
+
+		require.True(t, result.Affected)
+		require.Equal(t, []string{"railpack.json"}, result.PlanFiles)
+		require.Len(t, result.Steps, len(p.Steps))
+	})
+}
+
+func TestFindAffectedDockerignoreOrder(t *testing.T) {
+	app := app.NewAppFromFS(fstest.MapFS{
+		".dockerignore":  {Data: []byte("keep\n!keep\nkeep/*.tmp\n")},
+		"keep/config.js": {Data: []byte(``)},
+		"keep/cache.tmp": {Data: []byte(``)},
+	}, "app")
+
+	dockerignore, err := NewDockerignoreContext(app)
+	require.NoError(t, err)
+
+	// keep/*.tmp comes after !keep, so the temporary files are still ignored
+	result, err := FindAffected(createAffectedTestPlan(), []string{"keep/config.js", "keep/cache.tmp"}, AffectedOptions{Dockerignore: dockerignore})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"keep/cache.tmp"}, result.IgnoredFiles)
+	require.Equal(t, []string{"keep/config.js"}, result.Steps[1].Files)
+}
+
+func TestPathIncludes(t *testing.T) {
+	tests := []struct {
+		include  string
+		file     string
+		expected bool
+	}{
+		{".", "src/index.ts", true},
+		{"/app", "src/index.ts", true},
+		{"package.json", "package.json", true},
+		{"package.json", "apps/web/package.json", false},
+		{"src", "src/nested/index.ts", true},
+		{"src", "srcs/index.ts", false},
+		{"/app/src", "src/index.ts", true},
+		{"*.json", "tsconfig.json", true},
+		{"**/*.go", "cmd/server/main.go", true},
+	}
+
+	for _, tt := range tests {
+		matches, err := pathIncludes(tt.include, tt.file)
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, matches, "%s includes %s", tt.include, tt.file)
+	}
+}
+
+func stepNames(steps []AffectedStep) []string {
+	names := []string{}
+	for _, step := range steps {
+		names = append(names, step.Name)
+	}
+	return names
+}
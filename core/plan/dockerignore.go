@@ -13,19 +13,32 @@ import (
 
 // checks if a .dockerignore file exists in the app directory and parses it
 func CheckAndParseDockerignore(app *app.App) ([]string, []string, error) {
+	patterns, err := readDockerignorePatterns(app)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	excludes, includes := parseDockerignorePatterns(app, patterns)
+	return excludes, includes, nil
+}
+
+// readDockerignorePatterns returns the patterns of the .dockerignore file in the order they are written
+func readDockerignorePatterns(app *app.App) ([]string, error) {
 	if !app.HasFile(".dockerignore") {
-		return nil, nil, nil
+		return nil, nil
 	}
 
 	content, err := app.ReadFile(".dockerignore")
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	reader := strings.NewReader(content)
-	patterns, err := ignorefile.ReadAll(reader)
-	if err != nil {
-		return nil, nil, err
+	return ignorefile.ReadAll(strings.NewReader(content))
+}
+
+func parseDockerignorePatterns(app *app.App, patterns []string) ([]string, []string) {
+	if patterns == nil {
+		return nil, nil
 	}
 
 	excludePatterns, includePatterns := separatePatterns(patterns)
@@ -39,7 +52,7 @@ func CheckAndParseDockerignore(app *app.App) ([]string, []string, error) {
 		}
 	}
 
-	return excludePatterns, validIncludes, nil
+	return excludePatterns, validIncludes
 }
 
 // separatePatterns separates patterns into exclude and include lists
@@ -60,14 +73,19 @@ type DockerignoreContext struct {
 	Excludes []string
 	Includes []string
 	HasFile  bool
+
+	// Every pattern in the order it is written, with includes prefixed by '!'. Later patterns take precedence
+	Patterns []string
 }
 
 func NewDockerignoreContext(app *app.App) (*DockerignoreContext, error) {
 	hasFile := app.HasFile(".dockerignore")
-	excludes, includes, err := CheckAndParseDockerignore(app)
+	patterns, err := readDockerignorePatterns(app)
 	if err != nil {
 		return nil, err
 	}
+
+	excludes, includes := parseDockerignorePatterns(app, patterns)
 	if excludes != nil {
 		excludes = utils.RemoveDuplicates(excludes)
 	}
@@ -78,5 +96,6 @@ func NewDockerignoreContext(app *app.App) (*DockerignoreContext, error) {
 		Excludes: excludes,
 		Includes: includes,
 		HasFile:  hasFile,
+		Patterns: patterns,
 	}, nil
 }
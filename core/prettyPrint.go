@@ -2,11 +2,15 @@ package core
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/railwayapp/railpack/core/generate"
 	"github.com/railwayapp/railpack/core/logger"
 	"github.com/railwayapp/railpack/core/plan"
+	"github.com/railwayapp/railpack/core/providers"
 	"github.com/railwayapp/railpack/core/resolver"
 	"github.com/railwayapp/railpack/internal/utils"
 )
@@ -253,6 +257,139 @@ func formatMetadata(output *strings.Builder, metadata map[string]string, showMet
 	}
 }
 
+// FormatProvenance formats where every step, command, variable, and cache in the plan came from
+// If stepName is set, only that step (or "deploy") is formatted
+func FormatProvenance(br *BuildResult, stepName string) (string, error) {
+	var output strings.Builder
+
+	if br.Plan == nil || br.Provenance == nil {
+		return "", fmt.Errorf("build result has no provenance")
+	}
+
+	found := false
+	for _, step := range br.Plan.Steps {
+		if stepName != "" && step.Name != stepName {
+			continue
+		}
+
+		provenance, ok := br.Provenance.Steps[step.Name]
+		if !ok {
+			continue
+		}
+
+		found = true
+		formatStepProvenance(&output, &step, provenance)
+	}
+
+	if stepName == "" || stepName == "deploy" {
+		found = true
+		formatDeployProvenance(&output, br)
+	}
+
+	if !found {
+		return "", fmt.Errorf("step %q not found in plan", stepName)
+	}
+
+	output.WriteString("\n")
+	return output.String(), nil
+}
+
+func formatStepProvenance(output *strings.Builder, step *plan.Step, provenance *generate.StepProvenance) {
+	output.WriteString(indentedStepHeaderStyle.MarginTop(1).Render(fmt.Sprintf("▸ %s", step.Name)))
+	output.WriteString("\n")
+
+	for i, source := range provenance.Sources {
+		label := "created by"
+		if i > 0 {
+			label = "modified by"
+		}
+		output.WriteString(fmt.Sprintf("%s %s\n", commandPrefixStyle.Render(label), sourceStyle.Render(source.String())))
+	}
+
+	for i, cmd := range step.Commands {
+		if i >= len(provenance.Commands) {
+			break
+		}
+		output.WriteString(fmt.Sprintf("%s %s %s\n", commandPrefixStyle.Render("$"), commandStyle.Render(describeCommand(cmd)), separatorStyle.Render("← "+provenance.Commands[i].String())))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(provenance.Variables)) {
+		output.WriteString(fmt.Sprintf("%s %s %s\n", commandPrefixStyle.Render("env"), commandStyle.Render(name), separatorStyle.Render("← "+provenance.Variables[name].String())))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(provenance.Caches)) {
+		output.WriteString(fmt.Sprintf("%s %s %s\n", commandPrefixStyle.Render("cache"), commandStyle.Render(name), separatorStyle.Render("← "+provenance.Caches[name].String())))
+	}
+}
+
+func formatDeployProvenance(output *strings.Builder, br *BuildResult) {
+	output.WriteString(indentedStepHeaderStyle.MarginTop(1).Render("▸ deploy"))
+	output.WriteString("\n")
+
+	deploy := br.Provenance.Deploy
+	if br.Plan.Deploy.StartCmd != "" && deploy.StartCmd != nil {
+		output.WriteString(fmt.Sprintf("%s %s %s\n", commandPrefixStyle.Render("$"), commandStyle.Render(br.Plan.Deploy.StartCmd), separatorStyle.Render("← "+deploy.StartCmd.String())))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(deploy.Variables)) {
+		output.WriteString(fmt.Sprintf("%s %s %s\n", commandPrefixStyle.Render("env"), commandStyle.Render(name), separatorStyle.Render("← "+deploy.Variables[name].String())))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(br.Provenance.Caches)) {
+		output.WriteString(fmt.Sprintf("%s %s %s\n", commandPrefixStyle.Render("cache"), commandStyle.Render(name), separatorStyle.Render("← "+br.Provenance.Caches[name].String())))
+	}
+}
+
+// FormatDetections lists every provider that detected the app with its score, from the provider that is used to the lowest score
+func FormatDetections(detections []*providers.Detection) string {
+	var output strings.Builder
+
+	if len(detections) == 0 {
+		output.WriteString("No providers detected the app\n")
+		return output.String()
+	}
+
+	for i, detection := range detections {
+		header := fmt.Sprintf("▸ %s %s", detection.Name, versionStyle.Render(fmt.Sprintf("%d/%d", detection.Score, providers.MaxScore)))
+		if i == 0 {
+			header += separatorStyle.Render(" (used)")
+		}
+		output.WriteString(indentedStepHeaderStyle.MarginTop(1).Render(header))
+		output.WriteString("\n")
+
+		for _, reason := range detection.Reasons {
+			output.WriteString(fmt.Sprintf("%s %s\n", commandPrefixStyle.Render("-"), reason))
+		}
+		if len(detection.Evidence) > 0 {
+			output.WriteString(fmt.Sprintf("%s %s\n", commandPrefixStyle.Render("evidence"), commandStyle.Render(strings.Join(detection.Evidence, ", "))))
+		}
+	}
+
+	output.WriteString("\n")
+	return output.String()
+}
+
+// describeCommand returns a short human readable description of a command
+func describeCommand(cmd plan.Command) string {
+	switch cmd := cmd.(type) {
+	case plan.ExecCommand:
+		if cmd.CustomName != "" {
+			return cmd.CustomName
+		}
+		return cmd.Cmd
+	case plan.PathCommand:
+		return fmt.Sprintf("path %s", cmd.Path)
+	case plan.CopyCommand:
+		if cmd.Image != "" {
+			return fmt.Sprintf("copy %s:%s %s", cmd.Image, cmd.Src, cmd.Dest)
+		}
+		return fmt.Sprintf("copy %s %s", cmd.Src, cmd.Dest)
+	case plan.FileCommand:
+		return fmt.Sprintf("file %s", cmd.Path)
+	}
+	return cmd.CommandType()
+}
+
 func getStepsToPrint(br *BuildResult) []*plan.Step {
 	execSteps := []*plan.Step{}
 	if br.Plan == nil {
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/railwayapp/railpack/core/app"
+	c "github.com/railwayapp/railpack/core/config"
+	"github.com/railwayapp/railpack/core/generate"
+)
+
+// the RAILPACK_* variable that sets each key in GenerateConfigFromEnvironment
+var envConfigVariables = map[string]string{
+	"steps.install":       "INSTALL_CMD",
+	"steps.build":         "BUILD_CMD",
+	"deploy.startCommand": "START_CMD",
+	"deploy.aptPackages":  "DEPLOY_APT_PACKAGES",
+	"packages":            "PACKAGES",
+	"buildAptPackages":    "BUILD_APT_PACKAGES",
+}
+
+// the CLI flag that sets each key in GenerateConfigFromOptions
+var optionsConfigFlags = map[string]string{
+	"steps.build":         "--build-cmd",
+	"deploy.startCommand": "--start-cmd",
+}
+
+// recordConfigSources records the source of every key that is set in the config
+func recordConfigSources(sources map[string]generate.Source, config *c.Config, source func(key string) generate.Source) {
+	record := func(key string) {
+		sources[key] = source(key)
+	}
+
+	for name, step := range config.Steps {
+		prefix := "steps." + name
+		record(prefix)
+
+		if len(step.Commands) > 0 {
+			record(prefix + ".commands")
+		}
+		if len(step.Inputs) > 0 {
+			record(prefix + ".inputs")
+		}
+		if len(step.Caches) > 0 {
+			record(prefix + ".caches")
+		}
+		for variable := range step.Variables {
+			record(prefix + ".variables." + variable)
+		}
+	}
+
+	if config.Deploy != nil {
+		if config.Deploy.StartCmd != "" {
+			record("deploy.startCommand")
+		}
+		if len(config.Deploy.Inputs) > 0 {
+			record("deploy.inputs")
+		}
+		if len(config.Deploy.AptPackages) > 0 {
+			record("deploy.aptPackages")
+		}
+		for variable := range config.Deploy.Variables {
+			record("deploy.variables." + variable)
+		}
+	}
+
+	for name := range config.Caches {
+		record("caches." + name)
+	}
+
+	for name := range config.Packages {
+		record("packages." + name)
+	}
+
+	if len(config.BuildAptPackages) > 0 {
+		record("buildAptPackages")
+	}
+}
+
+func optionsConfigSource(key string) generate.Source {
+	return generate.Source{Type: generate.SourceTypeCLI, Name: lookupConfigKey(optionsConfigFlags, key), Detail: key}
+}
+
+func envConfigSource(env *app.Environment, key string) generate.Source {
+	return generate.Source{Type: generate.SourceTypeEnv, Name: env.ConfigVariable(lookupConfigKey(envConfigVariables, key)), Detail: key}
+}
+
+// lookupConfigKey finds the value for the key or its closest parent key
+func lookupConfigKey(values map[string]string, key string) string {
+	for k := key; ; {
+		if value, ok := values[k]; ok {
+			return value
+		}
+
+		idx := strings.LastIndex(k, ".")
+		if idx == -1 {
+			return ""
+		}
+		k = k[:idx]
+	}
+}
@@ -0,0 +1,236 @@
+package providers
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/railwayapp/railpack/core/generate"
+)
+
+const (
+	// The score of a provider that detects the app. The share of source files in its language adds up to sourceScore
+	detectedScore = 50
+	sourceScore   = 50
+
+	MaxScore = detectedScore + sourceScore
+
+	// The most files that are counted when scoring the source of an app
+	maxSourceFiles = 20000
+)
+
+// Detection is how confident a provider is that it should build the app
+type Detection struct {
+	Provider Provider `json:"-"`
+	Name     string   `json:"provider"`
+
+	// From 1 to MaxScore. The provider with the highest score is used, and ties go to the provider that is detected first
+	Score int `json:"score"`
+
+	Reasons []string `json:"reasons,omitempty"`
+
+	// Files in the app that the provider was detected by
+	Evidence []string `json:"evidence,omitempty"`
+}
+
+// Scorer is implemented by providers that score their own detection.
+// Returning nil uses the default score
+type Scorer interface {
+	Score(ctx *generate.GenerateContext) (*Detection, error)
+}
+
+type languageSignals struct {
+	// Files that the provider detects apps by. Directories end with a slash
+	markers []string
+
+	// Extensions of the source files in the language of the provider
+	extensions []string
+
+	// Providers that this provider also builds when they are detected (e.g. PHP installs Node when a package.json is found)
+	includes []string
+}
+
+// Staticfile and shell have no source files, so any language provider that is also detected is used instead
+var signals = map[string]languageSignals{
+	"php":        {markers: []string{"composer.json", "index.php"}, extensions: []string{".php"}, includes: []string{"node"}},
+	"golang":     {markers: []string{"go.mod", "go.work", "main.go"}, extensions: []string{".go"}},
+	"java":       {markers: []string{"pom.*", "gradlew", "build.gradle", "build.gradle.kts"}, extensions: []string{".java", ".kt", ".scala", ".groovy", ".clj"}},
+	"rust":       {markers: []string{"Cargo.toml"}, extensions: []string{".rs"}},
+	"ruby":       {markers: []string{"Gemfile"}, extensions: []string{".rb", ".erb", ".rake"}, includes: []string{"node"}},
+	"Elixir":     {markers: []string{"mix.exs"}, extensions: []string{".ex", ".exs", ".heex", ".eex"}, includes: []string{"node"}},
+	"python":     {markers: []string{"requirements.txt", "pyproject.toml", "Pipfile", "setup.py", "main.py", "app.py"}, extensions: []string{".py"}},
+	"deno":       {markers: []string{"deno.json", "deno.jsonc"}, extensions: []string{".ts", ".tsx", ".js", ".jsx"}},
+	"Dotnet":     {markers: []string{"*.csproj"}, extensions: []string{".cs", ".fs", ".vb", ".cshtml", ".razor"}},
+	"node":       {markers: []string{"package.json"}, extensions: []string{".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs", ".vue", ".svelte", ".astro"}},
+	"gleam":      {markers: []string{"gleam.toml"}, extensions: []string{".gleam"}},
+	"cpp":        {markers: []string{"CMakeLists.txt", "meson.build"}, extensions: []string{".c", ".cc", ".cpp", ".cxx", ".h", ".hpp"}},
+	"staticfile": {markers: []string{"Staticfile", "public/", "index.html"}},
+	"shell":      {markers: []string{"start.sh"}},
+}
+
+// Directories of dependencies and build output that are not counted as the source of the app
+var sourceExcludes = []string{".git", "node_modules", "vendor", "target", "dist", "build", "_build", "deps", ".venv", "venv", "__pycache__", ".next"}
+
+// DetectAll returns every provider that detects the app, ordered from the highest score to the lowest.
+// Providers that fail to detect the app are skipped with a warning
+func DetectAll(ctx *generate.GenerateContext, providers []Provider) []*Detection {
+	matched := []Provider{}
+	for _, provider := range providers {
+		ok, err := provider.Detect(ctx)
+		if err != nil {
+			log.Warnf("Failed to detect provider `%s`: %s", provider.Name(), err.Error())
+			continue
+		}
+		if ok {
+			matched = append(matched, provider)
+		}
+	}
+
+	var sources *sourceCounts
+	detections := []*Detection{}
+
+	for _, provider := range matched {
+		var detection *Detection
+		if scorer, ok := provider.(Scorer); ok {
+			var err error
+			if detection, err = scorer.Score(ctx); err != nil {
+				log.Warnf("Failed to score provider `%s`: %s", provider.Name(), err.Error())
+			}
+		}
+
+		if detection == nil {
+			// Source files only decide between providers, so they are not counted when a single provider detects the app
+			if sources == nil && len(matched) > 1 {
+				sources = countSources(ctx)
+			} else if sources == nil {
+				sources = &sourceCounts{}
+			}
+			detection = defaultDetection(ctx, provider.Name(), sources)
+		}
+
+		detection.Provider = provider
+		detection.Name = provider.Name()
+		detection.Score = max(1, min(detection.Score, MaxScore))
+		detections = append(detections, detection)
+	}
+
+	SortDetections(detections)
+	return detections
+}
+
+// SortDetections orders detections from the highest score to the lowest, keeping the detection order of ties
+func SortDetections(detections []*Detection) {
+	slices.SortStableFunc(detections, func(a, b *Detection) int {
+		return b.Score - a.Score
+	})
+}
+
+func defaultDetection(ctx *generate.GenerateContext, name string, sources *sourceCounts) *Detection {
+	detection := &Detection{Score: detectedScore}
+
+	// Plugins and registered providers are more specific than the built-in providers, so they keep their precedence unless they score themselves
+	signal, ok := signals[name]
+	if !ok {
+		detection.Score = MaxScore
+		detection.Reasons = []string{"detected by a provider that does not score itself"}
+		return detection
+	}
+
+	for _, marker := range signal.markers {
+		if dir, ok := strings.CutSuffix(marker, "/"); ok {
+			if matches, err := ctx.App.FindDirectories(dir); err == nil {
+				detection.Evidence = append(detection.Evidence, matches...)
+			}
+		} else if matches, err := ctx.App.FindFiles(marker); err == nil {
+			detection.Evidence = append(detection.Evidence, matches...)
+		}
+	}
+	if len(detection.Evidence) > 0 {
+		detection.Reasons = append(detection.Reasons, "found "+strings.Join(detection.Evidence, ", "))
+	} else {
+		detection.Reasons = append(detection.Reasons, "detected by the provider")
+	}
+
+	extensions := slices.Clone(signal.extensions)
+	for _, included := range signal.includes {
+		extensions = append(extensions, signals[included].extensions...)
+	}
+
+	if len(extensions) == 0 || sources.total == 0 {
+		return detection
+	}
+
+	count, found := sources.count(extensions)
+	if count == 0 {
+		detection.Reasons = append(detection.Reasons, fmt.Sprintf("none of the %d source files are in its language", sources.total))
+		return detection
+	}
+
+	share := float64(count) / float64(sources.total)
+	detection.Score += int(share * sourceScore)
+	detection.Reasons = append(detection.Reasons, fmt.Sprintf("%d of %d source files (%d%%) are %s", count, sources.total, int(share*100), strings.Join(found, " ")))
+
+	return detection
+}
+
+// sourceCounts is the number of files in the app by extension, counting only extensions of a known language
+type sourceCounts struct {
+	byExtension map[string]int
+	total       int
+}
+
+// count returns the number of source files with the extensions, and the extensions that were found
+func (s *sourceCounts) count(extensions []string) (int, []string) {
+	count := 0
+	found := []string{}
+	for _, ext := range slices.Compact(slices.Sorted(slices.Values(extensions))) {
+		if s.byExtension[ext] > 0 {
+			count += s.byExtension[ext]
+			found = append(found, ext)
+		}
+	}
+	return count, found
+}
+
+func countSources(ctx *generate.GenerateContext) *sourceCounts {
+	known := map[string]bool{}
+	for _, signal := range signals {
+		for _, ext := range signal.extensions {
+			known[ext] = true
+		}
+	}
+
+	sources := &sourceCounts{byExtension: map[string]int{}}
+	files := 0
+
+	err := fs.WalkDir(ctx.App.FS(), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if name != "." && slices.Contains(sourceExcludes, d.Name()) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if files++; files > maxSourceFiles {
+			return fs.SkipAll
+		}
+
+		if ext := path.Ext(name); known[ext] {
+			sources.byExtension[ext]++
+			sources.total++
+		}
+		return nil
+	})
+	if err != nil {
+		log.Debugf("Failed to count the source files of the app: %s", err)
+	}
+
+	return sources
+}
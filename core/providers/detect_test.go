@@ -0,0 +1,118 @@
+package providers
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/railwayapp/railpack/core/generate"
+	"github.com/stretchr/testify/require"
+)
+
+type scoredProvider struct {
+	testProvider
+}
+
+func (p *scoredProvider) Score(ctx *generate.GenerateContext) (*Detection, error) {
+	return &Detection{Score: 70, Reasons: []string{"found acme.toml"}, Evidence: []string{"acme.toml"}}, nil
+}
+
+func detectionScores(detections []*Detection) map[string]int {
+	scores := map[string]int{}
+	for _, detection := range detections {
+		scores[detection.Name] = detection.Score
+	}
+	return scores
+}
+
+func TestDetectAllScoresBySource(t *testing.T) {
+	goFiles := fstest.MapFS{
+		"go.mod":       {Data: []byte("module app")},
+		"main.go":      {Data: []byte("package main")},
+		"server.go":    {Data: []byte("package main")},
+		"handlers.go":  {Data: []byte("package main")},
+		"package.json": {Data: []byte(`{"name": "tools"}`)},
+		"scripts/a.js": {Data: []byte(``)},
+	}
+
+	detections := DetectAll(newContext(t, goFiles), GetLanguageProviders())
+	require.Len(t, detections, 2)
+	require.Equal(t, "golang", detections[0].Name)
+	require.Equal(t, 87, detections[0].Score)
+	require.Equal(t, []string{"go.mod", "main.go"}, detections[0].Evidence)
+	require.Equal(t, []string{"found go.mod, main.go", "3 of 4 source files (75%) are .go"}, detections[0].Reasons)
+	require.Equal(t, "node", detections[1].Name)
+	require.Equal(t, 62, detections[1].Score)
+
+	// The same markers with mostly JavaScript
+	jsFiles := fstest.MapFS{
+		"go.mod":              {Data: []byte("module app")},
+		"tools/gen.go":        {Data: []byte("package main")},
+		"package.json":        {Data: []byte(`{"name": "app"}`)},
+		"src/index.ts":        {Data: []byte(``)},
+		"src/app.tsx":         {Data: []byte(``)},
+		"src/components/a.js": {Data: []byte(``)},
+		"node_modules/x.js":   {Data: []byte(``)},
+	}
+
+	detections = DetectAll(newContext(t, jsFiles), GetLanguageProviders())
+	require.Equal(t, "node", detections[0].Name)
+	require.Equal(t, map[string]int{"node": 87, "golang": 62}, detectionScores(detections))
+}
+
+func TestDetectAllSingleProvider(t *testing.T) {
+	// Source files are not counted when there is no other provider to decide between
+	ctx := newContext(t, fstest.MapFS{
+		"go.mod":  {Data: []byte("module app")},
+		"main.go": {Data: []byte("package main")},
+	})
+
+	detections := DetectAll(ctx, GetLanguageProviders())
+	require.Len(t, detections, 1)
+	require.Equal(t, "golang", detections[0].Name)
+	require.Equal(t, 50, detections[0].Score)
+	require.Equal(t, []string{"found go.mod, main.go"}, detections[0].Reasons)
+}
+
+func TestDetectAllTiesKeepOrder(t *testing.T) {
+	ctx := newContext(t, fstest.MapFS{
+		"go.mod":       {Data: []byte("module app")},
+		"package.json": {Data: []byte(`{"name": "app"}`)},
+	})
+
+	detections := DetectAll(ctx, GetLanguageProviders())
+	require.Equal(t, "golang", detections[0].Name)
+	require.Equal(t, map[string]int{"golang": 50, "node": 50}, detectionScores(detections))
+}
+
+func TestDetectAllIncludedProviders(t *testing.T) {
+	// PHP also builds the frontend, so the JavaScript files count for it
+	ctx := newContext(t, fstest.MapFS{
+		"composer.json":       {Data: []byte(`{}`)},
+		"index.php":           {Data: []byte(`<?php`)},
+		"package.json":        {Data: []byte(`{"name": "app"}`)},
+		"resources/js/app.js": {Data: []byte(``)},
+		"resources/js/b.js":   {Data: []byte(``)},
+	})
+
+	detections := DetectAll(ctx, GetLanguageProviders())
+	require.Equal(t, map[string]int{"php": 100, "node": 83}, detectionScores(detections))
+	require.Equal(t, "php", detections[0].Name)
+}
+
+func TestDetectAllCustomProviders(t *testing.T) {
+	ctx := newContext(t, fstest.MapFS{
+		"package.json": {Data: []byte(`{"name": "app"}`)},
+		"index.js":     {Data: []byte(``)},
+	})
+
+	all := append([]Provider{
+		&scoredProvider{testProvider{name: "scored"}},
+		&testProvider{name: "unscored"},
+	}, GetLanguageProviders()...)
+
+	detections := DetectAll(ctx, all)
+	require.Equal(t, []string{"unscored", "node", "scored"}, []string{detections[0].Name, detections[1].Name, detections[2].Name})
+	require.Equal(t, MaxScore, detections[0].Score)
+	require.Equal(t, 70, detections[2].Score)
+	require.Equal(t, []string{"acme.toml"}, detections[2].Evidence)
+}
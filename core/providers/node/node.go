@@ -35,6 +35,9 @@ type NodeProvider struct {
 	packageJson    *PackageJson
 	packageManager PackageManager
 	workspace      *Workspace
+
+	// The workspace package selected with NODE_WORKSPACE, which is built and started from the workspace root
+	member *WorkspacePackage
 }
 
 func (p *NodeProvider) Name() string {
@@ -56,6 +59,16 @@ func (p *NodeProvider) Initialize(ctx *generate.GenerateContext) error {
 	}
 	p.workspace = workspace
 
+	if memberPath, _ := ctx.Env.GetConfigVariable("NODE_WORKSPACE"); memberPath != "" {
+		p.member = workspace.GetPackage(path.Clean(memberPath))
+		if p.member == nil {
+			return fmt.Errorf("workspace package %s not found", memberPath)
+		}
+		if p.member.PackageJson.Name == "" && (p.packageManager == PackageManagerYarn1 || p.packageManager == PackageManagerYarnBerry) {
+			return fmt.Errorf("workspace package %s has no name, which yarn requires to run its scripts", memberPath)
+		}
+	}
+
 	return nil
 }
 
@@ -76,6 +89,10 @@ func (p *NodeProvider) Plan(ctx *generate.GenerateContext) error {
 		ctx.Logger.LogInfo("Found workspace with %d packages", len(p.workspace.Packages))
 	}
 
+	if p.member != nil {
+		ctx.Logger.LogInfo("Building workspace package %s", p.member.Path)
+	}
+
 	isSPA := p.isSPA(ctx)
 
 	miseStep := ctx.GetMiseStepBuilder()
@@ -158,6 +175,10 @@ func (p *NodeProvider) StartCommandHelp() string {
 }
 
 func (p *NodeProvider) GetStartCommand(ctx *generate.GenerateContext) string {
+	if p.member != nil {
+		return p.getMemberStartCommand(ctx)
+	}
+
 	if start := p.getScripts(p.packageJson, "start"); start != "" {
 		return p.packageManager.RunCmd("start")
 	} else if main := p.packageJson.Main; main != "" {
@@ -172,13 +193,34 @@ func (p *NodeProvider) GetStartCommand(ctx *generate.GenerateContext) string {
 	return ""
 }
 
+// getMemberStartCommand starts the selected workspace package from the workspace root
+func (p *NodeProvider) getMemberStartCommand(ctx *generate.GenerateContext) string {
+	if start := p.getScripts(p.member.PackageJson, "start"); start != "" {
+		return p.packageManager.RunWorkspaceCmd(p.member, "start")
+	} else if main := p.member.PackageJson.Main; main != "" {
+		return p.packageManager.RunScriptCommand(path.Join(p.member.Path, main))
+	} else if files, err := ctx.App.FindFiles(path.Join(p.member.Path, "{index.js,index.ts}")); err == nil && len(files) > 0 {
+		return p.packageManager.RunScriptCommand(files[0])
+	}
+
+	return ""
+}
+
 func (p *NodeProvider) Build(ctx *generate.GenerateContext, build *generate.CommandStepBuilder) {
 	build.AddInput(ctx.NewLocalLayer())
 
-	_, ok := p.packageJson.Scripts["build"]
-	if ok {
+	buildCmd := ""
+	if p.member != nil {
+		if p.member.PackageJson.HasScript("build") {
+			buildCmd = p.packageManager.RunWorkspaceCmd(p.member, "build")
+		}
+	} else if _, ok := p.packageJson.Scripts["build"]; ok {
+		buildCmd = p.packageManager.RunCmd("build")
+	}
+
+	if buildCmd != "" {
 		build.AddCommands([]plan.Command{
-			plan.NewExecCommand(p.packageManager.RunCmd("build")),
+			plan.NewExecCommand(buildCmd),
 		})
 
 		if p.isNext() {
@@ -1,11 +1,19 @@
 package node
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
+	"testing/fstest"
 
+	"github.com/railwayapp/railpack/core/app"
+	"github.com/railwayapp/railpack/core/config"
 	"github.com/railwayapp/railpack/core/generate"
+	"github.com/railwayapp/railpack/core/logger"
+	"github.com/railwayapp/railpack/core/plan"
+	"github.com/railwayapp/railpack/core/resolver"
 	testingUtils "github.com/railwayapp/railpack/core/testing"
 	"github.com/stretchr/testify/require"
 )
@@ -230,3 +238,81 @@ func TestPackageJsonRequiresBun(t *testing.T) {
 		require.False(t, got)
 	})
 }
+
+func TestNodeWorkspaceMember(t *testing.T) {
+	files := fstest.MapFS{
+		"package.json":              {Data: []byte(`{"name": "root", "workspaces": ["apps/*"], "packageManager": "pnpm@9.1.0", "scripts": {"build": "turbo build"}}`)},
+		"pnpm-lock.yaml":            {Data: []byte(``)},
+		"apps/web/package.json":     {Data: []byte(`{"name": "@acme/web", "scripts": {"build": "vite build", "start": "node server.js"}}`)},
+		"apps/worker/package.json":  {Data: []byte(`{"name": "@acme/worker", "main": "dist/index.js"}`)},
+		"apps/unnamed/package.json": {Data: []byte(`{"scripts": {"start": "node ."}}`)},
+	}
+
+	tests := []struct {
+		member string
+		build  string
+		start  string
+	}{
+		{member: "apps/web", build: "pnpm --filter @acme/web run build", start: "pnpm --filter @acme/web run start"},
+		{member: "./apps/worker", start: "node apps/worker/dist/index.js"},
+		{member: "apps/unnamed", start: "pnpm --filter ./apps/unnamed run start"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.member, func(t *testing.T) {
+			env := app.NewEnvironment(&map[string]string{"RAILPACK_NODE_WORKSPACE": tt.member})
+			ctx, err := generate.NewGenerateContextWithResolver(context.Background(), app.NewAppFromFS(files, "app"), env, config.EmptyConfig(), resolver.NewOfflineResolver(&resolver.VersionIndex{}), logger.NewLogger())
+			require.NoError(t, err)
+
+			provider := NodeProvider{}
+			require.NoError(t, provider.Initialize(ctx))
+			require.NoError(t, provider.Plan(ctx))
+
+			// The member is installed from the workspace root with the root lockfile
+			require.Equal(t, PackageManagerPnpm, provider.packageManager)
+
+			build := (*ctx.GetStepByName("build")).(*generate.CommandStepBuilder)
+			if tt.build == "" {
+				require.Empty(t, build.Commands)
+			} else {
+				require.Equal(t, []plan.Command{plan.NewExecCommand(tt.build)}, build.Commands)
+			}
+			require.Equal(t, tt.start, ctx.Deploy.StartCmd)
+		})
+	}
+
+	ctx, err := generate.NewGenerateContextWithResolver(context.Background(), app.NewAppFromFS(files, "app"), app.NewEnvironment(&map[string]string{"RAILPACK_NODE_WORKSPACE": "apps/missing"}), config.EmptyConfig(), resolver.NewOfflineResolver(&resolver.VersionIndex{}), logger.NewLogger())
+	require.NoError(t, err)
+	provider := NodeProvider{}
+	require.ErrorContains(t, provider.Initialize(ctx), "workspace package apps/missing not found")
+
+	// Yarn only runs the scripts of workspace packages by name
+	yarnFiles := fstest.MapFS{
+		"package.json":              {Data: []byte(`{"name": "root", "workspaces": ["apps/*"], "packageManager": "yarn@4.5.0"}`)},
+		"yarn.lock":                 {Data: []byte(``)},
+		"apps/unnamed/package.json": {Data: []byte(`{"scripts": {"start": "node ."}}`)},
+	}
+	ctx, err = generate.NewGenerateContextWithResolver(context.Background(), app.NewAppFromFS(yarnFiles, "app"), app.NewEnvironment(&map[string]string{"RAILPACK_NODE_WORKSPACE": "apps/unnamed"}), config.EmptyConfig(), resolver.NewOfflineResolver(&resolver.VersionIndex{}), logger.NewLogger())
+	require.NoError(t, err)
+	provider = NodeProvider{}
+	require.ErrorContains(t, provider.Initialize(ctx), "workspace package apps/unnamed has no name, which yarn requires")
+}
+
+func TestWorkspacePackageIsDeployable(t *testing.T) {
+	tests := []struct {
+		packageJson string
+		deployable  bool
+	}{
+		{`{"name": "web", "scripts": {"start": "node server.js"}}`, true},
+		{`{"name": "worker", "main": "dist/index.js"}`, true},
+		{`{"name": "site", "dependencies": {"next": "15.0.0"}}`, true},
+		{`{"name": "ui", "scripts": {"build": "tsc"}, "devDependencies": {"vite": "6.0.0"}}`, false},
+		{`{"name": "config"}`, false},
+	}
+
+	for _, tt := range tests {
+		packageJson := NewPackageJson()
+		require.NoError(t, json.Unmarshal([]byte(tt.packageJson), packageJson))
+		require.Equal(t, tt.deployable, (&WorkspacePackage{PackageJson: packageJson}).IsDeployable(), tt.packageJson)
+	}
+}
@@ -40,6 +40,26 @@ func (p PackageManager) RunCmd(cmd string) string {
 	return fmt.Sprintf("%s run %s", p.Name(), cmd)
 }
 
+// RunWorkspaceCmd runs a script of a workspace package from the workspace root.
+// Unnamed packages are selected by path, which yarn does not support (see NodeProvider.Initialize)
+func (p PackageManager) RunWorkspaceCmd(pkg *WorkspacePackage, cmd string) string {
+	selector := pkg.PackageJson.Name
+	if selector == "" {
+		selector = "./" + pkg.Path
+	}
+
+	switch p {
+	case PackageManagerPnpm:
+		return fmt.Sprintf("pnpm --filter %s run %s", selector, cmd)
+	case PackageManagerBun:
+		return fmt.Sprintf("bun run --filter %s %s", selector, cmd)
+	case PackageManagerYarn1, PackageManagerYarnBerry:
+		return fmt.Sprintf("yarn workspace %s run %s", selector, cmd)
+	default:
+		return fmt.Sprintf("npm run %s --workspace %s", cmd, selector)
+	}
+}
+
 func (p PackageManager) RunScriptCommand(cmd string) string {
 	if p == PackageManagerBun {
 		return "bun " + cmd
@@ -18,7 +18,8 @@ const (
 var caddyfileTemplate string
 
 func (p *NodeProvider) isSPA(ctx *generate.GenerateContext) bool {
-	if ctx.Env.IsConfigVariableTruthy("NO_SPA") {
+	// Composed with another provider, the build output is served by the start command of the primary provider
+	if ctx.IsComposed() || ctx.Env.IsConfigVariableTruthy("NO_SPA") {
 		return false
 	}
 
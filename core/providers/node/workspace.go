@@ -3,6 +3,7 @@ package node
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
 
 	"github.com/railwayapp/railpack/core/app"
 )
@@ -17,6 +18,18 @@ type WorkspacePackage struct {
 	PackageJson *PackageJson
 }
 
+// Frameworks with a server that the node provider starts, so packages that use them are apps rather than libraries
+var serverFrameworks = []string{"next", "nuxt", "@remix-run/node", "@tanstack/react-start"}
+
+// IsDeployable checks if the package is an app that can be started, rather than a library shared by the other packages
+func (p *WorkspacePackage) IsDeployable() bool {
+	if p.PackageJson.HasScript("start") || p.PackageJson.Main != "" {
+		return true
+	}
+
+	return slices.ContainsFunc(serverFrameworks, p.PackageJson.hasDependency)
+}
+
 type PnpmWorkspace struct {
 	Packages []string `yaml:"packages"`
 }
@@ -0,0 +1,448 @@
+// external providers that are executables speaking a JSON protocol over stdin and stdout
+// this allows adding providers for frameworks that are not part of railpack without forking it
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	a "github.com/railwayapp/railpack/core/app"
+	"github.com/railwayapp/railpack/core/config"
+	"github.com/railwayapp/railpack/core/generate"
+	"github.com/railwayapp/railpack/core/plan"
+	"github.com/railwayapp/railpack/core/providers"
+)
+
+const (
+	ProtocolVersion = 1
+
+	// Executables with this prefix on the plugin path are providers named after the rest of the file name
+	ExecutablePrefix = "railpack-provider-"
+
+	// Directories that plugins are loaded from, separated like $PATH
+	PluginPathEnvVar = "RAILPACK_PLUGIN_PATH"
+
+	// Allows railpack.json to declare plugins that are executables in the app.
+	// Disabled by default because it runs code from the app on the machine that generates the plan
+	AllowAppPluginsEnvVar = "RAILPACK_ALLOW_APP_PLUGINS"
+
+	MethodInfo   = "info"
+	MethodDetect = "detect"
+	MethodPlan   = "plan"
+
+	// Files larger than this are listed in the snapshot without their contents
+	maxFileSize = 1 << 20
+
+	// The most files listed in the snapshot of an app
+	maxSnapshotFiles = 10000
+)
+
+// Directories that are not listed in the snapshot of an app
+var snapshotExcludes = []string{".git", "node_modules"}
+
+// Request is written to the stdin of the plugin. Every call runs the plugin once
+type Request struct {
+	Version int    `json:"version"`
+	Method  string `json:"method"`
+
+	// Not sent to info calls
+	App *AppSnapshot      `json:"app,omitempty"`
+	Env map[string]string `json:"env,omitempty"`
+}
+
+type AppSnapshot struct {
+	// Every file in the app, relative to the root of the app
+	Files []string `json:"files"`
+
+	// The contents of the files that match the patterns returned by the info call
+	Contents map[string]string `json:"contents,omitempty"`
+}
+
+// Response is read from the stdout of the plugin
+type Response struct {
+	// Info: glob patterns of the files to include the contents of in the snapshot (e.g. "*.toml")
+	Files []string `json:"files,omitempty"`
+
+	// Info: shown when the plan has no start command
+	StartCommandHelp string `json:"startCommandHelp,omitempty"`
+
+	// Detect: whether the plugin should plan the app
+	Detected bool `json:"detected,omitempty"`
+
+	// Detect: how confident the plugin is that it should plan the app, from 1 to 100. Scored like the built-in providers if not set
+	Score int `json:"score,omitempty"`
+
+	// Detect: why the plugin detected the app, and the files it was detected by
+	Reasons  []string `json:"reasons,omitempty"`
+	Evidence []string `json:"evidence,omitempty"`
+
+	// Plan: the steps, packages, caches, and deploy settings of the app, in the same format as railpack.json
+	Plan *config.Config `json:"plan,omitempty"`
+
+	// Messages shown in the build output
+	Logs []Log `json:"logs,omitempty"`
+
+	// Fails the call
+	Error string `json:"error,omitempty"`
+}
+
+type Log struct {
+	// One of: info, warn, error
+	Level string `json:"level"`
+	Msg   string `json:"msg"`
+}
+
+type PluginProvider struct {
+	name string
+	path string
+
+	info      *Response
+	snapshot  *AppSnapshot
+	detection *Response
+}
+
+// New creates a provider that runs the plugin executable at path
+func New(name, path string) *PluginProvider {
+	return &PluginProvider{name: name, path: path}
+}
+
+// Load finds the plugins declared in the config and the plugins on the plugin path.
+// Declared plugins are listed first, and are either the name of a plugin on the plugin path or the path of an executable in the app
+func Load(app *a.App, declared []string) ([]*PluginProvider, error) {
+	pluginPath := filepath.SplitList(os.Getenv(PluginPathEnvVar))
+	plugins := []*PluginProvider{}
+	seen := map[string]bool{}
+
+	add := func(plugin *PluginProvider) {
+		if seen[plugin.name] {
+			return
+		}
+		seen[plugin.name] = true
+		plugins = append(plugins, plugin)
+	}
+
+	for _, name := range declared {
+		plugin, err := resolveDeclared(app, pluginPath, name)
+		if err != nil {
+			return nil, err
+		}
+		add(plugin)
+	}
+
+	for _, dir := range pluginPath {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			log.Debugf("Skipping plugin directory %s: %s", dir, err)
+			continue
+		}
+
+		for _, entry := range entries {
+			name, ok := strings.CutPrefix(entry.Name(), ExecutablePrefix)
+			if !ok || name == "" || !isExecutable(filepath.Join(dir, entry.Name())) {
+				continue
+			}
+			add(New(name, filepath.Join(dir, entry.Name())))
+		}
+	}
+
+	return plugins, nil
+}
+
+func resolveDeclared(app *a.App, pluginPath []string, name string) (*PluginProvider, error) {
+	if !strings.ContainsRune(name, '/') {
+		for _, dir := range pluginPath {
+			path := filepath.Join(dir, ExecutablePrefix+name)
+			if isExecutable(path) {
+				return New(name, path), nil
+			}
+		}
+		return nil, fmt.Errorf("plugin `%s` not found. Add %s%s to a directory in %s", name, ExecutablePrefix, name, PluginPathEnvVar)
+	}
+
+	if os.Getenv(AllowAppPluginsEnvVar) != "true" && os.Getenv(AllowAppPluginsEnvVar) != "1" {
+		return nil, fmt.Errorf("plugin `%s` is in the app. Set %s=true to run plugins from the app", name, AllowAppPluginsEnvVar)
+	}
+
+	if app.Dir() == "" || !filepath.IsLocal(name) {
+		return nil, fmt.Errorf("plugin `%s` must be an executable in the app directory", name)
+	}
+
+	path := filepath.Join(app.Dir(), name)
+	if !isExecutable(path) {
+		return nil, fmt.Errorf("plugin `%s` is not an executable", name)
+	}
+
+	return New(strings.TrimPrefix(filepath.Base(name), ExecutablePrefix), path), nil
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular() && info.Mode()&0111 != 0
+}
+
+func (p *PluginProvider) Name() string {
+	return p.name
+}
+
+func (p *PluginProvider) Initialize(ctx *generate.GenerateContext) error {
+	return nil
+}
+
+func (p *PluginProvider) Detect(ctx *generate.GenerateContext) (bool, error) {
+	resp, err := p.call(ctx, MethodDetect)
+	if err != nil {
+		return false, err
+	}
+
+	p.detection = resp
+	return resp.Detected, nil
+}
+
+// Score returns the score from the detect reply of the plugin
+func (p *PluginProvider) Score(ctx *generate.GenerateContext) (*providers.Detection, error) {
+	if p.detection == nil || p.detection.Score == 0 {
+		return nil, nil
+	}
+
+	return &providers.Detection{
+		Score:    p.detection.Score,
+		Reasons:  p.detection.Reasons,
+		Evidence: p.detection.Evidence,
+	}, nil
+}
+
+// Plan merges the reply of the plugin into the context.
+// Steps and deploy settings are merged into the config, so railpack.json still takes precedence over the plugin
+func (p *PluginProvider) Plan(ctx *generate.GenerateContext) error {
+	resp, err := p.call(ctx, MethodPlan)
+	if err != nil {
+		return err
+	}
+
+	if resp.Plan == nil {
+		return fmt.Errorf("plugin `%s` did not return a plan", p.name)
+	}
+	pluginConfig := resp.Plan
+
+	for name, step := range pluginConfig.Steps {
+		if step == nil {
+			return fmt.Errorf("plugin `%s` returned an empty step `%s`", p.name, name)
+		}
+	}
+
+	miseStep := ctx.GetMiseStepBuilder()
+	for _, name := range slices.Sorted(maps.Keys(pluginConfig.Packages)) {
+		ref := miseStep.Default(name, pluginConfig.Packages[name])
+		miseStep.Version(ref, pluginConfig.Packages[name], p.name+" plugin")
+	}
+	miseStep.SupportingAptPackages = append(miseStep.SupportingAptPackages, pluginConfig.BuildAptPackages...)
+
+	if len(pluginConfig.Packages) > 0 {
+		ctx.Deploy.AddInputs([]plan.Layer{miseStep.GetLayer()})
+	}
+
+	// Steps without inputs are built on top of the mise packages and the app source.
+	// When composed with another provider, the steps are named after the plugin (e.g. `build:acme`)
+	steps := map[string]*config.StepConfig{}
+	for _, name := range slices.Sorted(maps.Keys(pluginConfig.Steps)) {
+		stepName := ctx.GetStepName(name)
+		steps[stepName] = pluginConfig.Steps[name]
+
+		if len(pluginConfig.Steps[name].Inputs) > 0 || ctx.GetStepByName(stepName) != nil {
+			continue
+		}
+
+		step := ctx.NewCommandStep(name)
+		step.AddInput(plan.NewStepLayer(miseStep.Name()))
+		step.AddInput(ctx.NewLocalLayer())
+	}
+	pluginConfig.Steps = steps
+
+	// The primary provider owns the start command
+	if ctx.GetStepName("") != "" && pluginConfig.Deploy != nil {
+		pluginConfig.Deploy.StartCmd = ""
+	}
+
+	p.recordConfigSources(ctx, pluginConfig)
+
+	pluginConfig.Packages = nil
+	pluginConfig.BuildAptPackages = nil
+	ctx.Config = config.Merge(pluginConfig, ctx.Config)
+
+	return nil
+}
+
+// recordConfigSources attributes the config of the plugin to the plugin, unless the key is set by the user config
+func (p *PluginProvider) recordConfigSources(ctx *generate.GenerateContext, pluginConfig *config.Config) {
+	keys := []string{}
+	for name := range pluginConfig.Steps {
+		keys = append(keys, "steps."+name)
+	}
+	for name := range pluginConfig.Caches {
+		keys = append(keys, "caches."+name)
+	}
+	if pluginConfig.Deploy != nil {
+		keys = append(keys, "deploy")
+	}
+
+	if ctx.Provenance.ConfigSources == nil {
+		ctx.Provenance.ConfigSources = map[string]generate.Source{}
+	}
+	for _, key := range keys {
+		if _, ok := ctx.Provenance.ConfigSources[key]; !ok {
+			ctx.Provenance.ConfigSources[key] = generate.Source{Type: generate.SourceTypeProvider, Name: p.name}
+		}
+	}
+}
+
+func (p *PluginProvider) CleansePlan(buildPlan *plan.BuildPlan) {}
+
+func (p *PluginProvider) StartCommandHelp() string {
+	if p.info == nil {
+		return ""
+	}
+	return p.info.StartCommandHelp
+}
+
+// call runs the plugin with a snapshot of the app
+func (p *PluginProvider) call(ctx *generate.GenerateContext, method string) (*Response, error) {
+	snapshot, err := p.getSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.run(ctx, &Request{
+		Version: ProtocolVersion,
+		Method:  method,
+		App:     snapshot,
+		Env:     ctx.Env.Variables,
+	})
+}
+
+// getSnapshot lists the files of the app and reads the files that the plugin asks for in its info reply
+func (p *PluginProvider) getSnapshot(ctx *generate.GenerateContext) (*AppSnapshot, error) {
+	if p.snapshot != nil {
+		return p.snapshot, nil
+	}
+
+	if p.info == nil {
+		info, err := p.run(ctx, &Request{Version: ProtocolVersion, Method: MethodInfo})
+		if err != nil {
+			return nil, err
+		}
+		p.info = info
+	}
+
+	files, err := listFiles(ctx.App)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := map[string]string{}
+	for _, pattern := range p.info.Files {
+		matches, err := ctx.App.FindFiles(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("plugin `%s` requested invalid file pattern %q: %w", p.name, pattern, err)
+		}
+
+		for _, match := range matches {
+			if info, err := fs.Stat(ctx.App.FS(), match); err != nil || info.Size() > maxFileSize {
+				log.Debugf("Skipping contents of %s for plugin %s", match, p.name)
+				continue
+			}
+
+			content, err := ctx.App.ReadFile(match)
+			if err != nil {
+				return nil, err
+			}
+			contents[match] = content
+		}
+	}
+
+	p.snapshot = &AppSnapshot{Files: files, Contents: contents}
+	return p.snapshot, nil
+}
+
+// listFiles lists the files of the app, skipping directories that are not part of the app source
+func listFiles(app *a.App) ([]string, error) {
+	files := []string{}
+	err := fs.WalkDir(app.FS(), ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if slices.Contains(snapshotExcludes, d.Name()) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if len(files) >= maxSnapshotFiles {
+			return fs.SkipAll
+		}
+		files = append(files, path)
+		return nil
+	})
+
+	return files, err
+}
+
+// run sends the request to the plugin and reads its response. The plugin is killed when the plan is cancelled
+func (p *PluginProvider) run(ctx *generate.GenerateContext, req *Request) (*Response, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx.Context(), p.path)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	log.Debugf("Running %s plugin %s", req.Method, p.path)
+
+	if err := cmd.Run(); err != nil {
+		if ctxErr := ctx.Context().Err(); ctxErr != nil {
+			return nil, fmt.Errorf("plugin `%s` did not finish: %w", p.name, ctxErr)
+		}
+		return nil, fmt.Errorf("plugin `%s` failed to %s: %w\n%s", p.name, req.Method, err, strings.TrimSpace(stderr.String()))
+	}
+
+	if stderr.Len() > 0 {
+		log.Debugf("Plugin %s stderr: %s", p.name, stderr.String())
+	}
+
+	resp := &Response{}
+	if err := json.Unmarshal(stdout.Bytes(), resp); err != nil {
+		return nil, fmt.Errorf("plugin `%s` returned an invalid %s response: %w", p.name, req.Method, err)
+	}
+
+	for _, msg := range resp.Logs {
+		switch msg.Level {
+		case "warn":
+			ctx.Logger.LogWarn("%s", msg.Msg)
+		case "error":
+			ctx.Logger.LogError("%s", msg.Msg)
+		default:
+			ctx.Logger.LogInfo("%s", msg.Msg)
+		}
+	}
+
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin `%s` failed to %s: %s", p.name, req.Method, resp.Error)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,239 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/railwayapp/railpack/core/app"
+	"github.com/railwayapp/railpack/core/config"
+	"github.com/railwayapp/railpack/core/generate"
+	"github.com/railwayapp/railpack/core/logger"
+	"github.com/railwayapp/railpack/core/plan"
+	"github.com/railwayapp/railpack/core/resolver"
+	"github.com/stretchr/testify/require"
+)
+
+// A plugin that plans apps with an acme.toml file, and saves the last request it received next to itself
+const acmePlugin = `#!/bin/sh
+req=$(cat)
+echo "$req" > "$0.request.json"
+case "$req" in
+  *'"method":"info"'*)
+    echo '{"files": ["*.toml"], "startCommandHelp": "Set the start command in acme.toml"}' ;;
+  *'"method":"detect"'*)
+    case "$req" in
+      *'acme.toml'*) echo '{"detected": true, "score": 80, "reasons": ["found acme.toml"], "evidence": ["acme.toml"]}' ;;
+      *) echo '{"detected": false}' ;;
+    esac ;;
+  *'"method":"plan"'*)
+    echo '{
+      "logs": [{"level": "info", "msg": "Planning with acme"}],
+      "plan": {
+        "packages": {"node": "22"},
+        "buildAptPackages": ["libacme"],
+        "steps": {"build": {"commands": ["acme build"], "caches": ["acme"]}},
+        "caches": {"acme": {"directory": "/root/.acme", "type": "shared"}},
+        "deploy": {"startCommand": "acme start"}
+      }
+    }' ;;
+esac
+`
+
+const failingPlugin = `#!/bin/sh
+cat > /dev/null
+echo '{"error": "acme is broken"}'
+`
+
+func writePlugin(t *testing.T, dir, name, script string) string {
+	path := filepath.Join(dir, ExecutablePrefix+name)
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func readRequest(t *testing.T, path string) *Request {
+	data, err := os.ReadFile(path + ".request.json")
+	require.NoError(t, err)
+
+	req := &Request{}
+	require.NoError(t, json.Unmarshal(data, req))
+	return req
+}
+
+// newContext creates a context that resolves versions offline
+func newContext(t *testing.T, fsys fstest.MapFS) *generate.GenerateContext {
+	index := &resolver.VersionIndex{Packages: map[string][]string{"node": {"22.12.0"}}}
+	ctx, err := generate.NewGenerateContextWithResolver(context.Background(), app.NewAppFromFS(fsys, "app"), app.NewEnvironment(nil), config.EmptyConfig(), resolver.NewOfflineResolver(index), logger.NewLogger())
+	require.NoError(t, err)
+	return ctx
+}
+
+func acmeContext(t *testing.T) *generate.GenerateContext {
+	return newContext(t, fstest.MapFS{
+		"acme.toml":             {Data: []byte(`name = "app"`)},
+		"src/main.acme":         {Data: []byte(`print "hello"`)},
+		"node_modules/x/x.json": {Data: []byte(`{}`)},
+	})
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "acme", acmePlugin)
+	writePlugin(t, dir, "other", failingPlugin)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ExecutablePrefix+"notexecutable"), []byte(""), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "railpack-unrelated"), []byte(""), 0755))
+
+	t.Setenv(PluginPathEnvVar, dir)
+	userApp := app.NewAppFromFS(fstest.MapFS{}, "app")
+
+	plugins, err := Load(userApp, nil)
+	require.NoError(t, err)
+	require.Len(t, plugins, 2)
+	require.Equal(t, "acme", plugins[0].Name())
+	require.Equal(t, "other", plugins[1].Name())
+
+	// Declared plugins are listed first
+	plugins, err = Load(userApp, []string{"other"})
+	require.NoError(t, err)
+	require.Len(t, plugins, 2)
+	require.Equal(t, "other", plugins[0].Name())
+
+	_, err = Load(userApp, []string{"missing"})
+	require.ErrorContains(t, err, "plugin `missing` not found")
+}
+
+func TestLoadFromApp(t *testing.T) {
+	t.Setenv(PluginPathEnvVar, "")
+
+	appDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(appDir, "plugins"), 0755))
+	writePlugin(t, filepath.Join(appDir, "plugins"), "acme", acmePlugin)
+	userApp, err := app.NewApp(appDir)
+	require.NoError(t, err)
+
+	_, err = Load(userApp, []string{"plugins/railpack-provider-acme"})
+	require.ErrorContains(t, err, AllowAppPluginsEnvVar)
+
+	t.Setenv(AllowAppPluginsEnvVar, "true")
+
+	plugins, err := Load(userApp, []string{"plugins/railpack-provider-acme"})
+	require.NoError(t, err)
+	require.Len(t, plugins, 1)
+	require.Equal(t, "acme", plugins[0].Name())
+
+	_, err = Load(userApp, []string{"../railpack-provider-acme"})
+	require.ErrorContains(t, err, "must be an executable in the app directory")
+
+	// Apps that are not on disk cannot have plugins
+	_, err = Load(app.NewAppFromFS(fstest.MapFS{}, "app"), []string{"plugins/railpack-provider-acme"})
+	require.Error(t, err)
+}
+
+func TestDetect(t *testing.T) {
+	path := writePlugin(t, t.TempDir(), "acme", acmePlugin)
+	provider := New("acme", path)
+
+	detected, err := provider.Detect(acmeContext(t))
+	require.NoError(t, err)
+	require.True(t, detected)
+	require.Equal(t, "Set the start command in acme.toml", provider.StartCommandHelp())
+
+	detection, err := provider.Score(acmeContext(t))
+	require.NoError(t, err)
+	require.Equal(t, 80, detection.Score)
+	require.Equal(t, []string{"acme.toml"}, detection.Evidence)
+
+	req := readRequest(t, path)
+	require.Equal(t, ProtocolVersion, req.Version)
+	require.Equal(t, MethodDetect, req.Method)
+	require.Equal(t, []string{"acme.toml", "src/main.acme"}, req.App.Files)
+	require.Equal(t, map[string]string{"acme.toml": `name = "app"`}, req.App.Contents)
+
+	ctx := newContext(t, fstest.MapFS{
+		"index.js": {Data: []byte(`console.log("hello")`)},
+	})
+	detected, err = New("acme", path).Detect(ctx)
+	require.NoError(t, err)
+	require.False(t, detected)
+}
+
+func TestPlan(t *testing.T) {
+	path := writePlugin(t, t.TempDir(), "acme", acmePlugin)
+	provider := New("acme", path)
+	ctx := acmeContext(t)
+
+	require.NoError(t, provider.Plan(ctx))
+
+	miseStep := ctx.GetMiseStepBuilder()
+	require.Contains(t, miseStep.SupportingAptPackages, "libacme")
+	require.Equal(t, "22", ctx.Resolver.Get("node").Version)
+	require.Equal(t, "acme plugin", ctx.Resolver.Get("node").Source)
+
+	build := ctx.GetStepByName("build")
+	require.NotNil(t, build)
+	require.Equal(t, []string{"sh -c 'acme build'"}, commands(ctx.Config.Steps["build"].Commands))
+	require.Equal(t, "acme start", ctx.Config.Deploy.StartCmd)
+	require.Equal(t, "/root/.acme", ctx.Config.Caches["acme"].Directory)
+	require.Equal(t, generate.Source{Type: generate.SourceTypeProvider, Name: "acme"}, ctx.Provenance.ConfigSource("steps.build.commands"))
+
+	require.Equal(t, "Planning with acme", ctx.Logger.Logs[len(ctx.Logger.Logs)-1].Msg)
+}
+
+func TestPlanUserConfigTakesPrecedence(t *testing.T) {
+	path := writePlugin(t, t.TempDir(), "acme", acmePlugin)
+	ctx := acmeContext(t)
+	ctx.Config.Deploy.StartCmd = "acme start --port 3000"
+
+	require.NoError(t, New("acme", path).Plan(ctx))
+	require.Equal(t, "acme start --port 3000", ctx.Config.Deploy.StartCmd)
+	require.Equal(t, []string{"sh -c 'acme build'"}, commands(ctx.Config.Steps["build"].Commands))
+}
+
+func TestPlanComposed(t *testing.T) {
+	path := writePlugin(t, t.TempDir(), "acme", acmePlugin)
+	ctx := acmeContext(t)
+
+	ctx.EnterSubContext("acme")
+	require.NoError(t, New("acme", path).Plan(ctx))
+	ctx.ExitSubContext()
+
+	require.NotNil(t, ctx.GetStepByName("build:acme"))
+	require.Contains(t, ctx.Config.Steps, "build:acme")
+	require.Empty(t, ctx.Config.Deploy.StartCmd)
+}
+
+func TestPluginErrors(t *testing.T) {
+	dir := t.TempDir()
+	ctx := acmeContext(t)
+
+	_, err := New("other", writePlugin(t, dir, "other", failingPlugin)).Detect(ctx)
+	require.ErrorContains(t, err, "plugin `other` failed to info: acme is broken")
+
+	_, err = New("exit", writePlugin(t, dir, "exit", "#!/bin/sh\necho 'no acme here' >&2\nexit 1\n")).Detect(ctx)
+	require.ErrorContains(t, err, "no acme here")
+
+	_, err = New("invalid", writePlugin(t, dir, "invalid", "#!/bin/sh\necho 'not json'\n")).Detect(ctx)
+	require.ErrorContains(t, err, "returned an invalid info response")
+
+	nullStep := `#!/bin/sh
+case "$(cat)" in
+  *'"method":"info"'*) echo '{}' ;;
+  *) echo '{"plan": {"steps": {"build": null}}}' ;;
+esac
+`
+	err = New("null", writePlugin(t, dir, "null", nullStep)).Plan(ctx)
+	require.ErrorContains(t, err, "plugin `null` returned an empty step `build`")
+}
+
+func commands(cmds []plan.Command) []string {
+	result := []string{}
+	for _, cmd := range cmds {
+		if exec, ok := cmd.(plan.ExecCommand); ok {
+			result = append(result, exec.Cmd)
+		}
+	}
+	return result
+}
@@ -28,8 +28,13 @@ type Provider interface {
 	StartCommandHelp() string
 }
 
+// GetLanguageProviders returns the built-in providers and the registered providers in the order they are detected
 func GetLanguageProviders() []Provider {
-	// Order is important here. The first provider that returns true from Detect() will be used.
+	return withRegistered(builtinProviders())
+}
+
+func builtinProviders() []Provider {
+	// The provider with the highest detection score is used (see DetectAll). Order only breaks ties between scores
 	return []Provider{
 		&php.PhpProvider{},
 		&golang.GoProvider{},
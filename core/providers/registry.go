@@ -0,0 +1,245 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/railwayapp/railpack/core/generate"
+	"github.com/railwayapp/railpack/core/plan"
+)
+
+const (
+	// The priority of the built-in providers
+	BuiltinPriority = 0
+
+	// The priority of registered providers that do not set one, so they are detected before the built-in providers
+	DefaultPriority = 100
+)
+
+var (
+	registryMu sync.RWMutex
+	registered []*registration
+)
+
+type registration struct {
+	provider Provider
+	priority int
+
+	// Set when any option is used. Otherwise a provider with the name of a built-in provider takes its place
+	positioned bool
+
+	before string
+	after  string
+}
+
+type RegisterOption func(r *registration)
+
+// WithPriority sets the priority of the provider. Providers with a higher priority are detected first.
+// Built-in providers have BuiltinPriority, so a negative priority detects the provider after all of them
+func WithPriority(priority int) RegisterOption {
+	return func(r *registration) {
+		r.priority = priority
+		r.positioned = true
+	}
+}
+
+// Before detects the provider right before the provider with the given name
+func Before(name string) RegisterOption {
+	return func(r *registration) {
+		r.before = name
+		r.positioned = true
+	}
+}
+
+// After detects the provider right after the provider with the given name
+func After(name string) RegisterOption {
+	return func(r *registration) {
+		r.after = name
+		r.positioned = true
+	}
+}
+
+// Register adds a provider to every plan generated by this program.
+// A provider with the same name as a built-in or registered provider replaces it.
+// Every plan uses its own copy of the provider, so it can keep state between Detect, Initialize, and Plan
+func Register(p Provider, opts ...RegisterOption) error {
+	if p == nil || p.Name() == "" {
+		return errors.New("provider must have a name")
+	}
+
+	r := &registration{provider: p, priority: DefaultPriority}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.before != "" && r.after != "" {
+		return fmt.Errorf("provider `%s` cannot be registered both before `%s` and after `%s`", p.Name(), r.before, r.after)
+	}
+	if r.before == p.Name() || r.after == p.Name() {
+		return fmt.Errorf("provider `%s` cannot be ordered relative to itself", p.Name())
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registered = slices.DeleteFunc(registered, func(existing *registration) bool {
+		return existing.provider.Name() == p.Name()
+	})
+	registered = append(registered, r)
+
+	return nil
+}
+
+// Unregister removes a registered provider. Built-in providers that it replaced are used again
+func Unregister(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registered = slices.DeleteFunc(registered, func(r *registration) bool {
+		return r.provider.Name() == name
+	})
+}
+
+type rankedProvider struct {
+	provider Provider
+	priority int
+}
+
+// withRegistered adds the registered providers to the built-in providers and orders them by priority.
+// Providers registered before or after another provider are then moved next to it, in the order they were registered
+func withRegistered(builtins []Provider) []Provider {
+	registryMu.RLock()
+	registrations := slices.Clone(registered)
+	registryMu.RUnlock()
+
+	if len(registrations) == 0 {
+		return builtins
+	}
+
+	ranked := []rankedProvider{}
+	for _, p := range builtins {
+		ranked = append(ranked, rankedProvider{provider: p, priority: BuiltinPriority})
+	}
+
+	for _, r := range registrations {
+		idx := indexOfProvider(ranked, r.provider.Name())
+		if idx != -1 && !r.positioned {
+			ranked[idx].provider = newInstance(r.provider)
+			continue
+		}
+		if idx != -1 {
+			ranked = slices.Delete(ranked, idx, idx+1)
+		}
+
+		ranked = append(ranked, rankedProvider{provider: newInstance(r.provider), priority: r.priority})
+	}
+
+	slices.SortStableFunc(ranked, func(a, b rankedProvider) int {
+		return b.priority - a.priority
+	})
+
+	for _, r := range registrations {
+		target := r.before + r.after
+		if target == "" || indexOfProvider(ranked, target) == -1 {
+			continue
+		}
+
+		idx := indexOfProvider(ranked, r.provider.Name())
+		p := ranked[idx]
+		ranked = slices.Delete(ranked, idx, idx+1)
+
+		idx = indexOfProvider(ranked, target)
+		if r.after != "" {
+			idx++
+		}
+		ranked = slices.Insert(ranked, idx, p)
+	}
+
+	result := make([]Provider, 0, len(ranked))
+	for _, p := range ranked {
+		result = append(result, p.provider)
+	}
+	return result
+}
+
+func indexOfProvider(ranked []rankedProvider, name string) int {
+	return slices.IndexFunc(ranked, func(p rankedProvider) bool {
+		return p.provider.Name() == name
+	})
+}
+
+// newInstance returns a shallow copy of a provider that is a pointer to a struct, so each plan has its own state
+func newInstance[T any](p T) T {
+	if cloner, ok := any(p).(interface{ newInstance() Provider }); ok {
+		return cloner.newInstance().(T)
+	}
+
+	v := reflect.ValueOf(p)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return p
+	}
+
+	c := reflect.New(v.Elem().Type())
+	c.Elem().Set(v.Elem())
+	return c.Interface().(T)
+}
+
+// ExternalProvider is a provider for programs that use railpack as a library.
+// It only depends on generate.ProviderContext, which does not change within a generate.ProviderAPIVersion.
+// It can also implement StartCommandHelp() string to explain how to set a start command,
+// and Score(ctx generate.ProviderContext) (*Detection, error) to score its detection
+type ExternalProvider interface {
+	Name() string
+	Detect(ctx generate.ProviderContext) (bool, error)
+	Plan(ctx generate.ProviderContext) error
+}
+
+// FromExternal wraps an external provider so that it can be registered
+func FromExternal(p ExternalProvider) Provider {
+	return &externalProvider{provider: p}
+}
+
+type externalProvider struct {
+	provider ExternalProvider
+}
+
+func (p *externalProvider) Name() string {
+	return p.provider.Name()
+}
+
+func (p *externalProvider) Detect(ctx *generate.GenerateContext) (bool, error) {
+	return p.provider.Detect(ctx)
+}
+
+func (p *externalProvider) Initialize(ctx *generate.GenerateContext) error {
+	return nil
+}
+
+func (p *externalProvider) Plan(ctx *generate.GenerateContext) error {
+	return p.provider.Plan(ctx)
+}
+
+func (p *externalProvider) CleansePlan(buildPlan *plan.BuildPlan) {}
+
+func (p *externalProvider) StartCommandHelp() string {
+	if helper, ok := p.provider.(interface{ StartCommandHelp() string }); ok {
+		return helper.StartCommandHelp()
+	}
+	return ""
+}
+
+func (p *externalProvider) Score(ctx *generate.GenerateContext) (*Detection, error) {
+	if scorer, ok := p.provider.(interface {
+		Score(ctx generate.ProviderContext) (*Detection, error)
+	}); ok {
+		return scorer.Score(ctx)
+	}
+	return nil, nil
+}
+
+func (p *externalProvider) newInstance() Provider {
+	return &externalProvider{provider: newInstance(p.provider)}
+}
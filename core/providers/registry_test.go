@@ -0,0 +1,152 @@
+package providers
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/railwayapp/railpack/core/app"
+	"github.com/railwayapp/railpack/core/config"
+	"github.com/railwayapp/railpack/core/generate"
+	"github.com/railwayapp/railpack/core/logger"
+	"github.com/railwayapp/railpack/core/plan"
+	"github.com/railwayapp/railpack/core/providers/node"
+	"github.com/railwayapp/railpack/core/resolver"
+	"github.com/stretchr/testify/require"
+)
+
+type testProvider struct {
+	name    string
+	planned bool
+}
+
+func (p *testProvider) Name() string                                       { return p.name }
+func (p *testProvider) Detect(ctx *generate.GenerateContext) (bool, error) { return true, nil }
+func (p *testProvider) Initialize(ctx *generate.GenerateContext) error     { return nil }
+func (p *testProvider) CleansePlan(buildPlan *plan.BuildPlan)              {}
+func (p *testProvider) StartCommandHelp() string                           { return "" }
+
+func (p *testProvider) Plan(ctx *generate.GenerateContext) error {
+	p.planned = true
+	return nil
+}
+
+// A provider that only uses the versioned provider API
+type acmeProvider struct{}
+
+func (p *acmeProvider) Name() string { return "acme" }
+
+func (p *acmeProvider) Detect(ctx generate.ProviderContext) (bool, error) {
+	return ctx.GetApp().HasFile("acme.toml"), nil
+}
+
+func (p *acmeProvider) Plan(ctx generate.ProviderContext) error {
+	build := ctx.NewCommandStep("build")
+	build.AddInput(ctx.NewLocalLayer())
+	build.AddCommand(plan.NewExecShellCommand("acme build"))
+	ctx.GetDeploy().SetStartCmd("acme start")
+	return nil
+}
+
+func (p *acmeProvider) StartCommandHelp() string { return "Set the start command in acme.toml" }
+
+// newContext creates a context that resolves versions offline
+func newContext(t *testing.T, fsys fstest.MapFS) *generate.GenerateContext {
+	ctx, err := generate.NewGenerateContextWithResolver(context.Background(), app.NewAppFromFS(fsys, "app"), app.NewEnvironment(nil), config.EmptyConfig(), resolver.NewOfflineResolver(&resolver.VersionIndex{}), logger.NewLogger())
+	require.NoError(t, err)
+	return ctx
+}
+
+func register(t *testing.T, p Provider, opts ...RegisterOption) {
+	require.NoError(t, Register(p, opts...))
+	t.Cleanup(func() { Unregister(p.Name()) })
+}
+
+func providerNames() []string {
+	names := []string{}
+	for _, p := range GetLanguageProviders() {
+		names = append(names, p.Name())
+	}
+	return names
+}
+
+func TestRegisterOrder(t *testing.T) {
+	builtins := providerNames()
+
+	register(t, &testProvider{name: "first"})
+	register(t, &testProvider{name: "fallback"}, WithPriority(-1))
+	register(t, &testProvider{name: "before-node"}, Before("node"))
+	register(t, &testProvider{name: "after-python"}, After("python"))
+	register(t, &testProvider{name: "missing-target"}, Before("missing"))
+
+	names := providerNames()
+	require.Equal(t, []string{"first", "missing-target"}, names[:2])
+	require.Equal(t, "fallback", names[len(names)-1])
+	require.Equal(t, "before-node", names[slices.Index(names, "node")-1])
+	require.Equal(t, "after-python", names[slices.Index(names, "python")+1])
+	require.Len(t, names, len(builtins)+5)
+
+	Unregister("first")
+	Unregister("fallback")
+	Unregister("before-node")
+	Unregister("after-python")
+	Unregister("missing-target")
+	require.Equal(t, builtins, providerNames())
+}
+
+func TestRegisterReplacesProvider(t *testing.T) {
+	builtins := providerNames()
+
+	register(t, &testProvider{name: "node"})
+	require.Equal(t, builtins, providerNames())
+	require.IsType(t, &testProvider{}, GetProvider("node"))
+
+	// A priority moves the replaced provider
+	register(t, &testProvider{name: "node"}, WithPriority(DefaultPriority))
+	require.Equal(t, "node", providerNames()[0])
+	require.Len(t, providerNames(), len(builtins))
+
+	Unregister("node")
+	require.Equal(t, builtins, providerNames())
+	require.IsType(t, &node.NodeProvider{}, GetProvider("node"))
+}
+
+func TestRegisterErrors(t *testing.T) {
+	require.Error(t, Register(&testProvider{}))
+	require.Error(t, Register(&testProvider{name: "acme"}, Before("node"), After("python")))
+	require.Error(t, Register(&testProvider{name: "acme"}, Before("acme")))
+}
+
+func TestRegisteredProvidersAreCopied(t *testing.T) {
+	provider := &testProvider{name: "stateful"}
+	register(t, provider)
+
+	ctx := newContext(t, fstest.MapFS{})
+	registered := GetProvider("stateful")
+	require.NoError(t, registered.Plan(ctx))
+
+	require.True(t, registered.(*testProvider).planned)
+	require.False(t, provider.planned)
+	require.False(t, GetProvider("stateful").(*testProvider).planned)
+}
+
+func TestRegisterExternalProvider(t *testing.T) {
+	register(t, FromExternal(&acmeProvider{}))
+
+	provider := GetLanguageProviders()[0]
+	require.Equal(t, "acme", provider.Name())
+	require.Equal(t, "Set the start command in acme.toml", provider.StartCommandHelp())
+
+	ctx := newContext(t, fstest.MapFS{
+		"acme.toml": {Data: []byte(`name = "app"`)},
+	})
+
+	detected, err := provider.Detect(ctx)
+	require.NoError(t, err)
+	require.True(t, detected)
+
+	require.NoError(t, provider.Plan(ctx))
+	require.NotNil(t, ctx.GetStepByName("build"))
+	require.Equal(t, "acme start", ctx.Deploy.StartCmd)
+}
@@ -1,9 +1,8 @@
 package shell
 
 import (
-	"os"
-	"path/filepath"
 	"testing"
+	"testing/fstest"
 
 	"github.com/railwayapp/railpack/core/app"
 	testingUtils "github.com/railwayapp/railpack/core/testing"
@@ -170,12 +169,9 @@ func TestDetectShellInterpreter(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tmpDir := t.TempDir()
-			scriptPath := filepath.Join(tmpDir, "test.sh")
-			err := os.WriteFile(scriptPath, []byte(tt.scriptContent), 0644)
-			require.NoError(t, err)
-
-			ctx := testingUtils.CreateGenerateContext(t, tmpDir)
+			ctx := testingUtils.CreateGenerateContextFromFS(t, fstest.MapFS{
+				"test.sh": {Data: []byte(tt.scriptContent)},
+			})
 			got, err := detectShellInterpreter(ctx, "test.sh")
 			require.NoError(t, err)
 			require.Equal(t, tt.wantInterpreter, got)
@@ -0,0 +1,127 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// How long versions from mise are reused before mise is asked again
+const DefaultVersionCacheTTL = 10 * time.Minute
+
+// VersionCache is a VersionSource that remembers the versions returned by another source.
+// It is safe to share between concurrent plans. Concurrent lookups of the same version wait for a single call to the source
+type VersionCache struct {
+	source VersionSource
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*versionCacheEntry
+}
+
+type versionCacheEntry struct {
+	// Closed when the lookup finished. The other fields are only read after that
+	done     chan struct{}
+	versions []string
+	err      error
+	expires  time.Time
+}
+
+func NewVersionCache(source VersionSource, ttl time.Duration) *VersionCache {
+	return &VersionCache{
+		source:  source,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]*versionCacheEntry{},
+	}
+}
+
+func (c *VersionCache) GetLatestVersion(ctx context.Context, pkg, version string) (string, error) {
+	versions, err := c.get(ctx, "latest "+pkg+"@"+version, func(ctx context.Context) ([]string, error) {
+		latest, err := c.source.GetLatestVersion(ctx, pkg, version)
+		if err != nil {
+			return nil, err
+		}
+		return []string{latest}, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return versions[0], nil
+}
+
+func (c *VersionCache) GetAllVersions(ctx context.Context, pkg, version string) ([]string, error) {
+	return c.get(ctx, "all "+pkg+"@"+version, func(ctx context.Context) ([]string, error) {
+		return c.source.GetAllVersions(ctx, pkg, version)
+	})
+}
+
+func (c *VersionCache) ListVersions(ctx context.Context, pkg string) ([]string, error) {
+	return c.get(ctx, "list "+pkg, func(ctx context.Context) ([]string, error) {
+		return c.source.ListVersions(ctx, pkg)
+	})
+}
+
+// get returns the cached versions for the key, or loads them from the source.
+// Errors are not cached, so the next plan asks the source again
+func (c *VersionCache) get(ctx context.Context, key string, load func(ctx context.Context) ([]string, error)) ([]string, error) {
+	for {
+		c.mu.Lock()
+		entry, ok := c.entries[key]
+		if ok && entry.isExpired(c.now()) {
+			delete(c.entries, key)
+			ok = false
+		}
+
+		if !ok {
+			entry = &versionCacheEntry{done: make(chan struct{})}
+			c.entries[key] = entry
+			c.mu.Unlock()
+
+			entry.versions, entry.err = load(ctx)
+			entry.expires = c.now().Add(c.ttl)
+			if entry.err != nil {
+				c.mu.Lock()
+				if c.entries[key] == entry {
+					delete(c.entries, key)
+				}
+				c.mu.Unlock()
+			}
+			close(entry.done)
+
+			return slices.Clone(entry.versions), entry.err
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		// The plan that was loading the versions was cancelled, which does not mean this plan should fail
+		if isContextError(entry.err) && ctx.Err() == nil {
+			continue
+		}
+
+		return slices.Clone(entry.versions), entry.err
+	}
+}
+
+// isExpired is true if the lookup finished before the TTL. Lookups that are still running are never expired
+func (e *versionCacheEntry) isExpired(now time.Time) bool {
+	select {
+	case <-e.done:
+		return !now.Before(e.expires)
+	default:
+		return false
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
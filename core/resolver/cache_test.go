@@ -0,0 +1,119 @@
+package resolver
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingSource is a version index that counts how often it is asked for versions
+type countingSource struct {
+	*VersionIndex
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func newCountingSource() *countingSource {
+	return &countingSource{VersionIndex: testVersionIndex(), started: make(chan struct{}, 100), release: make(chan struct{})}
+}
+
+func (s *countingSource) GetLatestVersion(ctx context.Context, pkg, version string) (string, error) {
+	s.calls.Add(1)
+	s.started <- struct{}{}
+
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	return s.VersionIndex.GetLatestVersion(ctx, pkg, version)
+}
+
+func TestVersionCacheConcurrent(t *testing.T) {
+	source := newCountingSource()
+	cache := NewVersionCache(source, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			latest, err := cache.GetLatestVersion(context.Background(), "node", "22")
+			require.NoError(t, err)
+			require.Equal(t, "22.12.0", latest)
+		}()
+	}
+
+	<-source.started
+	close(source.release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), source.calls.Load())
+}
+
+func TestVersionCacheExpires(t *testing.T) {
+	source := newCountingSource()
+	close(source.release)
+
+	now := time.Now()
+	cache := NewVersionCache(source, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		_, err := cache.GetLatestVersion(context.Background(), "node", "22")
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(1), source.calls.Load())
+
+	now = now.Add(time.Minute)
+	_, err := cache.GetLatestVersion(context.Background(), "node", "22")
+	require.NoError(t, err)
+	require.Equal(t, int32(2), source.calls.Load())
+}
+
+func TestVersionCacheErrors(t *testing.T) {
+	source := newCountingSource()
+	close(source.release)
+	cache := NewVersionCache(source, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		_, err := cache.GetLatestVersion(context.Background(), "missing", "1")
+		require.Error(t, err)
+	}
+	require.Equal(t, int32(2), source.calls.Load())
+}
+
+func TestVersionCacheCancelledLookup(t *testing.T) {
+	source := newCountingSource()
+	cache := NewVersionCache(source, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		_, err := cache.GetLatestVersion(ctx, "node", "22")
+		firstErr <- err
+	}()
+	<-source.started
+
+	secondResult := make(chan string)
+	go func() {
+		latest, err := cache.GetLatestVersion(context.Background(), "node", "22")
+		require.NoError(t, err)
+		secondResult <- latest
+	}()
+
+	// The waiting lookup loads the versions itself once the first lookup is cancelled
+	cancel()
+	require.ErrorIs(t, <-firstErr, context.Canceled)
+
+	<-source.started
+	close(source.release)
+	require.Equal(t, "22.12.0", <-secondResult)
+	require.Equal(t, int32(2), source.calls.Load())
+}
@@ -0,0 +1,427 @@
+package resolver
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed package version. Any number of numeric parts is supported (e.g. 8.0.100)
+type Version struct {
+	Parts      []int
+	Prerelease string
+	Original   string
+}
+
+// Constraint is a version range in npm, PEP 440, Gemfile, or Cargo syntax.
+// A version satisfies the constraint if it satisfies every comparator of any one of the sets
+type Constraint struct {
+	sets     [][]comparator
+	original string
+}
+
+// Dialect is the syntax of a version range, which changes how partial versions are compared
+type Dialect string
+
+const (
+	// npm and Cargo treat a partial version as a range, so <=3.11 includes every 3.11.x version
+	DialectNpm Dialect = "npm"
+
+	// PEP 440 and Gemfile compare a partial version as if the missing parts were 0, so <=3.11 excludes 3.11.1
+	DialectPEP440 Dialect = "pep440"
+	DialectGem    Dialect = "gem"
+)
+
+// The dialect of the version ranges of packages that are not npm or Cargo style
+var packageDialects = map[string]Dialect{
+	"python": DialectPEP440,
+	"uv":     DialectPEP440,
+	"poetry": DialectPEP440,
+	"ruby":   DialectGem,
+}
+
+// DialectOf returns the dialect of the version ranges of a package
+func DialectOf(name string) Dialect {
+	if dialect, ok := packageDialects[name]; ok {
+		return dialect
+	}
+	return DialectNpm
+}
+
+type comparator struct {
+	op      string
+	version Version
+
+	// The number of parts that were specified, e.g. 2 for "3.11" or "3.11.x"
+	specified int
+}
+
+var (
+	versionRegex = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)(?:-?([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]*)?$`)
+
+	// Operators sorted so that the longest operator is matched first
+	constraintOperators = []string{"===", "==", "!=", "~=", "~>", ">=", "<=", ">", "<", "=", "^", "~"}
+)
+
+func ParseVersion(version string) (Version, error) {
+	version = strings.TrimSpace(version)
+
+	matches := versionRegex.FindStringSubmatch(version)
+	if matches == nil {
+		return Version{}, fmt.Errorf("invalid version %q", version)
+	}
+
+	parts := []int{}
+	for _, part := range strings.Split(matches[1], ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid version %q", version)
+		}
+		parts = append(parts, n)
+	}
+
+	return Version{Parts: parts, Prerelease: matches[2], Original: version}, nil
+}
+
+func (v Version) String() string {
+	return v.Original
+}
+
+// Compare returns -1, 0, or 1 if the version is less than, equal to, or greater than the other version.
+// Missing parts are treated as 0 and prereleases come before the release
+func (v Version) Compare(other Version) int {
+	for i := 0; i < max(len(v.Parts), len(other.Parts)); i++ {
+		a, b := part(v.Parts, i), part(other.Parts, i)
+		if a != b {
+			if a < b {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+
+	return comparePrerelease(v.Prerelease, other.Prerelease)
+}
+
+// comparePrerelease compares the dot separated identifiers of prereleases (semver §11), so rc.9 comes before rc.10.
+// Identifiers with a number after letters (e.g. PEP 440's rc9) are compared by the letters and then the number
+func comparePrerelease(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+
+	for i := 0; i < min(len(as), len(bs)); i++ {
+		if c := compareIdentifier(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Compare(len(as), len(bs))
+}
+
+func compareIdentifier(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+
+	switch {
+	case aErr == nil && bErr == nil:
+		return cmp.Compare(an, bn)
+	case aErr == nil:
+		// Numeric identifiers come before alphanumeric ones
+		return -1
+	case bErr == nil:
+		return 1
+	}
+
+	aPrefix, aNumber := splitTrailingNumber(a)
+	bPrefix, bNumber := splitTrailingNumber(b)
+	if aPrefix != bPrefix || aNumber < 0 || bNumber < 0 {
+		return strings.Compare(a, b)
+	}
+
+	return cmp.Compare(aNumber, bNumber)
+}
+
+// splitTrailingNumber splits an identifier like rc10 into rc and 10. The number is -1 if there is none
+func splitTrailingNumber(identifier string) (string, int) {
+	prefix := strings.TrimRight(identifier, "0123456789")
+	number, err := strconv.Atoi(identifier[len(prefix):])
+	if err != nil {
+		return identifier, -1
+	}
+	return prefix, number
+}
+
+// ParseConstraint parses a version range. Supported syntax includes
+//
+//	22, 22.x, 3.11.*, latest, *       prefix matches
+//	>=18 <20, >=3.9,<3.13             comparators separated by spaces or commas
+//	^1.2.3, ~1.2.3                    npm and Cargo caret and tilde ranges
+//	~> 3.2, ~=3.11                    Gemfile and PEP 440 compatible releases
+//	==3.11.*, !=3.12.1, ===3.11.4     PEP 440 equality
+//	1.2 - 2.3                         npm hyphen ranges
+//	18 || 20                          any of multiple ranges
+//
+// Partial versions in > and <= are read with npm and Cargo semantics. Use ParseConstraintWithDialect for PEP 440 and Gemfile ranges
+func ParseConstraint(constraint string) (*Constraint, error) {
+	return ParseConstraintWithDialect(constraint, DialectNpm)
+}
+
+// ParseConstraintWithDialect parses a version range like ParseConstraint, comparing partial versions the way the dialect does
+func ParseConstraintWithDialect(constraint string, dialect Dialect) (*Constraint, error) {
+	c := &Constraint{original: strings.TrimSpace(constraint)}
+
+	for _, set := range strings.Split(c.original, "||") {
+		comparators, err := parseComparatorSet(set, dialect)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", c.original, err)
+		}
+		c.sets = append(c.sets, comparators)
+	}
+
+	return c, nil
+}
+
+func (c *Constraint) String() string {
+	return c.original
+}
+
+// IsPrefix is true if the constraint matches every version starting with a prefix (e.g. "22" or "3.11.x").
+// These are resolved directly by mise instead of by listing every version
+func (c *Constraint) IsPrefix() (string, bool) {
+	if len(c.sets) != 1 || len(c.sets[0]) > 1 {
+		return "", false
+	}
+
+	if len(c.sets[0]) == 0 {
+		return "latest", true
+	}
+
+	comp := c.sets[0][0]
+	if comp.op != "=" || comp.version.Prerelease != "" {
+		return "", false
+	}
+
+	parts := make([]string, comp.specified)
+	for i := range parts {
+		parts[i] = strconv.Itoa(comp.version.Parts[i])
+	}
+
+	return strings.Join(parts, "."), true
+}
+
+// Check returns true if the version satisfies the constraint.
+// Prereleases only satisfy a range that mentions a prerelease
+func (c *Constraint) Check(version Version) bool {
+	for _, set := range c.sets {
+		if version.Prerelease != "" && !slices.ContainsFunc(set, func(comp comparator) bool { return comp.version.Prerelease != "" }) {
+			continue
+		}
+
+		if !slices.ContainsFunc(set, func(comp comparator) bool { return !comp.check(version) }) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Latest returns the highest version that satisfies the constraint and the filter
+func (c *Constraint) Latest(versions []string, filter func(version string) bool) (string, bool) {
+	var latest *Version
+
+	for _, v := range versions {
+		version, err := ParseVersion(v)
+		if err != nil || !c.Check(version) || (filter != nil && !filter(v)) {
+			continue
+		}
+
+		if latest == nil || version.Compare(*latest) > 0 {
+			latest = &version
+		}
+	}
+
+	if latest == nil {
+		return "", false
+	}
+
+	return latest.Original, true
+}
+
+func parseComparatorSet(set string, dialect Dialect) ([]comparator, error) {
+	fields := strings.Fields(strings.ReplaceAll(set, ",", " "))
+
+	// Hyphen ranges are inclusive of both ends
+	if len(fields) == 3 && fields[1] == "-" {
+		fields = []string{">=" + fields[0], "<=" + fields[2]}
+	}
+
+	comparators := []comparator{}
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		// Operators can be separated from the version with a space (e.g. ">= 22" or "~> 3.2")
+		if slices.Contains(constraintOperators, field) && i+1 < len(fields) {
+			field += fields[i+1]
+			i++
+		}
+
+		parsed, err := parseComparator(field, dialect)
+		if err != nil {
+			return nil, err
+		}
+		comparators = append(comparators, parsed...)
+	}
+
+	return comparators, nil
+}
+
+// parseComparator expands a single operator and version into the primitive comparators =, !=, >=, >, <=, and <
+func parseComparator(field string, dialect Dialect) ([]comparator, error) {
+	op := ""
+	for _, candidate := range constraintOperators {
+		if strings.HasPrefix(field, candidate) {
+			op = candidate
+			break
+		}
+	}
+
+	raw := strings.TrimSpace(strings.TrimPrefix(field, op))
+	if raw == "" {
+		return nil, fmt.Errorf("missing version in %q", field)
+	}
+
+	if raw == "*" || raw == "latest" || strings.EqualFold(raw, "x") {
+		if op == "" || op == "=" || op == "==" || op == ">=" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("invalid comparator %q", field)
+	}
+
+	version, specified, err := parsePartialVersion(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	lower := comparator{op: ">=", version: version, specified: specified}
+
+	switch op {
+	case "", "=", "==", "===":
+		return []comparator{{op: "=", version: version, specified: specified}}, nil
+	case "!=":
+		return []comparator{{op: "!=", version: version, specified: specified}}, nil
+	case ">=", "<":
+		return []comparator{{op: op, version: version, specified: specified}}, nil
+	case ">", "<=":
+		if version.Prerelease != "" || dialect != DialectNpm {
+			return []comparator{{op: op, version: version, specified: specified}}, nil
+		}
+
+		// In npm and Cargo >22 excludes and <=22 includes every 22.x version
+		if op == ">" {
+			return []comparator{{op: ">=", version: bump(version, specified-1)}}, nil
+		}
+		return []comparator{{op: "<", version: bump(version, specified-1)}}, nil
+	case "^":
+		// Bump the first non-zero part, e.g. ^1.2.3 is <2.0.0 and ^0.2.3 is <0.3.0
+		index := 0
+		for index < specified-1 && version.Parts[index] == 0 {
+			index++
+		}
+		return []comparator{lower, {op: "<", version: bump(version, index)}}, nil
+	case "~":
+		// Allow patch updates if a minor version is specified, otherwise minor updates
+		return []comparator{lower, {op: "<", version: bump(version, min(1, specified-1))}}, nil
+	case "~>", "~=":
+		// Allow updates to the last specified part, e.g. ~> 3.2 is <4 and ~> 3.2.1 is <3.3
+		return []comparator{lower, {op: "<", version: bump(version, max(0, specified-2))}}, nil
+	}
+
+	return nil, fmt.Errorf("invalid comparator %q", field)
+}
+
+// parsePartialVersion parses a version that can end in wildcards (e.g. "3.11.*" or "14.x") and returns the number of specified parts
+func parsePartialVersion(raw string) (Version, int, error) {
+	parts := strings.Split(raw, ".")
+	for i, part := range parts {
+		if part == "*" || strings.EqualFold(part, "x") {
+			parts = parts[:i]
+			break
+		}
+	}
+
+	if len(parts) == 0 {
+		return Version{}, 0, fmt.Errorf("invalid version %q", raw)
+	}
+
+	version, err := ParseVersion(strings.Join(parts, "."))
+	if err != nil {
+		return Version{}, 0, err
+	}
+
+	return version, len(version.Parts), nil
+}
+
+func (c comparator) check(version Version) bool {
+	switch c.op {
+	case "=":
+		return c.matchesPrefix(version)
+	case "!=":
+		return !c.matchesPrefix(version)
+	case ">=":
+		return version.Compare(c.version) >= 0
+	case ">":
+		return version.Compare(c.version) > 0
+	case "<=":
+		return version.Compare(c.version) <= 0
+	case "<":
+		return version.Compare(c.version) < 0
+	}
+
+	return false
+}
+
+// matchesPrefix checks the specified parts of the comparator and the prerelease if one was given
+func (c comparator) matchesPrefix(version Version) bool {
+	for i := 0; i < c.specified; i++ {
+		if part(version.Parts, i) != c.version.Parts[i] {
+			return false
+		}
+	}
+
+	if c.version.Prerelease != "" {
+		return version.Prerelease == c.version.Prerelease && len(version.Parts) <= c.specified
+	}
+
+	return true
+}
+
+// bump increments the part at the index and drops the parts after it
+func bump(version Version, index int) Version {
+	parts := slices.Clone(version.Parts[:index+1])
+	parts[index]++
+
+	strs := make([]string, len(parts))
+	for i, p := range parts {
+		strs[i] = strconv.Itoa(p)
+	}
+
+	return Version{Parts: parts, Original: strings.Join(strs, ".")}
+}
+
+func part(parts []int, index int) int {
+	if index < len(parts) {
+		return parts[index]
+	}
+	return 0
+}
@@ -0,0 +1,218 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input      string
+		parts      []int
+		prerelease string
+	}{
+		{"22.12.0", []int{22, 12, 0}, ""},
+		{"v14.3", []int{14, 3}, ""},
+		{"8.0.100", []int{8, 0, 100}, ""},
+		{"3.14.0rc1", []int{3, 14, 0}, "rc1"},
+		{"23.0.0-rc.1", []int{23, 0, 0}, "rc.1"},
+		{"1.2.3+build.5", []int{1, 2, 3}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			version, err := ParseVersion(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, tt.parts, version.Parts)
+			require.Equal(t, tt.prerelease, version.Prerelease)
+		})
+	}
+
+	_, err := ParseVersion("temurin-21")
+	require.Error(t, err)
+}
+
+func TestCompareVersions(t *testing.T) {
+	compare := func(a, b string) int {
+		va, err := ParseVersion(a)
+		require.NoError(t, err)
+		vb, err := ParseVersion(b)
+		require.NoError(t, err)
+		return va.Compare(vb)
+	}
+
+	require.Equal(t, -1, compare("3.9.1", "3.10.0"))
+	require.Equal(t, 0, compare("22", "22.0.0"))
+	require.Equal(t, 1, compare("8.0.100", "8.0.99"))
+	require.Equal(t, -1, compare("3.14.0rc1", "3.14.0"))
+	require.Equal(t, -1, compare("3.14.0rc1", "3.14.0rc2"))
+	require.Equal(t, 1, compare("3.14.0rc10", "3.14.0rc9"))
+	require.Equal(t, 1, compare("1.0.0-rc.10", "1.0.0-rc.9"))
+	require.Equal(t, -1, compare("1.0.0-alpha", "1.0.0-alpha.1"))
+	require.Equal(t, -1, compare("1.0.0-alpha.1", "1.0.0-alpha.beta"))
+	require.Equal(t, -1, compare("1.0.0-beta.11", "1.0.0-rc.1"))
+}
+
+func TestConstraintCheck(t *testing.T) {
+	tests := []struct {
+		constraint string
+		matches    []string
+		rejects    []string
+	}{
+		// Prefixes
+		{"22", []string{"22.0.0", "22.12.0"}, []string{"21.9.0", "23.0.0", "220.0.0"}},
+		{"14.x", []string{"14.0.0", "14.21.3"}, []string{"15.0.0"}},
+		{"3.11.*", []string{"3.11.0", "3.11.9"}, []string{"3.1.0", "3.12.0"}},
+		{"*", []string{"1.0.0", "22.12.0"}, []string{"23.0.0-rc.1"}},
+		{"", []string{"1.0.0"}, []string{}},
+
+		// npm
+		{">=18 <20", []string{"18.0.0", "19.9.9"}, []string{"17.9.0", "20.0.0", "20.1.0"}},
+		{">= 20.4", []string{"20.4.0", "22.0.0"}, []string{"20.3.9"}},
+		{">18", []string{"19.0.0"}, []string{"18.20.4"}},
+		{"<=18", []string{"18.20.4", "16.0.0"}, []string{"19.0.0"}},
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"1.2.2", "2.0.0"}},
+		{"^0.2.3", []string{"0.2.9"}, []string{"0.3.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~1.2.3", []string{"1.2.9"}, []string{"1.3.0"}},
+		{"~1", []string{"1.9.0"}, []string{"2.0.0"}},
+		{"1.2 - 2.3", []string{"1.2.0", "2.3.9"}, []string{"1.1.9", "2.4.0"}},
+		{"18 || 20", []string{"18.1.0", "20.2.0"}, []string{"19.0.0", "22.0.0"}},
+		{"^18 || >=22 <23", []string{"18.9.0", "22.1.0"}, []string{"20.0.0", "23.0.0"}},
+
+		// PEP 440
+		{">=3.9,<3.13", []string{"3.9.0", "3.12.9"}, []string{"3.8.9", "3.13.0"}},
+		{"~=3.11", []string{"3.11.0", "3.12.0"}, []string{"3.10.9", "4.0.0"}},
+		{"~=3.11.4", []string{"3.11.9"}, []string{"3.11.3", "3.12.0"}},
+		{"==3.11.*", []string{"3.11.2"}, []string{"3.12.0"}},
+		{">=3.9, !=3.12.1", []string{"3.12.0", "3.12.2"}, []string{"3.12.1"}},
+		{"!=3.12.*, >=3.11", []string{"3.11.9", "3.13.0"}, []string{"3.12.4"}},
+		{"===3.11.4", []string{"3.11.4"}, []string{"3.11.5"}},
+
+		// Gemfile
+		{"~> 3.2", []string{"3.2.0", "3.4.1"}, []string{"3.1.9", "4.0.0"}},
+		{"~> 3.2.1", []string{"3.2.5"}, []string{"3.2.0", "3.3.0"}},
+
+		// Cargo
+		{">=1.70, <1.80", []string{"1.79.0"}, []string{"1.80.0"}},
+		{"=1.84.0", []string{"1.84.0"}, []string{"1.84.1"}},
+
+		// Prereleases
+		{">=3.14.0rc1", []string{"3.14.0rc2", "3.14.0"}, []string{"3.14.0a1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			constraint, err := ParseConstraint(tt.constraint)
+			require.NoError(t, err)
+
+			for _, v := range tt.matches {
+				version, err := ParseVersion(v)
+				require.NoError(t, err)
+				require.True(t, constraint.Check(version), "%s should satisfy %s", v, tt.constraint)
+			}
+
+			for _, v := range tt.rejects {
+				version, err := ParseVersion(v)
+				require.NoError(t, err)
+				require.False(t, constraint.Check(version), "%s should not satisfy %s", v, tt.constraint)
+			}
+		})
+	}
+}
+
+func TestConstraintDialects(t *testing.T) {
+	tests := []struct {
+		constraint string
+		dialect    Dialect
+		matches    []string
+		rejects    []string
+	}{
+		{"<=3.11", DialectNpm, []string{"3.11.0", "3.11.4"}, []string{"3.12.0"}},
+		{"<=3.11", DialectPEP440, []string{"3.10.9", "3.11.0"}, []string{"3.11.4", "3.12.0"}},
+		{">3.11", DialectPEP440, []string{"3.11.4", "3.12.0"}, []string{"3.11.0"}},
+		{"<=3.2", DialectGem, []string{"3.2.0"}, []string{"3.2.1"}},
+		{">1.70", DialectNpm, []string{"1.71.0"}, []string{"1.70.1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.dialect)+" "+tt.constraint, func(t *testing.T) {
+			constraint, err := ParseConstraintWithDialect(tt.constraint, tt.dialect)
+			require.NoError(t, err)
+
+			for _, v := range tt.matches {
+				version, err := ParseVersion(v)
+				require.NoError(t, err)
+				require.True(t, constraint.Check(version), "%s should satisfy %s", v, tt.constraint)
+			}
+
+			for _, v := range tt.rejects {
+				version, err := ParseVersion(v)
+				require.NoError(t, err)
+				require.False(t, constraint.Check(version), "%s should not satisfy %s", v, tt.constraint)
+			}
+		})
+	}
+
+	require.Equal(t, DialectPEP440, DialectOf("python"))
+	require.Equal(t, DialectGem, DialectOf("ruby"))
+	require.Equal(t, DialectNpm, DialectOf("node"))
+}
+
+func TestParseConstraintInvalid(t *testing.T) {
+	for _, constraint := range []string{"lts", "temurin-21", ">=", "^latest"} {
+		_, err := ParseConstraint(constraint)
+		require.Error(t, err, constraint)
+	}
+}
+
+func TestConstraintIsPrefix(t *testing.T) {
+	tests := []struct {
+		constraint string
+		prefix     string
+		isPrefix   bool
+	}{
+		{"22", "22", true},
+		{"v14.3.2", "14.3.2", true},
+		{"14.x", "14", true},
+		{"==3.11.*", "3.11", true},
+		{"*", "latest", true},
+		{"latest", "latest", true},
+		{"^22", "", false},
+		{">=18 <20", "", false},
+		{"18 || 20", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			constraint, err := ParseConstraint(tt.constraint)
+			require.NoError(t, err)
+
+			prefix, ok := constraint.IsPrefix()
+			require.Equal(t, tt.isPrefix, ok)
+			require.Equal(t, tt.prefix, prefix)
+		})
+	}
+}
+
+func TestConstraintLatest(t *testing.T) {
+	versions := []string{"18.20.4", "20.18.2", "19.9.0", "22.12.0", "temurin-21", "23.0.0-rc.1"}
+
+	constraint, err := ParseConstraint(">=18 <20")
+	require.NoError(t, err)
+
+	latest, ok := constraint.Latest(versions, nil)
+	require.True(t, ok)
+	require.Equal(t, "19.9.0", latest)
+
+	latest, ok = constraint.Latest(versions, func(version string) bool { return version != "19.9.0" })
+	require.True(t, ok)
+	require.Equal(t, "18.20.4", latest)
+
+	constraint, err = ParseConstraint(">=24")
+	require.NoError(t, err)
+
+	_, ok = constraint.Latest(versions, nil)
+	require.False(t, ok)
+}
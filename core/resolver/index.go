@@ -0,0 +1,135 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/railwayapp/railpack/core/mise"
+	"github.com/railwayapp/railpack/internal/utils"
+)
+
+const (
+	VersionIndexFileName = "railpack-versions.json"
+)
+
+var prereleaseRegex = regexp.MustCompile(`(?i)(rc|alpha|beta|dev|preview)`)
+
+// VersionSource lists the versions available for a package
+type VersionSource interface {
+	GetLatestVersion(ctx context.Context, pkg, version string) (string, error)
+	GetAllVersions(ctx context.Context, pkg, version string) ([]string, error)
+	ListVersions(ctx context.Context, pkg string) ([]string, error)
+}
+
+// VersionIndex is a local list of every available version of a package, used to resolve versions without network access
+type VersionIndex struct {
+	// Versions of each package, ordered from oldest to newest
+	Packages map[string][]string `json:"packages"`
+}
+
+func NewVersionIndex() *VersionIndex {
+	return &VersionIndex{Packages: map[string][]string{}}
+}
+
+// ReadVersionIndex reads a version index from a file, or from the index file in a directory
+func ReadVersionIndex(path string) (*VersionIndex, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, VersionIndexFileName)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version index: %w", err)
+	}
+
+	index := NewVersionIndex()
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse version index %s: %w", path, err)
+	}
+
+	if index.Packages == nil {
+		index.Packages = map[string][]string{}
+	}
+
+	return index, nil
+}
+
+func (i *VersionIndex) Write(path string) error {
+	data, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// GetLatestVersion returns the newest version matching the fuzzy version, the same as `mise latest`
+func (i *VersionIndex) GetLatestVersion(ctx context.Context, pkg, version string) (string, error) {
+	versions, err := i.GetAllVersions(ctx, pkg, version)
+	if err != nil {
+		return "", err
+	}
+
+	return versions[len(versions)-1], nil
+}
+
+// GetAllVersions returns every version matching the fuzzy version, the same as `mise ls-remote`
+func (i *VersionIndex) GetAllVersions(_ context.Context, pkg, version string) ([]string, error) {
+	available, ok := i.Packages[pkg]
+	if !ok {
+		return nil, fmt.Errorf("package `%s` not found in the version index. Run `railpack versions sync %s` to add it", pkg, pkg)
+	}
+
+	// Try with extracted semver version first, then the original version
+	versions := matchingVersions(available, utils.ExtractSemverVersion(version))
+	if len(versions) == 0 {
+		versions = matchingVersions(available, version)
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf(mise.ErrMiseGetLatestVersion, version, pkg)
+	}
+
+	return versions, nil
+}
+
+// ListVersions returns every version of the package in the index
+func (i *VersionIndex) ListVersions(_ context.Context, pkg string) ([]string, error) {
+	versions, ok := i.Packages[pkg]
+	if !ok || len(versions) == 0 {
+		return nil, fmt.Errorf("package `%s` not found in the version index. Run `railpack versions sync %s` to add it", pkg, pkg)
+	}
+
+	return versions, nil
+}
+
+// matchingVersions returns the versions that the fuzzy version is a prefix of (e.g. "3.1" matches "3.1.4" but not "3.10.0")
+func matchingVersions(available []string, version string) []string {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return nil
+	}
+
+	var versions []string
+	for _, v := range available {
+		// Prereleases are only used when they are requested explicitly
+		if isPrerelease(v) && !isPrerelease(version) {
+			continue
+		}
+
+		if version == "latest" || v == version || strings.HasPrefix(v, version+".") || strings.HasPrefix(v, version+"-") {
+			versions = append(versions, v)
+		}
+	}
+
+	return versions
+}
+
+func isPrerelease(version string) bool {
+	return prereleaseRegex.MatchString(version)
+}
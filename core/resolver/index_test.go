@@ -0,0 +1,97 @@
+package resolver
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testVersionIndex() *VersionIndex {
+	return &VersionIndex{Packages: map[string][]string{
+		"node":   {"20.18.2", "22.0.0", "22.11.0", "22.12.0", "23.0.0-rc.1"},
+		"python": {"3.1.4", "3.10.0", "3.13.1", "3.14.0rc1"},
+	}}
+}
+
+func TestVersionIndexGetLatestVersion(t *testing.T) {
+	index := testVersionIndex()
+
+	tests := []struct {
+		pkg     string
+		version string
+		want    string
+		wantErr bool
+	}{
+		{pkg: "node", version: "22", want: "22.12.0"},
+		{pkg: "node", version: "22.11", want: "22.11.0"},
+		{pkg: "node", version: "latest", want: "22.12.0"},
+		{pkg: "node", version: ">=20", want: "20.18.2"},
+		{pkg: "python", version: "3.1", want: "3.1.4"},
+		{pkg: "python", version: "3", want: "3.13.1"},
+		{pkg: "node", version: "999", wantErr: true},
+		{pkg: "ruby", version: "3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pkg+"@"+tt.version, func(t *testing.T) {
+			got, err := index.GetLatestVersion(context.Background(), tt.pkg, tt.version)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestVersionIndexGetAllVersions(t *testing.T) {
+	versions, err := testVersionIndex().GetAllVersions(context.Background(), "node", "22")
+	require.NoError(t, err)
+	require.Equal(t, []string{"22.0.0", "22.11.0", "22.12.0"}, versions)
+}
+
+func TestReadVersionIndex(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, testVersionIndex().Write(filepath.Join(dir, VersionIndexFileName)))
+
+	fromDir, err := ReadVersionIndex(dir)
+	require.NoError(t, err)
+	require.Equal(t, testVersionIndex(), fromDir)
+
+	fromFile, err := ReadVersionIndex(filepath.Join(dir, VersionIndexFileName))
+	require.NoError(t, err)
+	require.Equal(t, testVersionIndex(), fromFile)
+
+	_, err = ReadVersionIndex(filepath.Join(dir, "missing.json"))
+	require.Error(t, err)
+}
+
+func TestOfflineResolver(t *testing.T) {
+	resolver := NewOfflineResolver(testVersionIndex())
+	require.True(t, resolver.IsOffline())
+
+	node := resolver.Default("node", "22")
+	resolver.SetVersionAvailable(resolver.Default("python", "3"), func(version string) bool {
+		return version != "3.13.1"
+	})
+	resolver.Version(node, "^22.11", "package.json")
+
+	resolved, err := resolver.ResolvePackages(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "22.12.0", *resolved["node"].ResolvedVersion)
+	require.Equal(t, "3.10.0", *resolved["python"].ResolvedVersion)
+}
+
+func TestResolvePackagesCancelled(t *testing.T) {
+	resolver := NewOfflineResolver(testVersionIndex())
+	resolver.Default("node", "22")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := resolver.ResolvePackages(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,83 @@
+package resolver
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+const (
+	LockFileName = "railpack.lock"
+)
+
+// LockFile pins the resolved version of every package so that builds are reproducible
+type LockFile struct {
+	Packages map[string]*LockedPackage `json:"packages"`
+}
+
+type LockedPackage struct {
+	// The version that was requested when the package was locked (e.g. "22" or "^3.2")
+	Requested string `json:"requested"`
+
+	// The fully qualified version the request resolved to
+	Resolved string `json:"resolved"`
+}
+
+func NewLockFile(resolvedPackages map[string]*ResolvedPackage) *LockFile {
+	lockFile := &LockFile{Packages: map[string]*LockedPackage{}}
+
+	for name, pkg := range resolvedPackages {
+		if pkg.RequestedVersion == nil || pkg.ResolvedVersion == nil {
+			continue
+		}
+
+		lockFile.Packages[name] = &LockedPackage{
+			Requested: *pkg.RequestedVersion,
+			Resolved:  *pkg.ResolvedVersion,
+		}
+	}
+
+	return lockFile
+}
+
+// Get returns the locked version of a package if it was locked for the same requested version
+func (l *LockFile) Get(name, requested string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+
+	locked, ok := l.Packages[name]
+	if !ok || locked.Requested != requested {
+		return "", false
+	}
+
+	return locked.Resolved, true
+}
+
+// Diff returns a description of every difference between the lock file and the resolved packages
+func (l *LockFile) Diff(resolvedPackages map[string]*ResolvedPackage) []string {
+	current := NewLockFile(resolvedPackages)
+	differences := []string{}
+
+	for _, name := range slices.Sorted(maps.Keys(current.Packages)) {
+		pkg := current.Packages[name]
+		locked, ok := l.Packages[name]
+
+		switch {
+		case !ok:
+			differences = append(differences, fmt.Sprintf("%s %s is not locked", name, pkg.Resolved))
+		case locked.Requested != pkg.Requested:
+			differences = append(differences, fmt.Sprintf("%s is locked for version %s but %s was requested", name, locked.Requested, pkg.Requested))
+		case locked.Resolved != pkg.Resolved:
+			differences = append(differences, fmt.Sprintf("%s is locked to %s but resolved to %s", name, locked.Resolved, pkg.Resolved))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(l.Packages)) {
+		if _, ok := current.Packages[name]; !ok {
+			differences = append(differences, fmt.Sprintf("%s is locked but no longer used", name))
+		}
+	}
+
+	return differences
+}
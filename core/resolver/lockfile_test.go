@@ -0,0 +1,92 @@
+package resolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func resolvedPackage(name, requested, resolved string) *ResolvedPackage {
+	return &ResolvedPackage{Name: name, RequestedVersion: &requested, ResolvedVersion: &resolved, Source: DefaultSource}
+}
+
+func TestNewLockFile(t *testing.T) {
+	lockFile := NewLockFile(map[string]*ResolvedPackage{
+		"node":   resolvedPackage("node", "22", "22.11.0"),
+		"python": {Name: "python", Source: DefaultSource},
+	})
+
+	require.Equal(t, map[string]*LockedPackage{
+		"node": {Requested: "22", Resolved: "22.11.0"},
+	}, lockFile.Packages)
+}
+
+func TestLockFileGet(t *testing.T) {
+	lockFile := &LockFile{Packages: map[string]*LockedPackage{
+		"node": {Requested: "22", Resolved: "22.11.0"},
+	}}
+
+	version, ok := lockFile.Get("node", "22")
+	require.True(t, ok)
+	require.Equal(t, "22.11.0", version)
+
+	// The requested version changed, so the lock no longer applies
+	_, ok = lockFile.Get("node", "23")
+	require.False(t, ok)
+
+	_, ok = lockFile.Get("bun", "latest")
+	require.False(t, ok)
+
+	var missing *LockFile
+	_, ok = missing.Get("node", "22")
+	require.False(t, ok)
+}
+
+func TestLockFileDiff(t *testing.T) {
+	lockFile := &LockFile{Packages: map[string]*LockedPackage{
+		"node":   {Requested: "22", Resolved: "22.11.0"},
+		"python": {Requested: "3.13", Resolved: "3.13.1"},
+		"ruby":   {Requested: "3.3", Resolved: "3.3.6"},
+		"go":     {Requested: "1.23", Resolved: "1.23.4"},
+	}}
+
+	require.Empty(t, lockFile.Diff(map[string]*ResolvedPackage{
+		"node":   resolvedPackage("node", "22", "22.11.0"),
+		"python": resolvedPackage("python", "3.13", "3.13.1"),
+		"ruby":   resolvedPackage("ruby", "3.3", "3.3.6"),
+		"go":     resolvedPackage("go", "1.23", "1.23.4"),
+	}))
+
+	require.Equal(t, []string{
+		"bun 1.1.38 is not locked",
+		"node is locked to 22.11.0 but resolved to 22.12.0",
+		"python is locked for version 3.13 but 3.12 was requested",
+		"go is locked but no longer used",
+	}, lockFile.Diff(map[string]*ResolvedPackage{
+		"bun":    resolvedPackage("bun", "latest", "1.1.38"),
+		"node":   resolvedPackage("node", "22", "22.12.0"),
+		"python": resolvedPackage("python", "3.12", "3.12.8"),
+		"ruby":   resolvedPackage("ruby", "3.3", "3.3.6"),
+	}))
+}
+
+func TestResolvePackagesWithLockFile(t *testing.T) {
+	// Locked packages are never looked up with mise
+	resolver := &Resolver{
+		packages:         map[string]*RequestedPackage{},
+		previousVersions: map[string]string{},
+	}
+	resolver.SetLockFile(&LockFile{Packages: map[string]*LockedPackage{
+		"node": {Requested: "22", Resolved: "22.11.0"},
+	}})
+
+	node := resolver.Default("node", "20")
+	resolver.Version(node, "22", "package.json engines")
+
+	resolved, err := resolver.ResolvePackages(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "22.11.0", *resolved["node"].ResolvedVersion)
+	require.Equal(t, "22", *resolved["node"].RequestedVersion)
+	require.Equal(t, "package.json engines", resolved["node"].Source)
+}
@@ -1,11 +1,14 @@
 package resolver
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/charmbracelet/log"
 	"github.com/railwayapp/railpack/core/mise"
+	"github.com/railwayapp/railpack/internal/utils"
 )
 
 const (
@@ -13,9 +16,10 @@ const (
 )
 
 type Resolver struct {
-	mise             *mise.Mise
+	versions         VersionSource
 	packages         map[string]*RequestedPackage
 	previousVersions map[string]string
+	lockFile         *LockFile
 }
 
 type RequestedPackage struct {
@@ -56,55 +60,94 @@ func (p *RequestedPackage) SetVersion(version, source string) *RequestedPackage
 	return p
 }
 
-func NewResolver(miseDir string) (*Resolver, error) {
-	mise, err := mise.New(miseDir)
+var (
+	miseVersionsMu sync.Mutex
+
+	// Versions resolved with mise are shared by every resolver in the process that uses the same mise directory
+	miseVersions = map[string]*VersionCache{}
+)
+
+// NewResolver creates a resolver for a single plan that resolves versions with mise.
+// The resolver is not safe for concurrent use, but the versions it gets from mise are cached for every resolver
+func NewResolver(ctx context.Context, miseDir string) (*Resolver, error) {
+	versions, err := sharedMiseVersions(ctx, miseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return newResolver(versions), nil
+}
+
+func sharedMiseVersions(ctx context.Context, miseDir string) (*VersionCache, error) {
+	miseVersionsMu.Lock()
+	defer miseVersionsMu.Unlock()
+
+	if versions, ok := miseVersions[miseDir]; ok {
+		return versions, nil
+	}
+
+	mise, err := mise.New(ctx, miseDir)
 	if err != nil {
 		return nil, err
 	}
 
+	versions := NewVersionCache(mise, DefaultVersionCacheTTL)
+	miseVersions[miseDir] = versions
+	return versions, nil
+}
+
+// NewOfflineResolver resolves versions from the version index instead of mise, so it does not need network access
+func NewOfflineResolver(index *VersionIndex) *Resolver {
+	return newResolver(index)
+}
+
+func newResolver(versions VersionSource) *Resolver {
 	return &Resolver{
-		mise:             mise,
+		versions:         versions,
 		packages:         make(map[string]*RequestedPackage),
 		previousVersions: make(map[string]string),
-	}, nil
+	}
+}
+
+// IsOffline is true if versions are resolved from a version index
+func (r *Resolver) IsOffline() bool {
+	_, ok := r.versions.(*VersionIndex)
+	return ok
 }
 
-func (r *Resolver) ResolvePackages() (map[string]*ResolvedPackage, error) {
+// ResolvePackages resolves the version of every requested package.
+// Resolving stops with the context error when the context is done
+func (r *Resolver) ResolvePackages(ctx context.Context) (map[string]*ResolvedPackage, error) {
 	resolvedPackages := make(map[string]*ResolvedPackage)
 
 	for name, pkg := range r.packages {
-		fuzzyVersion := resolveToFuzzyVersion(pkg.Version)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 
-		var latestVersion string
+		// Locked versions are used as is so that resolving does not depend on the latest available versions
+		if lockedVersion, ok := r.lockFile.Get(name, pkg.Version); ok {
+			log.Debugf("Using locked package version %s %s", name, lockedVersion)
 
-		// If there is a custom version validator, we get possible versions and pick the latest one that matches
-		if pkg.IsVersionAvailable != nil {
-			versions, err := r.mise.GetAllVersions(name, fuzzyVersion)
-			if err != nil {
-				return nil, err
+			resolvedPackages[name] = &ResolvedPackage{
+				Name:             name,
+				RequestedVersion: &pkg.Version,
+				ResolvedVersion:  &lockedVersion,
+				Source:           pkg.Source,
 			}
+			continue
+		}
 
-			for i := len(versions) - 1; i >= 0; i-- {
-				if pkg.IsVersionAvailable(versions[i]) {
-					latestVersion = versions[i]
-					break
-				}
+		latestVersion, err := r.resolveVersion(ctx, name, pkg)
+		if err != nil {
+			// If we are not installing with Mise, then we don't need to error if we can't resolve the version
+			if !pkg.SkipMiseInstall || pkg.IsVersionAvailable != nil || ctx.Err() != nil {
+				return nil, err
 			}
 
+			latestVersion = utils.ExtractSemverVersion(pkg.Version)
 			if latestVersion == "" {
-				return nil, fmt.Errorf("no version available for %s %s", name, pkg.Version)
-			}
-		} else {
-			// Otherwise, we just get the latest version
-			var err error
-			latestVersion, err = r.mise.GetLatestVersion(name, fuzzyVersion)
-			if err != nil {
-				// If we are not installing with Mise, then we don't need to error if we can't resolve the version
-				if pkg.SkipMiseInstall {
-					latestVersion = fuzzyVersion
-				} else {
-					return nil, err
-				}
+				latestVersion = "latest"
 			}
 		}
 
@@ -123,6 +166,53 @@ func (r *Resolver) ResolvePackages() (map[string]*ResolvedPackage, error) {
 	return resolvedPackages, nil
 }
 
+// resolveVersion finds the highest available version that satisfies the requested version
+func (r *Resolver) resolveVersion(ctx context.Context, name string, pkg *RequestedPackage) (string, error) {
+	constraint, err := ParseConstraintWithDialect(pkg.Version, DialectOf(name))
+	if err != nil {
+		// Versions that are not ranges (e.g. "lts" or "temurin-21") are passed to mise as is
+		log.Debugf("Resolving %s %s with mise: %s", name, pkg.Version, err)
+		return r.resolvePrefix(ctx, name, strings.TrimPrefix(strings.TrimSpace(pkg.Version), "v"), pkg)
+	}
+
+	// Prefixes like "22" or "3.11.x" are resolved the same as `mise latest`
+	if prefix, ok := constraint.IsPrefix(); ok {
+		return r.resolvePrefix(ctx, name, prefix, pkg)
+	}
+
+	versions, err := r.versions.ListVersions(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	latestVersion, ok := constraint.Latest(versions, pkg.IsVersionAvailable)
+	if !ok {
+		return "", fmt.Errorf("no version of %s satisfies %s (from %s)", name, pkg.Version, pkg.Source)
+	}
+
+	return latestVersion, nil
+}
+
+func (r *Resolver) resolvePrefix(ctx context.Context, name, prefix string, pkg *RequestedPackage) (string, error) {
+	// If there is a custom version validator, we get possible versions and pick the latest one that matches
+	if pkg.IsVersionAvailable != nil {
+		versions, err := r.versions.GetAllVersions(ctx, name, prefix)
+		if err != nil {
+			return "", err
+		}
+
+		for i := len(versions) - 1; i >= 0; i-- {
+			if pkg.IsVersionAvailable(versions[i]) {
+				return versions[i], nil
+			}
+		}
+
+		return "", fmt.Errorf("no version available for %s %s", name, pkg.Version)
+	}
+
+	return r.versions.GetLatestVersion(ctx, name, prefix)
+}
+
 func (r *Resolver) Get(name string) *RequestedPackage {
 	return r.packages[name]
 }
@@ -149,6 +239,11 @@ func (r *Resolver) SetPreviousVersion(name, version string) {
 	r.previousVersions[name] = version
 }
 
+// SetLockFile pins packages to the versions in the lock file when they are requested with the same version
+func (r *Resolver) SetLockFile(lockFile *LockFile) {
+	r.lockFile = lockFile
+}
+
 func (r *Resolver) SetVersionAvailable(ref PackageRef, isVersionAvailable func(version string) bool) {
 	r.packages[ref.Name].IsVersionAvailable = isVersionAvailable
 }
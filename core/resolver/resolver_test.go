@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"testing"
 
 	"github.com/railwayapp/railpack/core/mise"
@@ -19,7 +20,7 @@ func TestPackagesWithDefaults(t *testing.T) {
 }
 
 func TestPackageResolver(t *testing.T) {
-	resolver, err := NewResolver(mise.TestInstallDir)
+	resolver, err := NewResolver(context.Background(), mise.TestInstallDir)
 	require.NoError(t, err)
 
 	// Set up Node.js
@@ -46,7 +47,7 @@ func TestPackageResolver(t *testing.T) {
 	})
 
 	// Resolve all packages
-	resolvedPackages, err := resolver.ResolvePackages()
+	resolvedPackages, err := resolver.ResolvePackages(context.Background())
 	require.NoError(t, err)
 	assert.Equal(t, 5, len(resolvedPackages))
 
@@ -80,7 +81,7 @@ func TestPackageResolver(t *testing.T) {
 }
 
 func TestPackageResolverWithPreviousVersions(t *testing.T) {
-	resolver, err := NewResolver(mise.TestInstallDir)
+	resolver, err := NewResolver(context.Background(), mise.TestInstallDir)
 	require.NoError(t, err)
 
 	resolver.SetPreviousVersion("node", "16")
@@ -105,7 +106,7 @@ func TestPackageResolverWithPreviousVersions(t *testing.T) {
 }
 
 func TestResolvingPackagesNotAvailable(t *testing.T) {
-	resolver, err := NewResolver(mise.TestInstallDir)
+	resolver, err := NewResolver(context.Background(), mise.TestInstallDir)
 	require.NoError(t, err)
 
 	node := resolver.Default("node", "18.20")
@@ -113,6 +114,31 @@ func TestResolvingPackagesNotAvailable(t *testing.T) {
 		return version == "100"
 	})
 
-	_, err = resolver.ResolvePackages()
+	_, err = resolver.ResolvePackages(context.Background())
 	require.Error(t, err)
 }
+
+func TestResolveVersionRanges(t *testing.T) {
+	index := &VersionIndex{Packages: map[string][]string{
+		"node":   {"18.20.4", "19.9.0", "20.18.2", "22.12.0"},
+		"python": {"3.11.9", "3.12.1", "3.12.8", "3.13.1"},
+		"ruby":   {"3.2.6", "3.3.6", "3.4.1"},
+	}}
+
+	resolver := NewOfflineResolver(index)
+	resolver.Version(resolver.Default("node", "22"), ">=18 <20", "package.json > engines > node")
+	resolver.Version(resolver.Default("python", "3.13"), ">=3.11,!=3.12.8,<3.13", "pyproject.toml")
+	resolver.Version(resolver.Default("ruby", "3.4"), "~> 3.2.0", "Gemfile")
+
+	resolvedPackages, err := resolver.ResolvePackages(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "19.9.0", *resolvedPackages["node"].ResolvedVersion)
+	assert.Equal(t, "3.12.1", *resolvedPackages["python"].ResolvedVersion)
+	assert.Equal(t, "3.2.6", *resolvedPackages["ruby"].ResolvedVersion)
+
+	resolver = NewOfflineResolver(index)
+	resolver.Version(resolver.Default("node", "22"), "^24", "package.json > engines > node")
+
+	_, err = resolver.ResolvePackages(context.Background())
+	require.EqualError(t, err, "no version of node satisfies ^24 (from package.json > engines > node)")
+}
@@ -0,0 +1,101 @@
+package sbom
+
+import (
+	"time"
+)
+
+// CycloneDX 1.5 document (https://cyclonedx.org/docs/1.5/json/)
+type cycloneDXDocument struct {
+	BOMFormat    string               `json:"bomFormat"`
+	SpecVersion  string               `json:"specVersion"`
+	SerialNumber string               `json:"serialNumber"`
+	Version      int                  `json:"version"`
+	Metadata     cycloneDXMetadata    `json:"metadata"`
+	Components   []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     cycloneDXTools     `json:"tools"`
+	Component cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXTools struct {
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXComponent struct {
+	Type       string              `json:"type"`
+	BOMRef     string              `json:"bom-ref,omitempty"`
+	Name       string              `json:"name"`
+	Version    string              `json:"version,omitempty"`
+	PURL       string              `json:"purl,omitempty"`
+	Properties []cycloneDXProperty `json:"properties,omitempty"`
+	Evidence   *cycloneDXEvidence  `json:"evidence,omitempty"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cycloneDXEvidence struct {
+	Occurrences []cycloneDXOccurrence `json:"occurrences"`
+}
+
+type cycloneDXOccurrence struct {
+	Location string `json:"location"`
+}
+
+var cycloneDXTypes = map[string]string{
+	ComponentTypeRuntime: "application",
+	ComponentTypeApt:     "library",
+	ComponentTypeImage:   "container",
+	ComponentTypeLibrary: "library",
+}
+
+// CycloneDX marshals the SBOM as a CycloneDX 1.5 JSON document
+func (s *SBOM) CycloneDX() ([]byte, error) {
+	doc := cycloneDXDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + s.id().String(),
+		Version:      1,
+		Metadata: cycloneDXMetadata{
+			Timestamp: s.Created.UTC().Format(time.RFC3339),
+			Tools: cycloneDXTools{Components: []cycloneDXComponent{{
+				Type:    "application",
+				Name:    "railpack",
+				Version: s.RailpackVersion,
+			}}},
+			Component: cycloneDXComponent{
+				Type:   "container",
+				BOMRef: s.Name,
+				Name:   s.Name,
+			},
+		},
+		Components: []cycloneDXComponent{},
+	}
+
+	for _, component := range s.Components {
+		c := cycloneDXComponent{
+			Type:    cycloneDXTypes[component.Type],
+			BOMRef:  component.PURL,
+			Name:    component.Name,
+			Version: component.Version,
+			PURL:    component.PURL,
+			Properties: []cycloneDXProperty{
+				{Name: "railpack:type", Value: component.Type},
+			},
+		}
+		if component.Source != "" {
+			c.Properties = append(c.Properties, cycloneDXProperty{Name: "railpack:source", Value: component.Source})
+		}
+		if component.Location != "" {
+			c.Evidence = &cycloneDXEvidence{Occurrences: []cycloneDXOccurrence{{Location: component.Location}}}
+		}
+		doc.Components = append(doc.Components, c)
+	}
+
+	return marshalJSON(doc)
+}
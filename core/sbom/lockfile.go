@@ -0,0 +1,393 @@
+package sbom
+
+import (
+	"bufio"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	a "github.com/railwayapp/railpack/core/app"
+)
+
+type lockFileReader struct {
+	name string
+	read func(app *a.App, file string) ([]Component, error)
+}
+
+// The lock files that app dependencies are read from
+var lockFileReaders = []lockFileReader{
+	{"package-lock.json", readPackageLock},
+	{"pnpm-lock.yaml", readPnpmLock},
+	{"yarn.lock", readYarnLock},
+	{"poetry.lock", readPythonLock},
+	{"uv.lock", readPythonLock},
+	{"pdm.lock", readPythonLock},
+	{"Cargo.lock", readCargoLock},
+	{"Gemfile.lock", readGemfileLock},
+	{"composer.lock", readComposerLock},
+	{"mix.lock", readMixLock},
+	{"go.mod", readGoMod},
+}
+
+func npmComponent(name, version string) Component {
+	return Component{
+		Name:    name,
+		Version: version,
+		PURL:    "pkg:npm/" + purlName(name) + "@" + purlEscape(version),
+	}
+}
+
+// purlName escapes each part of a namespaced name (e.g. @types/node is %40types/node)
+func purlName(name string) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		parts[i] = purlEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// purlEscape escapes a package URL segment. @ separates the version so it is escaped as well
+func purlEscape(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), "@", "%40")
+}
+
+type packageLock struct {
+	Packages map[string]struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+		Dev     bool   `json:"dev"`
+		Link    bool   `json:"link"`
+	} `json:"packages"`
+	Dependencies map[string]packageLockV1Dependency `json:"dependencies"`
+}
+
+type packageLockV1Dependency struct {
+	Version      string                             `json:"version"`
+	Dev          bool                               `json:"dev"`
+	Dependencies map[string]packageLockV1Dependency `json:"dependencies"`
+}
+
+func readPackageLock(app *a.App, file string) ([]Component, error) {
+	var lock packageLock
+	if err := app.ReadJSON(file, &lock); err != nil {
+		return nil, err
+	}
+
+	components := []Component{}
+
+	// lockfileVersion 2 and 3 list every package by its path in node_modules
+	for key, pkg := range lock.Packages {
+		i := strings.LastIndex(key, "node_modules/")
+		if i == -1 || pkg.Dev || pkg.Link || pkg.Version == "" {
+			continue
+		}
+
+		name := pkg.Name
+		if name == "" {
+			name = key[i+len("node_modules/"):]
+		}
+		components = append(components, npmComponent(name, pkg.Version))
+	}
+
+	if len(lock.Packages) > 0 {
+		return components, nil
+	}
+
+	// lockfileVersion 1 nests dependencies
+	var walk func(deps map[string]packageLockV1Dependency)
+	walk = func(deps map[string]packageLockV1Dependency) {
+		for name, dep := range deps {
+			if dep.Dev {
+				continue
+			}
+			components = append(components, npmComponent(name, dep.Version))
+			walk(dep.Dependencies)
+		}
+	}
+	walk(lock.Dependencies)
+
+	return components, nil
+}
+
+func readPnpmLock(app *a.App, file string) ([]Component, error) {
+	var lock struct {
+		Packages map[string]interface{} `yaml:"packages"`
+	}
+	if err := app.ReadYAML(file, &lock); err != nil {
+		return nil, err
+	}
+
+	components := []Component{}
+	for key := range lock.Packages {
+		name, version := parsePnpmKey(key)
+		if name == "" || version == "" {
+			continue
+		}
+		components = append(components, npmComponent(name, version))
+	}
+
+	return components, nil
+}
+
+var (
+	pnpmKeyRegex   = regexp.MustCompile(`^/?((?:@[^/@]+/)?[^/@]+)@([^(_]+)`)
+	pnpmV5KeyRegex = regexp.MustCompile(`^/((?:@[^/@]+/)?[^/@]+)/([^/(_]+)`)
+)
+
+// parsePnpmKey parses the packages keys of all pnpm lock file versions:
+// /name/1.0.0_peer@2.0.0 (v5), /name@1.0.0(peer@2.0.0) (v6), and name@1.0.0 (v9)
+func parsePnpmKey(key string) (string, string) {
+	if match := pnpmKeyRegex.FindStringSubmatch(key); match != nil {
+		return match[1], match[2]
+	}
+
+	if match := pnpmV5KeyRegex.FindStringSubmatch(key); match != nil {
+		return match[1], match[2]
+	}
+
+	return "", ""
+}
+
+var (
+	yarnEntryRegex   = regexp.MustCompile(`^"?((?:@[^/@"]+/)?[^@"]+)@`)
+	yarnVersionRegex = regexp.MustCompile(`^\s+version:? "?([^"\s]+)"?`)
+)
+
+func readYarnLock(app *a.App, file string) ([]Component, error) {
+	data, err := app.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	components := []Component{}
+	name := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Entries start at the beginning of the line (e.g. `"ansi-regex@npm:^5.0.1":` or `ansi-regex@^5.0.1:`)
+		if !strings.HasPrefix(line, " ") {
+			name = ""
+			if match := yarnEntryRegex.FindStringSubmatch(line); match != nil && !strings.HasPrefix(line, "__metadata") {
+				name = match[1]
+			}
+			continue
+		}
+
+		if name == "" {
+			continue
+		}
+
+		if match := yarnVersionRegex.FindStringSubmatch(line); match != nil {
+			// Workspace packages are resolved to a local version (e.g. 0.0.0-use.local)
+			if !strings.Contains(match[1], "use.local") {
+				components = append(components, npmComponent(name, match[1]))
+			}
+			name = ""
+		}
+	}
+
+	return components, scanner.Err()
+}
+
+func readPythonLock(app *a.App, file string) ([]Component, error) {
+	var lock struct {
+		Packages []struct {
+			Name    string                 `toml:"name"`
+			Version string                 `toml:"version"`
+			Source  map[string]interface{} `toml:"source"`
+		} `toml:"package"`
+	}
+	if err := app.ReadTOML(file, &lock); err != nil {
+		return nil, err
+	}
+
+	components := []Component{}
+	for _, pkg := range lock.Packages {
+		// Skip the project itself and workspace members
+		if _, ok := pkg.Source["editable"]; ok {
+			continue
+		}
+		if _, ok := pkg.Source["virtual"]; ok {
+			continue
+		}
+
+		components = append(components, Component{
+			Name:    pkg.Name,
+			Version: pkg.Version,
+			PURL:    "pkg:pypi/" + purlEscape(normalizePythonName(pkg.Name)) + "@" + purlEscape(pkg.Version),
+		})
+	}
+
+	return components, nil
+}
+
+var pythonNameSeparatorRegex = regexp.MustCompile(`[-_.]+`)
+
+// normalizePythonName normalizes a Python package name as described in PEP 503
+func normalizePythonName(name string) string {
+	return strings.ToLower(pythonNameSeparatorRegex.ReplaceAllString(name, "-"))
+}
+
+func readCargoLock(app *a.App, file string) ([]Component, error) {
+	var lock struct {
+		Packages []struct {
+			Name    string `toml:"name"`
+			Version string `toml:"version"`
+			Source  string `toml:"source"`
+		} `toml:"package"`
+	}
+	if err := app.ReadTOML(file, &lock); err != nil {
+		return nil, err
+	}
+
+	components := []Component{}
+	for _, pkg := range lock.Packages {
+		// Workspace members do not have a source
+		if pkg.Source == "" {
+			continue
+		}
+
+		components = append(components, Component{
+			Name:    pkg.Name,
+			Version: pkg.Version,
+			PURL:    "pkg:cargo/" + purlEscape(pkg.Name) + "@" + purlEscape(pkg.Version),
+		})
+	}
+
+	return components, nil
+}
+
+var gemSpecRegex = regexp.MustCompile(`^    ([^\s(]+) \(([^)]+)\)$`)
+
+func readGemfileLock(app *a.App, file string) ([]Component, error) {
+	data, err := app.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	components := []Component{}
+	inGems := false
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Gems are listed under the specs of the GEM section. PATH and GIT sections are local or unpublished gems
+		if !strings.HasPrefix(line, " ") {
+			inGems = line == "GEM"
+			continue
+		}
+
+		match := gemSpecRegex.FindStringSubmatch(line)
+		if !inGems || match == nil {
+			continue
+		}
+
+		// Platform specific gems include the platform in the version (e.g. 1.15.0-x86_64-linux)
+		version, platform, _ := strings.Cut(match[2], "-")
+		purl := "pkg:gem/" + purlEscape(match[1]) + "@" + purlEscape(version)
+		if platform != "" {
+			purl += "?platform=" + url.QueryEscape(platform)
+		}
+
+		components = append(components, Component{
+			Name:    match[1],
+			Version: version,
+			PURL:    purl,
+		})
+	}
+
+	return components, scanner.Err()
+}
+
+func readComposerLock(app *a.App, file string) ([]Component, error) {
+	var lock struct {
+		Packages []struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"packages"`
+	}
+	if err := app.ReadJSON(file, &lock); err != nil {
+		return nil, err
+	}
+
+	components := []Component{}
+	for _, pkg := range lock.Packages {
+		components = append(components, Component{
+			Name:    pkg.Name,
+			Version: pkg.Version,
+			PURL:    "pkg:composer/" + purlName(pkg.Name) + "@" + purlEscape(pkg.Version),
+		})
+	}
+
+	return components, nil
+}
+
+var mixDepRegex = regexp.MustCompile(`"([^"]+)": \{:hex, :([^,]+), "([^"]+)"`)
+
+func readMixLock(app *a.App, file string) ([]Component, error) {
+	data, err := app.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	components := []Component{}
+	for _, match := range mixDepRegex.FindAllStringSubmatch(data, -1) {
+		components = append(components, Component{
+			Name:    match[2],
+			Version: match[3],
+			PURL:    "pkg:hex/" + purlEscape(match[2]) + "@" + purlEscape(match[3]),
+		})
+	}
+
+	return components, nil
+}
+
+func readGoMod(app *a.App, file string) ([]Component, error) {
+	data, err := app.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := map[string]string{}
+	inRequire := false
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+
+		switch {
+		case len(fields) == 0:
+			continue
+		case fields[0] == "require" && len(fields) > 1 && fields[1] == "(":
+			inRequire = true
+		case fields[0] == ")":
+			inRequire = false
+		case fields[0] == "require" && len(fields) == 3:
+			modules[fields[1]] = fields[2]
+		case inRequire && len(fields) == 2:
+			modules[fields[0]] = fields[1]
+		}
+	}
+
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	components := []Component{}
+	for _, name := range names {
+		components = append(components, Component{
+			Name:    name,
+			Version: modules[name],
+			PURL:    "pkg:golang/" + name + "@" + purlEscape(modules[name]),
+		})
+	}
+
+	return components, scanner.Err()
+}
@@ -0,0 +1,77 @@
+package sbom
+
+import (
+	"path/filepath"
+	"testing"
+
+	a "github.com/railwayapp/railpack/core/app"
+	"github.com/stretchr/testify/require"
+)
+
+func readExampleLockFile(t *testing.T, example, file string) []Component {
+	app, err := a.NewApp(filepath.Join("..", "..", "examples", example))
+	require.NoError(t, err)
+
+	for _, reader := range lockFileReaders {
+		if reader.name == file {
+			components, err := reader.read(app, file)
+			require.NoError(t, err)
+			return components
+		}
+	}
+
+	t.Fatalf("no reader for %s", file)
+	return nil
+}
+
+func requireComponent(t *testing.T, components []Component, purl string) {
+	for _, component := range components {
+		if component.PURL == purl {
+			return
+		}
+	}
+	t.Errorf("expected component %s", purl)
+}
+
+func TestLockFileReaders(t *testing.T) {
+	tests := []struct {
+		example string
+		file    string
+		purl    string
+	}{
+		{"node-npm", "package-lock.json", "pkg:npm/dayjs@1.11.13"},
+		{"node-yarn-1", "yarn.lock", "pkg:npm/%40types/node@22.17.1"},
+		{"node-yarn-4", "yarn.lock", "pkg:npm/ansi-regex@3.0.1"},
+		{"node-pnpm-workspaces", "pnpm-lock.yaml", "pkg:npm/abbrev@3.0.1"},
+		{"python-uv", "uv.lock", "pkg:pypi/flask@3.1.0"},
+		{"ruby-sinatra", "Gemfile.lock", "pkg:gem/sinatra@2.2.0"},
+		{"go-mod", "go.mod", "pkg:golang/github.com/Code-Hex/Neo-cowsay/v2@v2.0.4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.example, func(t *testing.T) {
+			requireComponent(t, readExampleLockFile(t, tt.example, tt.file), tt.purl)
+		})
+	}
+}
+
+func TestReadCargoLockSkipsWorkspaceMembers(t *testing.T) {
+	for _, component := range readExampleLockFile(t, "rust-multiple-bins", "Cargo.lock") {
+		require.NotEqual(t, "rust-multiple-bins", component.Name)
+	}
+}
+
+func TestParsePnpmKey(t *testing.T) {
+	tests := map[string][2]string{
+		"/express/4.21.1":                 {"express", "4.21.1"},
+		"/@types/node/22.9.0":             {"@types/node", "22.9.0"},
+		"/react-dom@18.3.1(react@18.3.1)": {"react-dom", "18.3.1"},
+		"@types/node@22.9.0":              {"@types/node", "22.9.0"},
+		"/styled-jsx/5.1.1_react@18.2.0":  {"styled-jsx", "5.1.1"},
+	}
+
+	for key, want := range tests {
+		name, version := parsePnpmKey(key)
+		require.Equal(t, want, [2]string{name, version}, key)
+	}
+}
@@ -0,0 +1,324 @@
+// builds a software bill of materials for the image from the build plan
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/railwayapp/railpack/core"
+	a "github.com/railwayapp/railpack/core/app"
+	"github.com/railwayapp/railpack/core/generate"
+	"github.com/railwayapp/railpack/core/plan"
+)
+
+const (
+	FormatSPDX      = "spdx"
+	FormatCycloneDX = "cyclonedx"
+
+	// Where mise installs packages in the image
+	MiseInstallsDir = "/mise/installs"
+)
+
+var Formats = []string{FormatSPDX, FormatCycloneDX}
+
+const (
+	// A package installed with mise (e.g. node, python)
+	ComponentTypeRuntime = "runtime"
+
+	// A Debian package installed with apt
+	ComponentTypeApt = "apt"
+
+	// An image the final image is built from
+	ComponentTypeImage = "image"
+
+	// An app dependency read from a lock file
+	ComponentTypeLibrary = "library"
+)
+
+type Component struct {
+	Name    string
+	Version string
+	Type    string
+
+	// Package URL (https://github.com/package-url/purl-spec)
+	PURL string
+
+	// Where the component is in the image, if known
+	Location string
+
+	// Where the component was found (e.g. the lock file or the version source)
+	Source string
+}
+
+type SBOM struct {
+	// The name of the app the image is built from
+	Name            string
+	RailpackVersion string
+	Created         time.Time
+	Components      []Component
+}
+
+// Generate lists everything railpack knows is installed in the image.
+// This includes the mise packages, apt packages, and base images from the build result, and the app dependencies from lock files.
+// Mise packages and apt packages that are only used during the build are left out
+func Generate(app *a.App, result *core.BuildResult) (*SBOM, error) {
+	if result == nil || !result.Success || result.Plan == nil {
+		return nil, fmt.Errorf("cannot generate an SBOM from a failed build plan")
+	}
+
+	sbom := &SBOM{
+		Name:            filepath.Base(app.Source),
+		RailpackVersion: result.RailpackVersion,
+		Created:         time.Now().UTC(),
+	}
+
+	imageSteps := imageStepNames(result.Plan)
+
+	components := []Component{}
+	components = append(components, imageComponents(result.Plan, imageSteps)...)
+	if hasMiseInstalls(result.Plan, imageSteps) {
+		components = append(components, runtimeComponents(result)...)
+	}
+	components = append(components, aptComponents(result.AptPackages, imageSteps)...)
+
+	for _, lockFile := range lockFileReaders {
+		if !app.HasFile(lockFile.name) {
+			continue
+		}
+
+		libraries, err := lockFile.read(app, lockFile.name)
+		if err != nil {
+			log.Warnf("Skipping %s in SBOM: %s", lockFile.name, err)
+			continue
+		}
+
+		for _, library := range libraries {
+			library.Type = ComponentTypeLibrary
+			library.Source = lockFile.name
+			components = append(components, library)
+		}
+	}
+
+	sbom.Components = dedupeComponents(components)
+
+	return sbom, nil
+}
+
+// Marshal the SBOM as a JSON document in the given format
+func (s *SBOM) Marshal(format string) ([]byte, error) {
+	switch format {
+	case FormatSPDX:
+		return s.SPDX()
+	case FormatCycloneDX:
+		return s.CycloneDX()
+	default:
+		return nil, fmt.Errorf("unknown SBOM format %q. Must be one of: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// marshalJSON indents the document without escaping characters like < and >
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// imageStepNames returns the steps that make up the base of the final image.
+// The first input of a step is its base, so this follows the first inputs from the deploy base
+func imageStepNames(p *plan.BuildPlan) map[string]bool {
+	steps := map[string]*plan.Step{}
+	for i := range p.Steps {
+		steps[p.Steps[i].Name] = &p.Steps[i]
+	}
+
+	names := map[string]bool{}
+	layer := p.Deploy.Base
+	for layer.Step != "" && !names[layer.Step] {
+		names[layer.Step] = true
+
+		step, ok := steps[layer.Step]
+		if !ok || len(step.Inputs) == 0 {
+			break
+		}
+		layer = step.Inputs[0]
+	}
+
+	return names
+}
+
+// imageComponents returns the base image of the final image and any images copied into it
+func imageComponents(p *plan.BuildPlan, imageSteps map[string]bool) []Component {
+	images := []string{}
+	if p.Deploy.Base.Image != "" {
+		images = append(images, p.Deploy.Base.Image)
+	}
+
+	for _, step := range p.Steps {
+		if imageSteps[step.Name] && len(step.Inputs) > 0 && step.Inputs[0].Image != "" {
+			images = append(images, step.Inputs[0].Image)
+		}
+	}
+
+	for _, input := range p.Deploy.Inputs {
+		if input.Image != "" {
+			images = append(images, input.Image)
+		}
+	}
+
+	components := []Component{}
+	for _, image := range images {
+		name, version := splitImageRef(image)
+		components = append(components, Component{
+			Name:    name,
+			Version: version,
+			Type:    ComponentTypeImage,
+			PURL:    imagePURL(image),
+			Source:  "build plan",
+		})
+	}
+
+	return components
+}
+
+// hasMiseInstalls checks if the mise installs directory is copied into the final image.
+// Apps that build a binary (e.g. Go) only use mise packages during the build
+func hasMiseInstalls(p *plan.BuildPlan, imageSteps map[string]bool) bool {
+	if imageSteps[generate.MisePackageStepName] {
+		return true
+	}
+
+	for _, input := range p.Deploy.Inputs {
+		if input.Step == "" {
+			continue
+		}
+
+		for _, include := range input.Include {
+			if include == "/" || include == MiseInstallsDir || strings.HasPrefix(MiseInstallsDir, strings.TrimSuffix(include, "/")+"/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func runtimeComponents(result *core.BuildResult) []Component {
+	components := []Component{}
+	for _, pkg := range result.ResolvedPackages {
+		if pkg.ResolvedVersion == nil {
+			continue
+		}
+
+		components = append(components, Component{
+			Name:     pkg.Name,
+			Version:  *pkg.ResolvedVersion,
+			Type:     ComponentTypeRuntime,
+			PURL:     fmt.Sprintf("pkg:generic/mise/%s@%s", purlEscape(pkg.Name), purlEscape(*pkg.ResolvedVersion)),
+			Location: path.Join(MiseInstallsDir, miseInstallName(pkg.Name), *pkg.ResolvedVersion),
+			Source:   pkg.Source,
+		})
+	}
+
+	return components
+}
+
+// aptComponents returns the apt packages installed in the steps that the final image is built from.
+// Packages installed in other steps are only available during the build
+func aptComponents(aptPackages map[string][]string, imageSteps map[string]bool) []Component {
+	components := []Component{}
+	for step, pkgs := range aptPackages {
+		if !imageSteps[step] {
+			continue
+		}
+
+		for _, pkg := range pkgs {
+			components = append(components, Component{
+				Name:   pkg,
+				Type:   ComponentTypeApt,
+				PURL:   "pkg:deb/debian/" + purlEscape(pkg),
+				Source: step,
+			})
+		}
+	}
+
+	return components
+}
+
+// dedupeComponents removes components with the same package URL and sorts them by type and name
+func dedupeComponents(components []Component) []Component {
+	seen := map[string]bool{}
+	result := []Component{}
+	for _, component := range components {
+		key := component.PURL
+		if key == "" {
+			key = component.Type + "/" + component.Name + "@" + component.Version
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, component)
+	}
+
+	typeOrder := []string{ComponentTypeImage, ComponentTypeRuntime, ComponentTypeApt, ComponentTypeLibrary}
+	slices.SortStableFunc(result, func(x, y Component) int {
+		if c := slices.Index(typeOrder, x.Type) - slices.Index(typeOrder, y.Type); c != 0 {
+			return c
+		}
+		if c := strings.Compare(x.Name, y.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(x.Version, y.Version)
+	})
+
+	return result
+}
+
+// miseInstallName is the directory mise installs a package to (e.g. ubi:owner/repo is installed to ubi-owner-repo)
+func miseInstallName(name string) string {
+	return strings.NewReplacer(":", "-", "/", "-").Replace(name)
+}
+
+// splitImageRef splits an image reference into the name and the tag or digest
+func splitImageRef(image string) (string, string) {
+	if name, digest, ok := strings.Cut(image, "@"); ok {
+		return name, digest
+	}
+
+	// The tag is after the last colon, as long as it is not part of the registry host (e.g. localhost:5000/app)
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+
+	return image, "latest"
+}
+
+// imagePURL returns the package URL of an image (e.g. pkg:docker/railwayapp/railpack-runtime@latest?repository_url=ghcr.io)
+func imagePURL(image string) string {
+	name, version := splitImageRef(image)
+
+	repositoryURL := ""
+	if host, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		repositoryURL = host
+		name = rest
+	}
+
+	purl := "pkg:docker/" + name + "@" + purlEscape(version)
+	if repositoryURL != "" {
+		purl += "?repository_url=" + url.QueryEscape(repositoryURL)
+	}
+	return purl
+}
@@ -0,0 +1,181 @@
+package sbom
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/railwayapp/railpack/core"
+	a "github.com/railwayapp/railpack/core/app"
+	"github.com/railwayapp/railpack/core/generate"
+	"github.com/railwayapp/railpack/core/plan"
+	"github.com/railwayapp/railpack/core/resolver"
+	"github.com/stretchr/testify/require"
+)
+
+func testBuildResult() *core.BuildResult {
+	p := plan.NewBuildPlan()
+
+	aptBuild := plan.NewStep("packages:apt:build")
+	aptBuild.Inputs = []plan.Layer{plan.NewImageLayer(plan.RailpackBuilderImage)}
+	aptRuntime := plan.NewStep("packages:apt:runtime")
+	aptRuntime.Inputs = []plan.Layer{plan.NewImageLayer(plan.RailpackRuntimeImage)}
+	p.Steps = []plan.Step{*aptBuild, *aptRuntime}
+
+	p.Deploy.Base = plan.NewStepLayer("packages:apt:runtime")
+	p.Deploy.Inputs = []plan.Layer{
+		plan.NewStepLayer(generate.MisePackageStepName, plan.NewIncludeFilter([]string{"/mise/shims", "/mise/installs"})),
+	}
+
+	nodeVersion := "22.11.0"
+	return &core.BuildResult{
+		RailpackVersion: "1.0.0",
+		Plan:            p,
+		ResolvedPackages: map[string]*resolver.ResolvedPackage{
+			"node": {Name: "node", ResolvedVersion: &nodeVersion, Source: "package.json > engines > node"},
+		},
+		AptPackages: map[string][]string{
+			"packages:apt:build":   {"build-essential"},
+			"packages:apt:runtime": {"libpq5"},
+		},
+		Success: true,
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package-lock.json"), []byte(`{
+		"lockfileVersion": 3,
+		"packages": {
+			"": {"name": "app"},
+			"node_modules/@types/node": {"version": "22.9.0"},
+			"node_modules/express": {"version": "4.21.1"},
+			"node_modules/typescript": {"version": "5.6.3", "dev": true}
+		}
+	}`), 0644))
+
+	app, err := a.NewApp(dir)
+	require.NoError(t, err)
+
+	sbom, err := Generate(app, testBuildResult())
+	require.NoError(t, err)
+	require.Equal(t, filepath.Base(dir), sbom.Name)
+
+	require.Equal(t, []Component{
+		{Name: "ghcr.io/railwayapp/railpack-runtime", Version: "latest", Type: ComponentTypeImage, PURL: "pkg:docker/railwayapp/railpack-runtime@latest?repository_url=ghcr.io", Source: "build plan"},
+		{Name: "node", Version: "22.11.0", Type: ComponentTypeRuntime, PURL: "pkg:generic/mise/node@22.11.0", Location: "/mise/installs/node/22.11.0", Source: "package.json > engines > node"},
+		{Name: "libpq5", Type: ComponentTypeApt, PURL: "pkg:deb/debian/libpq5", Source: "packages:apt:runtime"},
+		{Name: "@types/node", Version: "22.9.0", Type: ComponentTypeLibrary, PURL: "pkg:npm/%40types/node@22.9.0", Source: "package-lock.json"},
+		{Name: "express", Version: "4.21.1", Type: ComponentTypeLibrary, PURL: "pkg:npm/express@4.21.1", Source: "package-lock.json"},
+	}, sbom.Components)
+}
+
+func TestGenerateWithoutMiseInstalls(t *testing.T) {
+	app, err := a.NewApp(t.TempDir())
+	require.NoError(t, err)
+
+	result := testBuildResult()
+	result.Plan.Deploy.Inputs = []plan.Layer{plan.NewStepLayer("build", plan.NewIncludeFilter([]string{"."}))}
+
+	sbom, err := Generate(app, result)
+	require.NoError(t, err)
+	for _, component := range sbom.Components {
+		require.NotEqual(t, ComponentTypeRuntime, component.Type)
+	}
+}
+
+func TestGenerateFailedBuild(t *testing.T) {
+	app, err := a.NewApp(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = Generate(app, &core.BuildResult{Success: false})
+	require.Error(t, err)
+}
+
+func TestSPDX(t *testing.T) {
+	sbom := &SBOM{
+		Name:            "app",
+		RailpackVersion: "1.0.0",
+		Created:         time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Components: []Component{
+			{Name: "ghcr.io/railwayapp/railpack-runtime", Version: "latest", Type: ComponentTypeImage, PURL: "pkg:docker/railwayapp/railpack-runtime@latest?repository_url=ghcr.io"},
+			{Name: "node", Version: "22.11.0", Type: ComponentTypeRuntime, PURL: "pkg:generic/mise/node@22.11.0", Location: "/mise/installs/node/22.11.0"},
+		},
+	}
+
+	data, err := sbom.Marshal(FormatSPDX)
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(data, &doc))
+	require.Equal(t, "SPDX-2.3", doc["spdxVersion"])
+	require.Equal(t, "2025-01-02T03:04:05Z", doc["creationInfo"].(map[string]any)["created"])
+	require.Equal(t, []any{"Tool: railpack-1.0.0"}, doc["creationInfo"].(map[string]any)["creators"])
+
+	packages := doc["packages"].([]any)
+	require.Len(t, packages, 3)
+	node := packages[2].(map[string]any)
+	require.Equal(t, "SPDXRef-Package-node-2", node["SPDXID"])
+	require.Equal(t, "/mise/installs/node/22.11.0", node["packageFileName"])
+	require.Equal(t, "pkg:generic/mise/node@22.11.0", node["externalRefs"].([]any)[0].(map[string]any)["referenceLocator"])
+
+	relationships := doc["relationships"].([]any)
+	require.Len(t, relationships, 3)
+	require.Equal(t, "DESCENDANT_OF", relationships[1].(map[string]any)["relationshipType"])
+	require.Equal(t, "CONTAINS", relationships[2].(map[string]any)["relationshipType"])
+
+	// The same SBOM always has the same namespace
+	again, err := sbom.SPDX()
+	require.NoError(t, err)
+	require.Equal(t, data, again)
+}
+
+func TestCycloneDX(t *testing.T) {
+	sbom := &SBOM{
+		Name:    "app",
+		Created: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Components: []Component{
+			{Name: "node", Version: "22.11.0", Type: ComponentTypeRuntime, PURL: "pkg:generic/mise/node@22.11.0", Location: "/mise/installs/node/22.11.0", Source: "railpack default"},
+		},
+	}
+
+	data, err := sbom.Marshal(FormatCycloneDX)
+	require.NoError(t, err)
+
+	var doc cycloneDXDocument
+	require.NoError(t, json.Unmarshal(data, &doc))
+	require.Equal(t, "CycloneDX", doc.BOMFormat)
+	require.Equal(t, "1.5", doc.SpecVersion)
+	require.Equal(t, "container", doc.Metadata.Component.Type)
+	require.Equal(t, []cycloneDXComponent{{
+		Type:    "application",
+		BOMRef:  "pkg:generic/mise/node@22.11.0",
+		Name:    "node",
+		Version: "22.11.0",
+		PURL:    "pkg:generic/mise/node@22.11.0",
+		Properties: []cycloneDXProperty{
+			{Name: "railpack:type", Value: "runtime"},
+			{Name: "railpack:source", Value: "railpack default"},
+		},
+		Evidence: &cycloneDXEvidence{Occurrences: []cycloneDXOccurrence{{Location: "/mise/installs/node/22.11.0"}}},
+	}}, doc.Components)
+
+	_, err = sbom.Marshal("swid")
+	require.ErrorContains(t, err, `unknown SBOM format "swid"`)
+}
+
+func TestImagePURL(t *testing.T) {
+	tests := map[string]string{
+		"ghcr.io/railwayapp/railpack-runtime:latest": "pkg:docker/railwayapp/railpack-runtime@latest?repository_url=ghcr.io",
+		"debian:bookworm-slim":                       "pkg:docker/debian@bookworm-slim",
+		"node":                                       "pkg:docker/node@latest",
+		"localhost:5000/app":                         "pkg:docker/app@latest?repository_url=localhost%3A5000",
+		"php@sha256:abc":                             "pkg:docker/php@sha256:abc",
+	}
+
+	for image, want := range tests {
+		require.Equal(t, want, imagePURL(image), image)
+	}
+}
@@ -0,0 +1,143 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SPDX 2.3 document (https://spdx.github.io/spdx-spec/v2.3/)
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name                  string            `json:"name"`
+	SPDXID                string            `json:"SPDXID"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	PackageFileName       string            `json:"packageFileName,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	SourceInfo            string            `json:"sourceInfo,omitempty"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+const spdxNoAssertion = "NOASSERTION"
+
+var spdxPurposes = map[string]string{
+	ComponentTypeRuntime: "APPLICATION",
+	ComponentTypeApt:     "LIBRARY",
+	ComponentTypeImage:   "CONTAINER",
+	ComponentTypeLibrary: "LIBRARY",
+}
+
+// SPDX marshals the SBOM as an SPDX 2.3 JSON document
+func (s *SBOM) SPDX() ([]byte, error) {
+	rootID := "SPDXRef-Image"
+
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              s.Name,
+		DocumentNamespace: fmt.Sprintf("https://railpack.com/spdx/%s-%s", s.Name, s.id()),
+		CreationInfo: spdxCreationInfo{
+			Created:  s.Created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + s.toolName()},
+		},
+		Packages: []spdxPackage{{
+			Name:                  s.Name,
+			SPDXID:                rootID,
+			DownloadLocation:      spdxNoAssertion,
+			PrimaryPackagePurpose: "CONTAINER",
+		}},
+		Relationships: []spdxRelationship{{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: rootID,
+		}},
+	}
+
+	for i, component := range s.Components {
+		id := fmt.Sprintf("SPDXRef-Package-%s-%d", spdxIDPart(component.Name), i+1)
+
+		pkg := spdxPackage{
+			Name:                  component.Name,
+			SPDXID:                id,
+			VersionInfo:           component.Version,
+			PackageFileName:       component.Location,
+			DownloadLocation:      spdxNoAssertion,
+			PrimaryPackagePurpose: spdxPurposes[component.Type],
+		}
+		if component.Source != "" {
+			pkg.SourceInfo = "found in " + component.Source
+		}
+		if component.PURL != "" {
+			pkg.ExternalRefs = []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  component.PURL,
+			}}
+		}
+		doc.Packages = append(doc.Packages, pkg)
+
+		relationship := spdxRelationship{SPDXElementID: rootID, RelationshipType: "CONTAINS", RelatedSPDXElement: id}
+		if component.Type == ComponentTypeImage {
+			relationship.RelationshipType = "DESCENDANT_OF"
+		}
+		doc.Relationships = append(doc.Relationships, relationship)
+	}
+
+	return marshalJSON(doc)
+}
+
+// id is a UUID derived from the contents of the SBOM, so the same build produces the same document
+func (s *SBOM) id() uuid.UUID {
+	data, _ := json.Marshal(s)
+	return uuid.NewSHA1(uuid.NameSpaceURL, data)
+}
+
+func (s *SBOM) toolName() string {
+	if s.RailpackVersion == "" {
+		return "railpack"
+	}
+	return "railpack-" + s.RailpackVersion
+}
+
+// spdxIDPart replaces the characters that are not allowed in SPDX identifiers
+func spdxIDPart(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '-'
+	}, name)
+}
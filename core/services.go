@@ -0,0 +1,302 @@
+// discovers the deployable services in a monorepo so that each one can be planned on its own
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/railwayapp/railpack/core/app"
+	c "github.com/railwayapp/railpack/core/config"
+	"github.com/railwayapp/railpack/core/generate"
+	"github.com/railwayapp/railpack/core/logger"
+	"github.com/railwayapp/railpack/core/providers"
+	"github.com/railwayapp/railpack/core/providers/node"
+	"github.com/railwayapp/railpack/core/providers/rust"
+)
+
+const (
+	ServiceSourceRoot            = "root"
+	ServiceSourceNodeWorkspace   = "node workspace"
+	ServiceSourceCargoWorkspace  = "cargo workspace"
+	ServiceSourceGoWorkspace     = "go workspace"
+	ServiceSourceDirectoryLayout = "directory layout"
+)
+
+// directories that conventionally contain one service per subdirectory
+var serviceDirectoryPatterns = []string{"apps/*", "services/*"}
+
+var invalidServiceIDChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+type Service struct {
+	// Stable identifier derived from the service path
+	ID string `json:"id"`
+
+	// Directory of the service relative to the repository root
+	Path string `json:"path"`
+
+	// Directory the plan is generated from, relative to the repository root.
+	// Workspace members that must be built from the workspace root use "."
+	Root string `json:"root"`
+
+	// How the service was discovered
+	Source string `json:"source"`
+
+	// The provider that plans the service. Services in a directory of their own are detected instead
+	Provider string `json:"provider,omitempty"`
+
+	// Variables added to the environment when planning this service
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+type ServiceBuildResult struct {
+	Service
+	Result *BuildResult `json:"result"`
+}
+
+// DiscoverServices finds every deployable service in the app
+// If no services are found, the app itself is returned as the only service
+func DiscoverServices(rootApp *app.App, env *app.Environment) ([]*Service, error) {
+	services := map[string]*Service{}
+	addService := func(service *Service) {
+		service.Path = filepath.ToSlash(filepath.Clean(service.Path))
+		if _, exists := services[service.Path]; exists || service.Path == "." {
+			return
+		}
+		service.ID = serviceID(service.Path)
+		services[service.Path] = service
+	}
+
+	for _, path := range nodeWorkspaceMembers(rootApp) {
+		addService(&Service{
+			Path:      path,
+			Root:      ".",
+			Source:    ServiceSourceNodeWorkspace,
+			Provider:  "node",
+			Variables: map[string]string{env.ConfigVariable("NODE_WORKSPACE"): path},
+		})
+	}
+
+	for path, packageName := range cargoWorkspaceBinaries(rootApp) {
+		addService(&Service{
+			Path:      path,
+			Root:      ".",
+			Source:    ServiceSourceCargoWorkspace,
+			Provider:  "rust",
+			Variables: map[string]string{env.ConfigVariable("CARGO_WORKSPACE"): packageName},
+		})
+	}
+
+	for _, path := range goWorkspaceModules(rootApp) {
+		addService(&Service{
+			Path:      path,
+			Root:      ".",
+			Source:    ServiceSourceGoWorkspace,
+			Provider:  "golang",
+			Variables: map[string]string{env.ConfigVariable("GO_WORKSPACE_MODULE"): path},
+		})
+	}
+
+	for _, pattern := range serviceDirectoryPatterns {
+		dirs, err := rootApp.FindDirectories(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dir := range dirs {
+			if isDeployableDirectory(rootApp, dir, env) {
+				addService(&Service{Path: dir, Root: dir, Source: ServiceSourceDirectoryLayout})
+			}
+		}
+	}
+
+	if len(services) == 0 {
+		return []*Service{{
+			ID:     ServiceSourceRoot,
+			Path:   ".",
+			Root:   ".",
+			Source: ServiceSourceRoot,
+		}}, nil
+	}
+
+	result := make([]*Service, 0, len(services))
+	for _, path := range slices.Sorted(maps.Keys(services)) {
+		result = append(result, services[path])
+	}
+
+	return result, nil
+}
+
+// GenerateServiceBuildPlans generates a build plan for every service discovered in the app.
+// Workspace members are planned from the workspace root with the provider of the workspace, not the provider detected at the root
+func GenerateServiceBuildPlans(ctx context.Context, rootApp *app.App, env *app.Environment, options *GenerateBuildPlanOptions) ([]*ServiceBuildResult, error) {
+	services, err := DiscoverServices(rootApp, env)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*ServiceBuildResult, 0, len(services))
+	for _, service := range services {
+		serviceApp, err := rootApp.Sub(service.Root)
+		if err != nil {
+			return nil, fmt.Errorf("error creating app for service %s: %w", service.ID, err)
+		}
+
+		serviceVariables := maps.Clone(env.Variables)
+		maps.Copy(serviceVariables, service.Variables)
+		serviceEnv := app.NewEnvironment(&serviceVariables)
+
+		serviceOptions := *options
+		serviceOptions.Provider = service.Provider
+
+		results = append(results, &ServiceBuildResult{
+			Service: *service,
+			Result:  GenerateBuildPlan(ctx, serviceApp, serviceEnv, &serviceOptions),
+		})
+	}
+
+	return results, nil
+}
+
+// serviceID converts a service path into an identifier that only changes when the service moves
+func serviceID(path string) string {
+	id := invalidServiceIDChars.ReplaceAllString(strings.ToLower(path), "-")
+	return strings.Trim(id, "-")
+}
+
+// isDeployableDirectory checks if any language provider detects an app in the directory
+func isDeployableDirectory(rootApp *app.App, dir string, env *app.Environment) bool {
+	dirApp, err := rootApp.Sub(dir)
+	if err != nil {
+		return false
+	}
+
+	ctx := &generate.GenerateContext{
+		App:      dirApp,
+		Env:      env,
+		Config:   c.EmptyConfig(),
+		Metadata: generate.NewMetadata(),
+		Logger:   logger.NewLogger(),
+	}
+
+	for _, provider := range providers.GetLanguageProviders() {
+		if matched, err := provider.Detect(ctx); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nodeWorkspaceMembers returns the workspace packages that are apps, leaving out the libraries they share
+func nodeWorkspaceMembers(rootApp *app.App) []string {
+	if !rootApp.HasFile("package.json") {
+		return nil
+	}
+
+	workspace, err := node.NewWorkspace(rootApp)
+	if err != nil {
+		return nil
+	}
+
+	paths := []string{}
+	for _, pkg := range workspace.Packages {
+		if pkg.IsDeployable() {
+			paths = append(paths, pkg.Path)
+		}
+	}
+	return paths
+}
+
+// cargoWorkspaceBinaries returns the binary members of a Cargo workspace keyed by their path
+func cargoWorkspaceBinaries(rootApp *app.App) map[string]string {
+	binaries := map[string]string{}
+
+	var cargoToml rust.CargoTOML
+	if err := rootApp.ReadTOML("Cargo.toml", &cargoToml); err != nil {
+		return binaries
+	}
+
+	for _, member := range cargoToml.Workspace.Members {
+		dirs := []string{member}
+		if strings.ContainsAny(member, "*?") {
+			matches, err := rootApp.FindDirectories(member)
+			if err != nil {
+				continue
+			}
+			dirs = matches
+		}
+
+		for _, dir := range dirs {
+			if slices.Contains(cargoToml.Workspace.ExcludeMembers, dir) {
+				continue
+			}
+
+			var manifest rust.CargoTOML
+			if err := rootApp.ReadTOML(filepath.Join(dir, "Cargo.toml"), &manifest); err != nil || manifest.Package.Name == "" {
+				continue
+			}
+
+			isBinary := rootApp.HasFile(filepath.Join(dir, "src/main.rs")) ||
+				rootApp.HasMatch(filepath.Join(dir, "src/bin")) ||
+				len(manifest.Bin) > 0
+			if isBinary {
+				binaries[dir] = manifest.Package.Name
+			}
+		}
+	}
+
+	return binaries
+}
+
+// goWorkspaceModules returns the modules in a go.work file that contain a main package
+func goWorkspaceModules(rootApp *app.App) []string {
+	contents, err := rootApp.ReadFile("go.work")
+	if err != nil {
+		return nil
+	}
+
+	modules := []string{}
+	for _, dir := range parseGoWorkUse(contents) {
+		if dir == "." {
+			continue
+		}
+
+		// the go provider builds workspace modules with `go build ./<module>`
+		if rootApp.HasFile(filepath.Join(dir, "main.go")) {
+			modules = append(modules, dir)
+		}
+	}
+
+	return modules
+}
+
+// parseGoWorkUse returns the directories in the `use` directives of a go.work file
+func parseGoWorkUse(contents string) []string {
+	dirs := []string{}
+	inUseBlock := false
+
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(strings.SplitN(line, "//", 2)[0])
+
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "use ("), line == "use(":
+			inUseBlock = true
+		case inUseBlock && line == ")":
+			inUseBlock = false
+		case inUseBlock:
+			dirs = append(dirs, filepath.Clean(line))
+		case strings.HasPrefix(line, "use "):
+			dirs = append(dirs, filepath.Clean(strings.TrimSpace(strings.TrimPrefix(line, "use "))))
+		}
+	}
+
+	return dirs
+}
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/railwayapp/railpack/core/app"
+	"github.com/railwayapp/railpack/core/logger"
+	"github.com/railwayapp/railpack/core/resolver"
+	"github.com/railwayapp/railpack/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+	}
+	return dir
+}
+
+func TestDiscoverServices(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"package.json":                `{"name": "root", "workspaces": ["packages/*"]}`,
+		"packages/web/package.json":   `{"name": "web", "scripts": {"start": "node server.js"}}`,
+		"packages/ui/package.json":    `{"name": "ui", "main": "", "scripts": {"build": "tsc"}}`,
+		"packages/empty/README.md":    `not a package`,
+		"apps/api/requirements.txt":   `flask`,
+		"apps/docs/README.md":         `no provider detects this`,
+		"services/worker/go.mod":      `module worker`,
+		"services/worker/main.go":     `package main`,
+		"Cargo.toml":                  "[workspace]\nmembers = [\"crates/*\"]\n",
+		"crates/server/Cargo.toml":    "[package]\nname = \"server\"\n",
+		"crates/server/src/main.rs":   `fn main() {}`,
+		"crates/shared/Cargo.toml":    "[package]\nname = \"shared\"\n",
+		"crates/shared/src/lib.rs":    ``,
+		"go.work":                     "go 1.23\n\nuse (\n\t./cmd-api // the api\n\t./lib\n)\n",
+		"cmd-api/main.go":             `package main`,
+		"lib/lib.go":                  `package lib`,
+		"apps/web-frontend/index.php": `<?php`,
+	})
+
+	rootApp, err := app.NewApp(dir)
+	require.NoError(t, err)
+
+	services, err := DiscoverServices(rootApp, app.NewEnvironment(nil))
+	require.NoError(t, err)
+
+	expected := []Service{
+		{ID: "apps-api", Path: "apps/api", Root: "apps/api", Source: ServiceSourceDirectoryLayout},
+		{ID: "apps-web-frontend", Path: "apps/web-frontend", Root: "apps/web-frontend", Source: ServiceSourceDirectoryLayout},
+		{ID: "cmd-api", Path: "cmd-api", Root: ".", Source: ServiceSourceGoWorkspace, Provider: "golang", Variables: map[string]string{"RAILPACK_GO_WORKSPACE_MODULE": "cmd-api"}},
+		{ID: "crates-server", Path: "crates/server", Root: ".", Source: ServiceSourceCargoWorkspace, Provider: "rust", Variables: map[string]string{"RAILPACK_CARGO_WORKSPACE": "server"}},
+		{ID: "packages-web", Path: "packages/web", Root: ".", Source: ServiceSourceNodeWorkspace, Provider: "node", Variables: map[string]string{"RAILPACK_NODE_WORKSPACE": "packages/web"}},
+		{ID: "services-worker", Path: "services/worker", Root: "services/worker", Source: ServiceSourceDirectoryLayout},
+	}
+
+	actual := []Service{}
+	for _, service := range services {
+		actual = append(actual, *service)
+	}
+
+	require.Equal(t, expected, actual)
+}
+
+func TestGenerateServiceBuildPlansMixedRoot(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"package.json":              `{"name": "root", "workspaces": ["packages/*"]}`,
+		"package-lock.json":         `{"lockfileVersion": 3, "packages": {}}`,
+		"packages/web/package.json": `{"name": "web", "scripts": {"start": "node server.js"}}`,
+		"packages/ui/package.json":  `{"name": "ui", "scripts": {"build": "tsc"}}`,
+		"go.work":                   "go 1.23\n\nuse ./cmd-api\n",
+		"cmd-api/go.mod":            "module api\n\ngo 1.23\n",
+		"cmd-api/main.go":           `package main`,
+	})
+
+	indexPath := filepath.Join(t.TempDir(), resolver.VersionIndexFileName)
+	index := &resolver.VersionIndex{Packages: map[string][]string{
+		"node": {"22.12.0"},
+		"go":   {"1.23.4", "1.25.3"},
+	}}
+	require.NoError(t, index.Write(indexPath))
+
+	rootApp, err := app.NewApp(dir)
+	require.NoError(t, err)
+
+	results, err := GenerateServiceBuildPlans(context.Background(), rootApp, app.NewEnvironment(nil), &GenerateBuildPlanOptions{VersionIndex: indexPath})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	// Every service is planned by the provider of its workspace, whichever provider is detected at the root
+	for _, result := range results {
+		require.True(t, result.Result.Success, "%s: %v", result.ID, result.Result.Logs)
+		require.Contains(t, logMessages(result.Result.Logs), "Using provider "+utils.CapitalizeFirst(result.Provider)+" from config", result.ID)
+	}
+
+	require.Equal(t, "cmd-api", results[0].ID)
+	require.Equal(t, "packages-web", results[1].ID)
+	require.Equal(t, "npm run start --workspace web", results[1].Result.Plan.Deploy.StartCmd)
+}
+
+func logMessages(logs []logger.Msg) []string {
+	messages := []string{}
+	for _, log := range logs {
+		messages = append(messages, log.Msg)
+	}
+	return messages
+}
+
+func TestDiscoverServicesFallsBackToRoot(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"package.json": `{"name": "app"}`,
+	})
+
+	rootApp, err := app.NewApp(dir)
+	require.NoError(t, err)
+
+	services, err := DiscoverServices(rootApp, app.NewEnvironment(nil))
+	require.NoError(t, err)
+	require.Len(t, services, 1)
+	require.Equal(t, Service{ID: "root", Path: ".", Root: ".", Source: ServiceSourceRoot}, *services[0])
+}
+
+func TestParseGoWorkUse(t *testing.T) {
+	contents := "go 1.23\n\nuse ./single\n\nuse (\n\t.\n\t./api // comment\n\tworker\n)\n"
+	require.Equal(t, []string{"single", ".", "api", "worker"}, parseGoWorkUse(contents))
+}
+
+func TestServiceID(t *testing.T) {
+	require.Equal(t, "apps-web", serviceID("apps/web"))
+	require.Equal(t, "services-my-api", serviceID("services/My_API"))
+	require.Equal(t, "api", serviceID("./api/"))
+}
@@ -0,0 +1,158 @@
+// builds SLSA provenance (https://slsa.dev/spec/v1.0/provenance) for images built from a build plan
+package slsa
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/railwayapp/railpack/core"
+	"github.com/railwayapp/railpack/core/plan"
+)
+
+const (
+	PredicateType = "https://slsa.dev/provenance/v1"
+	BuildType     = "https://railpack.com/provenance/build/v1"
+	BuilderID     = "https://github.com/railwayapp/railpack"
+)
+
+type Provenance struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+type BuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   ExternalParameters   `json:"externalParameters"`
+	InternalParameters   InternalParameters   `json:"internalParameters"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+// ExternalParameters are the inputs of the build that can be controlled by the user
+type ExternalParameters struct {
+	// The git repository and commit the app was built from (e.g. git+https://github.com/org/app@<sha>)
+	Source string `json:"source,omitempty"`
+
+	// The serialized plan the image was built from
+	Plan *plan.BuildPlan `json:"plan"`
+
+	Platforms []string `json:"platforms,omitempty"`
+}
+
+// InternalParameters are decided by railpack
+type InternalParameters struct {
+	DetectedProviders []string          `json:"detectedProviders,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+}
+
+type ResourceDescriptor struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+type RunDetails struct {
+	Builder  Builder       `json:"builder"`
+	Metadata BuildMetadata `json:"metadata"`
+}
+
+type Builder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+type BuildMetadata struct {
+	StartedOn string `json:"startedOn,omitempty"`
+}
+
+// Generate creates the provenance of an image built from the build result.
+// The source of the build is the git repository the app is in, if any. startedOn is omitted when it is zero
+func Generate(result *core.BuildResult, git *core.GitMetadata, platforms []string, startedOn time.Time) (*Provenance, error) {
+	if result == nil || !result.Success || result.Plan == nil {
+		return nil, fmt.Errorf("cannot generate provenance from a failed build plan")
+	}
+
+	provenance := &Provenance{
+		BuildDefinition: BuildDefinition{
+			BuildType: BuildType,
+			ExternalParameters: ExternalParameters{
+				Plan:      result.Plan,
+				Platforms: platforms,
+			},
+			InternalParameters: InternalParameters{
+				DetectedProviders: result.DetectedProviders,
+				Metadata:          result.Metadata,
+			},
+			ResolvedDependencies: []ResourceDescriptor{},
+		},
+		RunDetails: RunDetails{
+			Builder: Builder{ID: BuilderID},
+		},
+	}
+
+	if !startedOn.IsZero() {
+		provenance.RunDetails.Metadata.StartedOn = startedOn.UTC().Format(time.RFC3339)
+	}
+
+	if result.RailpackVersion != "" {
+		provenance.RunDetails.Builder.Version = map[string]string{"railpack": result.RailpackVersion}
+	}
+
+	if git != nil && git.Source != "" && git.Revision != "" {
+		provenance.BuildDefinition.ExternalParameters.Source = "git+" + git.Source + "@" + git.Revision
+		provenance.BuildDefinition.ResolvedDependencies = append(provenance.BuildDefinition.ResolvedDependencies, ResourceDescriptor{
+			URI:    "git+" + git.Source,
+			Digest: map[string]string{"gitCommit": git.Revision},
+		})
+	}
+
+	for _, image := range planImages(result.Plan) {
+		provenance.BuildDefinition.ResolvedDependencies = append(provenance.BuildDefinition.ResolvedDependencies, ResourceDescriptor{
+			URI: "docker-image://" + image,
+		})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(result.ResolvedPackages)) {
+		pkg := result.ResolvedPackages[name]
+		if pkg.ResolvedVersion == nil {
+			continue
+		}
+
+		provenance.BuildDefinition.ResolvedDependencies = append(provenance.BuildDefinition.ResolvedDependencies, ResourceDescriptor{
+			URI: fmt.Sprintf("pkg:generic/mise/%s@%s", url.PathEscape(pkg.Name), url.PathEscape(*pkg.ResolvedVersion)),
+		})
+	}
+
+	return provenance, nil
+}
+
+// Marshal the provenance as the predicate of an in-toto statement
+func (p *Provenance) Marshal() ([]byte, error) {
+	return json.MarshalIndent(p, "", "  ")
+}
+
+// planImages returns every image used by the steps and deploy of the plan
+func planImages(p *plan.BuildPlan) []string {
+	images := []string{}
+	addImage := func(layer plan.Layer) {
+		if layer.Image != "" && !slices.Contains(images, layer.Image) {
+			images = append(images, layer.Image)
+		}
+	}
+
+	for _, step := range p.Steps {
+		for _, input := range step.Inputs {
+			addImage(input)
+		}
+	}
+
+	addImage(p.Deploy.Base)
+	for _, input := range p.Deploy.Inputs {
+		addImage(input)
+	}
+
+	slices.Sort(images)
+	return images
+}
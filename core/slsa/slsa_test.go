@@ -0,0 +1,72 @@
+package slsa
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/railwayapp/railpack/core"
+	"github.com/railwayapp/railpack/core/plan"
+	"github.com/railwayapp/railpack/core/resolver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	p := plan.NewBuildPlan()
+	install := plan.NewStep("install")
+	install.Inputs = []plan.Layer{plan.NewImageLayer(plan.RailpackBuilderImage)}
+	p.Steps = []plan.Step{*install}
+	p.Deploy.Base = plan.NewImageLayer(plan.RailpackRuntimeImage)
+	p.Deploy.StartCmd = "node index.js"
+
+	nodeVersion := "22.11.0"
+	result := &core.BuildResult{
+		RailpackVersion:   "1.2.3",
+		Plan:              p,
+		ResolvedPackages:  map[string]*resolver.ResolvedPackage{"node": {Name: "node", ResolvedVersion: &nodeVersion}},
+		DetectedProviders: []string{"node"},
+		Success:           true,
+	}
+	git := &core.GitMetadata{Source: "https://github.com/org/app", Revision: "abc123"}
+
+	provenance, err := Generate(result, git, []string{"linux/amd64"}, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	require.NoError(t, err)
+
+	require.Equal(t, BuildType, provenance.BuildDefinition.BuildType)
+	require.Equal(t, "git+https://github.com/org/app@abc123", provenance.BuildDefinition.ExternalParameters.Source)
+	require.Equal(t, []ResourceDescriptor{
+		{URI: "git+https://github.com/org/app", Digest: map[string]string{"gitCommit": "abc123"}},
+		{URI: "docker-image://" + plan.RailpackBuilderImage},
+		{URI: "docker-image://" + plan.RailpackRuntimeImage},
+		{URI: "pkg:generic/mise/node@22.11.0"},
+	}, provenance.BuildDefinition.ResolvedDependencies)
+	require.Equal(t, Builder{ID: BuilderID, Version: map[string]string{"railpack": "1.2.3"}}, provenance.RunDetails.Builder)
+	require.Equal(t, "2025-01-02T03:04:05Z", provenance.RunDetails.Metadata.StartedOn)
+
+	data, err := provenance.Marshal()
+	require.NoError(t, err)
+
+	// The plan is embedded so the image can be rebuilt from the provenance
+	var decoded struct {
+		BuildDefinition struct {
+			ExternalParameters struct {
+				Plan *plan.BuildPlan `json:"plan"`
+			} `json:"externalParameters"`
+		} `json:"buildDefinition"`
+	}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, "node index.js", decoded.BuildDefinition.ExternalParameters.Plan.Deploy.StartCmd)
+}
+
+func TestGenerateWithoutGit(t *testing.T) {
+	result := &core.BuildResult{Plan: plan.NewBuildPlan(), Success: true}
+
+	provenance, err := Generate(result, nil, nil, time.Now())
+	require.NoError(t, err)
+	require.Empty(t, provenance.BuildDefinition.ExternalParameters.Source)
+	require.Empty(t, provenance.BuildDefinition.ResolvedDependencies)
+	require.Nil(t, provenance.RunDetails.Builder.Version)
+
+	_, err = Generate(&core.BuildResult{Success: false}, nil, nil, time.Now())
+	require.Error(t, err)
+}
@@ -1,6 +1,8 @@
 package testing
 
 import (
+	"context"
+	"io/fs"
 	"testing"
 
 	"github.com/railwayapp/railpack/core/app"
@@ -18,11 +20,24 @@ func CreateGenerateContext(t *testing.T, path string) *generate.GenerateContext
 		t.Fatalf("error creating app: %v", err)
 	}
 
+	return createGenerateContext(t, userApp)
+}
+
+// CreateGenerateContextFromFS creates a new GenerateContext for testing an app that only exists in memory (e.g. an fstest.MapFS)
+func CreateGenerateContextFromFS(t *testing.T, fsys fs.FS) *generate.GenerateContext {
+	t.Helper()
+
+	return createGenerateContext(t, app.NewAppFromFS(fsys, "app"))
+}
+
+func createGenerateContext(t *testing.T, userApp *app.App) *generate.GenerateContext {
+	t.Helper()
+
 	env := app.NewEnvironment(nil)
 
 	config := config.EmptyConfig()
 
-	ctx, err := generate.NewGenerateContext(userApp, env, config, logger.NewLogger())
+	ctx, err := generate.NewGenerateContext(context.Background(), userApp, env, config, logger.NewLogger())
 	if err != nil {
 		t.Fatalf("error creating generate context: %v", err)
 	}
@@ -0,0 +1,461 @@
+// converts a railpack build plan to a standalone multi-stage Dockerfile
+// this is an alternative to the LLB conversion in the buildkit package for environments that can only run `docker build`
+package dockerfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/moby/buildkit/util/system"
+	"github.com/railwayapp/railpack/core/plan"
+)
+
+const (
+	// heredocs, cache mounts, and secrets as environment variables all require the 1.x syntax
+	syntaxDirective = "# syntax=docker/dockerfile:1"
+
+	WorkingDir = "/app"
+)
+
+type ConvertPlanOptions struct {
+	// Unique value prepended to all cache mount ids
+	CacheKey string
+}
+
+type stageEnv struct {
+	pathList []string
+	envVars  map[string]string
+}
+
+type converter struct {
+	plan       *plan.BuildPlan
+	opts       ConvertPlanOptions
+	stageNames map[string]string
+	usedNames  map[string]bool
+	outputEnvs map[string]stageEnv
+	sb         strings.Builder
+}
+
+var invalidStageChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// ConvertPlanToDockerfile renders every step of the plan as a named build stage and the deploy as the final stage
+func ConvertPlanToDockerfile(p *plan.BuildPlan, opts ConvertPlanOptions) (string, error) {
+	c := &converter{
+		plan:       p,
+		opts:       opts,
+		stageNames: make(map[string]string),
+		usedNames:  make(map[string]bool),
+		outputEnvs: make(map[string]stageEnv),
+	}
+
+	order, err := c.orderSteps()
+	if err != nil {
+		return "", err
+	}
+
+	for _, step := range order {
+		c.stageNames[step.Name] = c.uniqueStageName(step.Name)
+	}
+
+	c.sb.WriteString(syntaxDirective + "\n")
+
+	for _, step := range order {
+		if err := c.writeStep(step); err != nil {
+			return "", err
+		}
+	}
+
+	if err := c.writeDeploy(); err != nil {
+		return "", err
+	}
+
+	return c.sb.String(), nil
+}
+
+// orderSteps returns the plan steps so that every step comes after the steps it depends on
+func (c *converter) orderSteps() ([]*plan.Step, error) {
+	stepsByName := make(map[string]*plan.Step, len(c.plan.Steps))
+	for i := range c.plan.Steps {
+		stepsByName[c.plan.Steps[i].Name] = &c.plan.Steps[i]
+	}
+
+	order := make([]*plan.Step, 0, len(c.plan.Steps))
+	visited := make(map[string]bool)
+	visiting := make(map[string]bool)
+
+	var visit func(step *plan.Step) error
+	visit = func(step *plan.Step) error {
+		if visited[step.Name] {
+			return nil
+		}
+		if visiting[step.Name] {
+			return fmt.Errorf("cycle detected: %s", step.Name)
+		}
+		visiting[step.Name] = true
+
+		for _, input := range step.Inputs {
+			if input.Step == "" {
+				continue
+			}
+
+			dep, ok := stepsByName[input.Step]
+			if !ok {
+				return fmt.Errorf("step %q references unknown step %q", step.Name, input.Step)
+			}
+
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		visiting[step.Name] = false
+		visited[step.Name] = true
+		order = append(order, step)
+		return nil
+	}
+
+	for i := range c.plan.Steps {
+		if err := visit(&c.plan.Steps[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// Dockerfile stage names must be lowercase and can only contain a limited set of characters
+func (c *converter) uniqueStageName(stepName string) string {
+	name := invalidStageChars.ReplaceAllString(strings.ToLower(stepName), "-")
+	name = strings.Trim(name, "-")
+	if name == "" || !(name[0] >= 'a' && name[0] <= 'z') {
+		name = "step-" + name
+	}
+
+	candidate := name
+	for i := 2; c.usedNames[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+	c.usedNames[candidate] = true
+
+	return candidate
+}
+
+func (c *converter) writeStep(step *plan.Step) error {
+	stageName := c.stageNames[step.Name]
+
+	c.sb.WriteString("\n")
+	c.sb.WriteString(fmt.Sprintf("# step: %s\n", step.Name))
+
+	if err := c.writeLayers(step.Inputs, stageName); err != nil {
+		return fmt.Errorf("step %q: %w", step.Name, err)
+	}
+
+	c.sb.WriteString(fmt.Sprintf("WORKDIR %s\n", WorkingDir))
+
+	// Mirror the LLB conversion: the environment of a step is the merged output of its parent steps
+	env := c.inputEnv(step.Inputs)
+	maps.Copy(env.envVars, step.Variables)
+	c.writeEnv(env.envVars)
+	if len(env.pathList) > 0 {
+		c.writePath(env.pathList)
+	}
+
+	for _, cmd := range step.Commands {
+		var err error
+		env, err = c.writeCommand(step, cmd, env)
+		if err != nil {
+			return fmt.Errorf("step %q: %w", step.Name, err)
+		}
+	}
+
+	c.outputEnvs[step.Name] = env
+
+	return nil
+}
+
+func (c *converter) writeDeploy() error {
+	c.sb.WriteString("\n# deploy\n")
+
+	deployInputs := append([]plan.Layer{c.plan.Deploy.Base}, c.plan.Deploy.Inputs...)
+	if err := c.writeLayers(deployInputs, ""); err != nil {
+		return fmt.Errorf("deploy: %w", err)
+	}
+
+	c.sb.WriteString(fmt.Sprintf("WORKDIR %s\n", WorkingDir))
+
+	graphEnv := newStageEnv()
+	for _, input := range c.plan.Deploy.Inputs {
+		if env, ok := c.outputEnvs[input.Step]; ok {
+			graphEnv.merge(env)
+		}
+	}
+
+	paths := []string{}
+	paths = append(paths, c.plan.Deploy.Paths...)
+	paths = append(paths, graphEnv.pathList...)
+	paths = append(paths, system.DefaultPathEnvUnix)
+	slices.Sort(paths)
+
+	envVars := maps.Clone(graphEnv.envVars)
+	maps.Copy(envVars, c.plan.Deploy.Variables)
+	envVars["PATH"] = strings.Join(paths, ":")
+	c.writeEnv(envVars)
+
+	startCommand := c.plan.Deploy.StartCmd
+	if startCommand == "" {
+		startCommand = "/bin/bash"
+	}
+
+	c.sb.WriteString("ENTRYPOINT [\"/bin/bash\", \"-c\"]\n")
+	c.sb.WriteString(fmt.Sprintf("CMD [%s]\n", quote(startCommand)))
+
+	return nil
+}
+
+// writeLayers writes the FROM instruction for the first layer and a COPY instruction for every include of the rest
+func (c *converter) writeLayers(layers []plan.Layer, stageName string) error {
+	as := ""
+	if stageName != "" {
+		as = " AS " + stageName
+	}
+
+	if len(layers) == 0 {
+		c.sb.WriteString(fmt.Sprintf("FROM scratch%s\n", as))
+		return nil
+	}
+
+	first := layers[0]
+	rest := layers[1:]
+
+	switch {
+	case first.Image != "":
+		c.sb.WriteString(fmt.Sprintf("FROM %s%s\n", first.Image, as))
+	case first.Step != "":
+		c.sb.WriteString(fmt.Sprintf("FROM %s%s\n", c.stageNames[first.Step], as))
+	default:
+		c.sb.WriteString(fmt.Sprintf("FROM scratch%s\n", as))
+		if first.Local {
+			rest = layers
+		}
+	}
+
+	for _, layer := range rest {
+		if err := c.writeLayerCopy(layer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *converter) writeLayerCopy(layer plan.Layer) error {
+	from := ""
+	switch {
+	case layer.Image != "":
+		from = "--from=" + layer.Image + " "
+	case layer.Step != "":
+		stageName, ok := c.stageNames[layer.Step]
+		if !ok {
+			return fmt.Errorf("unknown step %q", layer.Step)
+		}
+		from = "--from=" + stageName + " "
+	case !layer.Local:
+		return nil
+	}
+
+	excludes := ""
+	for _, exclude := range layer.Exclude {
+		excludes += fmt.Sprintf("--exclude=%s ", exclude)
+	}
+
+	for _, include := range layer.Include {
+		srcPath, destPath := resolvePaths(include, layer.Local)
+		c.sb.WriteString(fmt.Sprintf("COPY %s%s%s %s\n", from, excludes, srcPath, destPath))
+	}
+
+	return nil
+}
+
+func (c *converter) writeCommand(step *plan.Step, cmd plan.Command, env stageEnv) (stageEnv, error) {
+	switch cmd := cmd.(type) {
+	case plan.ExecCommand:
+		if cmd.CustomName != "" {
+			c.sb.WriteString(fmt.Sprintf("# %s\n", singleLine(cmd.CustomName)))
+		}
+
+		mounts, err := c.getMounts(step)
+		if err != nil {
+			return env, err
+		}
+
+		c.sb.WriteString("RUN ")
+		for _, mount := range mounts {
+			c.sb.WriteString(mount + " ")
+		}
+
+		// Multiline commands are written as a heredoc so they still run with the shell form, like single line commands
+		if strings.Contains(cmd.Cmd, "\n") {
+			delimiter := heredocDelimiter(cmd.Cmd)
+			c.sb.WriteString(fmt.Sprintf("<<\"%s\"\n%s\n%s\n", delimiter, strings.TrimSuffix(cmd.Cmd, "\n"), delimiter))
+		} else {
+			c.sb.WriteString(cmd.Cmd + "\n")
+		}
+	case plan.PathCommand:
+		env.pushPath(cmd.Path)
+		c.writePath(env.pathList)
+	case plan.CopyCommand:
+		from := ""
+		if cmd.Image != "" {
+			from = "--from=" + cmd.Image + " "
+		}
+		c.sb.WriteString(fmt.Sprintf("COPY %s%s %s\n", from, cmd.Src, cmd.Dest))
+	case plan.FileCommand:
+		asset, ok := step.Assets[cmd.Name]
+		if !ok {
+			return env, fmt.Errorf("asset %q not found", cmd.Name)
+		}
+
+		if cmd.CustomName != "" {
+			c.sb.WriteString(fmt.Sprintf("# %s\n", singleLine(cmd.CustomName)))
+		}
+
+		mode := cmd.Mode
+		if mode == 0 {
+			mode = 0644
+		}
+
+		delimiter := heredocDelimiter(asset)
+		if !strings.HasSuffix(asset, "\n") {
+			asset += "\n"
+		}
+		c.sb.WriteString(fmt.Sprintf("COPY --chmod=%04o <<\"%s\" %s\n%s%s\n", mode.Perm(), delimiter, cmd.Path, asset, delimiter))
+	}
+
+	return env, nil
+}
+
+// getMounts returns the cache and secret mounts for every exec command in the step
+func (c *converter) getMounts(step *plan.Step) ([]string, error) {
+	mounts := []string{}
+
+	for _, cacheKey := range step.Caches {
+		planCache, ok := c.plan.Caches[cacheKey]
+		if !ok {
+			return nil, fmt.Errorf("cache with key %q not found", cacheKey)
+		}
+
+		id := cacheKey
+		if c.opts.CacheKey != "" {
+			id = fmt.Sprintf("%s-%s", c.opts.CacheKey, cacheKey)
+		}
+
+		sharing := "shared"
+		if planCache.Type == plan.CacheTypeLocked {
+			sharing = "locked"
+		}
+
+		mounts = append(mounts, fmt.Sprintf("--mount=type=cache,id=%s,target=%s,sharing=%s", id, planCache.Directory, sharing))
+	}
+
+	// All secrets are available to all commands, the same as in the LLB conversion
+	for _, secret := range c.plan.Secrets {
+		mounts = append(mounts, fmt.Sprintf("--mount=type=secret,id=%s,env=%s", secret, secret))
+	}
+
+	return mounts, nil
+}
+
+func (c *converter) writeEnv(envVars map[string]string) {
+	for _, k := range slices.Sorted(maps.Keys(envVars)) {
+		c.sb.WriteString(fmt.Sprintf("ENV %s=%s\n", k, quoteEnv(envVars[k])))
+	}
+}
+
+func (c *converter) writePath(pathList []string) {
+	c.sb.WriteString(fmt.Sprintf("ENV PATH=%s\n", quoteEnv(strings.Join(pathList, ":")+":"+system.DefaultPathEnvUnix)))
+}
+
+func (c *converter) inputEnv(layers []plan.Layer) stageEnv {
+	env := newStageEnv()
+	for _, layer := range layers {
+		if parentEnv, ok := c.outputEnvs[layer.Step]; ok {
+			env.merge(parentEnv)
+		}
+	}
+	return env
+}
+
+func newStageEnv() stageEnv {
+	return stageEnv{
+		pathList: []string{},
+		envVars:  map[string]string{},
+	}
+}
+
+func (e *stageEnv) merge(other stageEnv) {
+	for _, p := range other.pathList {
+		if !slices.Contains(e.pathList, p) {
+			e.pathList = append(e.pathList, p)
+		}
+	}
+	maps.Copy(e.envVars, other.envVars)
+}
+
+func (e *stageEnv) pushPath(path string) {
+	if slices.Contains(e.pathList, path) {
+		return
+	}
+	e.pathList = append([]string{path}, e.pathList...)
+}
+
+// resolvePaths matches the path handling of the LLB conversion
+// local paths are copied into /app and container paths keep their location under /app
+func resolvePaths(include string, isLocal bool) (srcPath, destPath string) {
+	if isLocal {
+		return include, filepath.Join(WorkingDir, filepath.Base(include))
+	}
+
+	switch {
+	case include == "." || include == "/app" || include == "/app/":
+		return WorkingDir, WorkingDir
+	case filepath.IsAbs(include):
+		return include, include
+	default:
+		return filepath.Join(WorkingDir, include), filepath.Join(WorkingDir, include)
+	}
+}
+
+// quote returns a JSON string, which is what the exec form of CMD and ENTRYPOINT expects
+func quote(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// quoteEnv returns a double quoted ENV value with variable expansion disabled
+// values in the plan are set verbatim by the LLB conversion, so `$` must not be expanded here either
+func quoteEnv(s string) string {
+	return strings.ReplaceAll(quote(s), "$", "\\$")
+}
+
+func singleLine(s string) string {
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// heredocDelimiter picks a delimiter that does not appear as a line in the content
+func heredocDelimiter(content string) string {
+	lines := strings.Split(content, "\n")
+	delimiter := "EOF"
+	for i := 0; slices.Contains(lines, delimiter); i++ {
+		delimiter = fmt.Sprintf("EOF_%d", i)
+	}
+	return delimiter
+}
@@ -0,0 +1,144 @@
+package dockerfile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/railwayapp/railpack/core/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestPlan() *plan.BuildPlan {
+	p := plan.NewBuildPlan()
+
+	misePackages := plan.NewStep("packages:mise")
+	misePackages.Inputs = []plan.Layer{plan.NewImageLayer(plan.RailpackBuilderImage)}
+	misePackages.Variables["MISE_DATA_DIR"] = "/mise"
+	misePackages.Assets["mise.toml"] = "[tools]\nnode = \"22\"\n"
+	misePackages.AddCommands([]plan.Command{
+		plan.NewPathCommand("/mise/shims"),
+		plan.NewFileCommand("/etc/mise/config.toml", "mise.toml", plan.FileOptions{CustomName: "create mise config"}),
+		plan.NewExecCommand("mise install"),
+	})
+	misePackages.Secrets = []string{}
+
+	install := plan.NewStep("install")
+	install.Inputs = []plan.Layer{plan.NewStepLayer("packages:mise")}
+	install.Caches = []string{"npm"}
+	install.AddCommands([]plan.Command{
+		plan.NewCopyCommand("package.json"),
+		plan.NewExecShellCommand("npm ci"),
+	})
+
+	build := plan.NewStep("build")
+	build.Inputs = []plan.Layer{
+		plan.NewStepLayer("install"),
+		plan.NewLocalLayer(),
+	}
+	build.Variables["NODE_ENV"] = "production"
+	build.AddCommands([]plan.Command{
+		plan.NewExecShellCommand("npm run build"),
+	})
+
+	// Declared out of order to check that dependencies are written first
+	p.Steps = []plan.Step{*build, *install, *misePackages}
+	p.Caches["npm"] = plan.NewCache("/root/.npm")
+	p.Secrets = []string{"NPM_TOKEN"}
+	p.Deploy = plan.Deploy{
+		Base: plan.NewImageLayer(plan.RailpackRuntimeImage),
+		Inputs: []plan.Layer{
+			plan.NewStepLayer("packages:mise", plan.NewIncludeFilter([]string{"/mise/shims", "/mise/installs"})),
+			plan.NewStepLayer("build", plan.NewFilter([]string{"."}, []string{"node_modules"})),
+		},
+		StartCmd:  "npm start",
+		Variables: map[string]string{"PORT": "3000"},
+	}
+
+	return p
+}
+
+func TestConvertPlanToDockerfile(t *testing.T) {
+	contents, err := ConvertPlanToDockerfile(createTestPlan(), ConvertPlanOptions{CacheKey: "app"})
+	require.NoError(t, err)
+
+	require.True(t, strings.HasPrefix(contents, syntaxDirective+"\n"))
+
+	// stages are written in dependency order with sanitized names
+	miseIdx := strings.Index(contents, "FROM "+plan.RailpackBuilderImage+" AS packages-mise\n")
+	installIdx := strings.Index(contents, "FROM packages-mise AS install\n")
+	buildIdx := strings.Index(contents, "FROM install AS build\n")
+	deployIdx := strings.Index(contents, "FROM "+plan.RailpackRuntimeImage+"\n")
+	require.True(t, miseIdx >= 0 && miseIdx < installIdx && installIdx < buildIdx && buildIdx < deployIdx, contents)
+
+	require.Contains(t, contents, "COPY --chmod=0644 <<\"EOF\" /etc/mise/config.toml\n[tools]\nnode = \"22\"\nEOF\n")
+	require.Contains(t, contents, "ENV PATH=\"/mise/shims:")
+	require.Contains(t, contents, "RUN --mount=type=cache,id=app-npm,target=/root/.npm,sharing=shared --mount=type=secret,id=NPM_TOKEN,env=NPM_TOKEN sh -c 'npm ci'\n")
+	require.Contains(t, contents, "COPY package.json package.json\n")
+	require.Contains(t, contents, "COPY . /app\n")
+	require.Contains(t, contents, "ENV NODE_ENV=\"production\"\n")
+
+	require.Contains(t, contents, "COPY --from=packages-mise /mise/shims /mise/shims\n")
+	require.Contains(t, contents, "COPY --from=build --exclude=node_modules /app /app\n")
+	require.Contains(t, contents, "ENV PORT=\"3000\"\n")
+	require.Contains(t, contents, "ENV MISE_DATA_DIR=\"/mise\"\n")
+	require.True(t, strings.HasSuffix(contents, "ENTRYPOINT [\"/bin/bash\", \"-c\"]\nCMD [\"npm start\"]\n"), contents)
+}
+
+func TestConvertPlanToDockerfileErrors(t *testing.T) {
+	t.Run("unknown step", func(t *testing.T) {
+		p := plan.NewBuildPlan()
+		step := plan.NewStep("build")
+		step.Inputs = []plan.Layer{plan.NewStepLayer("missing")}
+		p.Steps = []plan.Step{*step}
+
+		_, err := ConvertPlanToDockerfile(p, ConvertPlanOptions{})
+		require.ErrorContains(t, err, "unknown step")
+	})
+
+	t.Run("missing cache", func(t *testing.T) {
+		p := plan.NewBuildPlan()
+		step := plan.NewStep("build")
+		step.Inputs = []plan.Layer{plan.NewImageLayer("alpine")}
+		step.Caches = []string{"missing"}
+		step.AddCommands([]plan.Command{plan.NewExecCommand("true")})
+		p.Steps = []plan.Step{*step}
+
+		_, err := ConvertPlanToDockerfile(p, ConvertPlanOptions{})
+		require.ErrorContains(t, err, "cache with key \"missing\" not found")
+	})
+}
+
+func TestConvertMultilineCommand(t *testing.T) {
+	p := plan.NewBuildPlan()
+	step := plan.NewStep("build")
+	step.Inputs = []plan.Layer{plan.NewImageLayer("alpine")}
+	step.AddCommands([]plan.Command{
+		plan.NewExecCommand("sh -c 'echo one\nEOF\necho two'"),
+	})
+	p.Steps = []plan.Step{*step}
+
+	contents, err := ConvertPlanToDockerfile(p, ConvertPlanOptions{})
+	require.NoError(t, err)
+
+	// A heredoc keeps the shell form, so a custom SHELL applies like it does to single line commands
+	require.Contains(t, contents, "RUN <<\"EOF_0\"\nsh -c 'echo one\nEOF\necho two'\nEOF_0\n")
+	require.NotContains(t, contents, "/bin/sh")
+}
+
+func TestUniqueStageName(t *testing.T) {
+	c := &converter{stageNames: map[string]string{}, usedNames: map[string]bool{}}
+
+	require.Equal(t, "packages-mise", c.uniqueStageName("packages:mise"))
+	require.Equal(t, "packages-mise-2", c.uniqueStageName("packages/mise"))
+	require.Equal(t, "step-1", c.uniqueStageName("1"))
+	require.Equal(t, "install-web", c.uniqueStageName("Install:Web"))
+}
+
+func TestQuoteEnv(t *testing.T) {
+	require.Equal(t, `"a \"b\" \$HOME <c>"`, quoteEnv(`a "b" $HOME <c>`))
+}
+
+func TestHeredocDelimiter(t *testing.T) {
+	require.Equal(t, "EOF", heredocDelimiter("hello\nworld"))
+	require.Equal(t, "EOF_0", heredocDelimiter("hello\nEOF\nworld"))
+}
@@ -122,6 +122,10 @@ export default defineConfig({
               label: "Running Railpack in Production",
               link: "/guides/running-railpack-in-production",
             },
+            {
+              label: "Custom Providers",
+              link: "/guides/provider-plugins",
+            },
           ],
         },
         {
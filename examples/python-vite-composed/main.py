@@ -0,0 +1,14 @@
+from flask import Flask
+
+# The assets are built with Vite by the composed Node provider
+app = Flask(__name__, static_folder="dist", static_url_path="")
+
+
+@app.route('/')
+def index():
+    return app.send_static_file('index.html')
+
+
+@app.route('/api/hello')
+def hello():
+    return {'message': 'Hello from Flask'}
@@ -0,0 +1,4 @@
+const response = await fetch('/api/hello')
+const { message } = await response.json()
+
+document.querySelector('#app').textContent = message
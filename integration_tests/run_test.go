@@ -135,7 +135,7 @@ func TestExamplesIntegration(t *testing.T) {
 				}
 
 				env := app.NewEnvironment(&testCase.Envs)
-				buildResult := core.GenerateBuildPlan(userApp, env, &core.GenerateBuildPlanOptions{
+				buildResult := core.GenerateBuildPlan(context.Background(), userApp, env, &core.GenerateBuildPlanOptions{
 					ConfigFilePath: testCase.ConfigFilePath,
 				})
 
@@ -164,12 +164,12 @@ func TestExamplesIntegration(t *testing.T) {
 					strings.ToLower(uuid.New().String()))
 
 				if err := buildkit.BuildWithBuildkitClient(examplePath, buildResult.Plan, buildkit.BuildWithBuildkitClientOptions{
-					ImageName:   imageName,
-					Platform:    testCase.Platform,
-					ImportCache: *buildkitCacheImport,
-					ExportCache: *buildkitCacheExport,
-					Secrets:     testCase.Envs,
-					CacheKey:    imageName,
+					ImageName: imageName,
+					Platform:  testCase.Platform,
+					CacheFrom: []string{*buildkitCacheImport},
+					CacheTo:   []string{*buildkitCacheExport},
+					Secrets:   testCase.Envs,
+					CacheKey:  imageName,
 					// Pass through GITHUB_TOKEN if it exists, this avoids mise timeouts during build
 					// this can easily occur since we run all integration tests in parallel via GHA
 					GitHubToken: os.Getenv("GITHUB_TOKEN"),
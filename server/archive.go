@@ -0,0 +1,111 @@
+package server
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+var errArchiveTooLarge = errors.New("the files in the archive are too large")
+
+// readArchive reads a zip, tar, or gzipped tar archive into a file system.
+// Archives are never extracted to disk, so paths and symlinks in the archive cannot point outside of it
+func readArchive(data []byte, maxSize int64) (fs.FS, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")), bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		return zipFS(data, maxSize)
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		gzr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer gzr.Close()
+		return tarFS(gzr, maxSize)
+	default:
+		return tarFS(bytes.NewReader(data), maxSize)
+	}
+}
+
+// zipFS reads a zip archive as a file system.
+// The zip reader fails for files that are larger than the size in their header, so the sizes in the headers are checked
+func zipFS(data []byte, maxSize int64) (fs.FS, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var size uint64
+	for _, file := range zr.File {
+		// Compared before adding so that sizes near the maximum uint64 cannot overflow
+		if file.UncompressedSize64 > uint64(maxSize)-size {
+			return nil, errArchiveTooLarge
+		}
+		size += file.UncompressedSize64
+	}
+
+	return zr, nil
+}
+
+// tarFS reads the directories and regular files of a tar archive into an in-memory zip archive, which is a file system.
+// Symlinks and other special files are skipped
+func tarFS(r io.Reader, maxSize int64) (fs.FS, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	tr := tar.NewReader(r)
+
+	var size int64
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		if name == "." {
+			continue
+		}
+		if !fs.ValidPath(name) {
+			return nil, fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			fh := &zip.FileHeader{Name: name + "/", Modified: header.ModTime}
+			fh.SetMode(fs.ModeDir | fs.FileMode(header.Mode)&fs.ModePerm)
+			if _, err := zw.CreateHeader(fh); err != nil {
+				return nil, err
+			}
+		case tar.TypeReg:
+			size += header.Size
+			if size > maxSize {
+				return nil, errArchiveTooLarge
+			}
+
+			fh := &zip.FileHeader{Name: name, Method: zip.Store, Modified: header.ModTime}
+			fh.SetMode(fs.FileMode(header.Mode) & fs.ModePerm)
+			w, err := zw.CreateHeader(fh)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := io.Copy(w, tr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+}
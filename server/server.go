@@ -0,0 +1,305 @@
+// for platforms: a long-running HTTP API that generates build plans without starting the CLI for every app
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/railwayapp/railpack/core"
+	"github.com/railwayapp/railpack/core/app"
+	"github.com/railwayapp/railpack/core/config"
+	"github.com/railwayapp/railpack/core/providers"
+)
+
+const (
+	DefaultTimeout        = 2 * time.Minute
+	DefaultMaxArchiveSize = 100 << 20
+
+	// Multipart form fields of a plan request with an archive
+	archiveField = "archive"
+	requestField = "request"
+
+	// The name of apps created from an archive
+	archiveAppName = "app"
+)
+
+type Options struct {
+	RailpackVersion string
+
+	// Directories on the server that apps can be planned from by path. Apps can only be uploaded as archives when empty
+	AllowedPaths []string
+
+	// Path to a version index file or directory used to resolve versions without mise
+	VersionIndex string
+
+	// How long a plan can take before the request fails. Defaults to DefaultTimeout
+	Timeout time.Duration
+
+	// The largest archive that can be uploaded, and the largest size of the files in it. Defaults to DefaultMaxArchiveSize
+	MaxArchiveSize int64
+}
+
+// PlanRequest is the JSON body of a plan request. When an archive is uploaded, it is the request field of the form and the path is not used
+type PlanRequest struct {
+	// Directory of the app on the server. Must be in one of the allowed paths
+	Path string `json:"path,omitempty"`
+
+	// Environment variables of the app
+	Env map[string]string `json:"env,omitempty"`
+
+	BuildCommand             string            `json:"buildCommand,omitempty"`
+	StartCommand             string            `json:"startCommand,omitempty"`
+	PreviousVersions         map[string]string `json:"previousVersions,omitempty"`
+	ConfigFile               string            `json:"configFile,omitempty"`
+	ErrorMissingStartCommand bool              `json:"errorMissingStartCommand,omitempty"`
+}
+
+type Server struct {
+	options Options
+	mux     *http.ServeMux
+}
+
+func New(options Options) *Server {
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultTimeout
+	}
+	if options.MaxArchiveSize <= 0 {
+		options.MaxArchiveSize = DefaultMaxArchiveSize
+	}
+
+	s := &Server{options: options, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /healthz", s.handleHealth)
+	s.mux.HandleFunc("GET /schema", s.handleSchema)
+	s.mux.HandleFunc("GET /providers", s.handleProviders)
+	s.mux.HandleFunc("POST /plan", s.handlePlan)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":  "ok",
+		"version": s.options.RailpackVersion,
+	})
+}
+
+func (s *Server) handleSchema(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, config.GetJsonSchema())
+}
+
+func (s *Server) handleProviders(w http.ResponseWriter, r *http.Request) {
+	names := []string{}
+	for _, provider := range providers.GetLanguageProviders() {
+		names = append(names, provider.Name())
+	}
+
+	// Listed in the order they are detected
+	writeJSON(w, http.StatusOK, map[string][]string{"providers": names})
+}
+
+// handlePlan generates the build result of an app, uploaded as an archive or read from a path on the server.
+// Plans that fail are still returned with a 200 status. Only plans that time out have a different status
+func (s *Server) handlePlan(w http.ResponseWriter, r *http.Request) {
+	started := time.Now()
+
+	req, userApp, err := s.readPlanRequest(w, r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.options.Timeout)
+	defer cancel()
+
+	env := app.NewEnvironment(&req.Env)
+	buildResult := core.GenerateBuildPlan(ctx, userApp, env, &core.GenerateBuildPlanOptions{
+		RailpackVersion:          s.options.RailpackVersion,
+		BuildCommand:             req.BuildCommand,
+		StartCommand:             req.StartCommand,
+		PreviousVersions:         req.PreviousVersions,
+		ConfigFilePath:           req.ConfigFile,
+		ErrorMissingStartCommand: req.ErrorMissingStartCommand,
+		VersionIndex:             s.options.VersionIndex,
+
+		// Never nil, so config files cannot extend files outside of the allowed paths
+		ExtendsAllowedPaths: append([]string{}, s.options.AllowedPaths...),
+	})
+
+	status := http.StatusOK
+	if errors.Is(buildResult.Err, context.DeadlineExceeded) {
+		status = http.StatusGatewayTimeout
+	}
+
+	log.Infof("Planned %s in %s (success: %t)", userApp.Source, time.Since(started).Round(time.Millisecond), buildResult.Success)
+	writeJSON(w, status, buildResult)
+}
+
+// readPlanRequest reads a JSON request with the path of the app, or a multipart form with an archive of the app
+func (s *Server) readPlanRequest(w http.ResponseWriter, r *http.Request) (*PlanRequest, *app.App, error) {
+	// The form includes the request JSON, so it is allowed to be a bit larger than the archive
+	r.Body = http.MaxBytesReader(w, r.Body, s.options.MaxArchiveSize+1<<20)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		return s.readPathRequest(r)
+	case "multipart/form-data":
+		return s.readArchiveRequest(r)
+	default:
+		return nil, nil, badRequest("unsupported content type %q. Send application/json with a path or multipart/form-data with an archive", mediaType)
+	}
+}
+
+func (s *Server) readPathRequest(r *http.Request) (*PlanRequest, *app.App, error) {
+	req := &PlanRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return nil, nil, requestError(err, "invalid request: %s", err)
+	}
+
+	if req.Path == "" {
+		return nil, nil, badRequest("path is required")
+	}
+
+	if !s.isAllowedPath(req.Path) {
+		return nil, nil, &httpError{status: http.StatusForbidden, message: fmt.Sprintf("path %s is not allowed", req.Path)}
+	}
+
+	userApp, err := app.NewApp(req.Path)
+	if err != nil {
+		return nil, nil, badRequest("%s", err)
+	}
+
+	return req, userApp, nil
+}
+
+func (s *Server) readArchiveRequest(r *http.Request) (*PlanRequest, *app.App, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, nil, badRequest("invalid form: %s", err)
+	}
+
+	req := &PlanRequest{}
+	var userApp *app.App
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, requestError(err, "invalid form: %s", err)
+		}
+
+		switch part.FormName() {
+		case requestField:
+			if err := json.NewDecoder(part).Decode(req); err != nil {
+				return nil, nil, requestError(err, "invalid request: %s", err)
+			}
+		case archiveField:
+			data, err := io.ReadAll(io.LimitReader(part, s.options.MaxArchiveSize+1))
+			if err != nil {
+				return nil, nil, requestError(err, "failed to read archive: %s", err)
+			}
+			if int64(len(data)) > s.options.MaxArchiveSize {
+				return nil, nil, tooLarge(s.options.MaxArchiveSize)
+			}
+
+			fsys, err := readArchive(data, s.options.MaxArchiveSize)
+			if err != nil {
+				return nil, nil, requestError(err, "invalid archive: %s", err)
+			}
+			userApp = app.NewAppFromFS(fsys, archiveAppName)
+		}
+	}
+
+	if userApp == nil {
+		return nil, nil, badRequest("the %s field is required", archiveField)
+	}
+
+	if req.Path != "" {
+		return nil, nil, badRequest("path cannot be used with an archive")
+	}
+
+	return req, userApp, nil
+}
+
+// isAllowedPath checks if the path is one of the allowed paths or inside of one.
+// Symlinks are resolved first, so a symlink in an allowed path cannot point outside of it
+func (s *Server) isAllowedPath(path string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range s.options.AllowedPaths {
+		if resolved, err := filepath.EvalSymlinks(allowed); err == nil {
+			allowed = resolved
+		}
+
+		rel, err := filepath.Rel(filepath.Clean(allowed), path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string { return e.message }
+
+func badRequest(format string, args ...any) error {
+	return &httpError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+func tooLarge(maxSize int64) error {
+	return &httpError{status: http.StatusRequestEntityTooLarge, message: fmt.Sprintf("the archive is larger than %d bytes", maxSize)}
+}
+
+// requestError is a bad request, unless the body was larger than allowed
+func requestError(err error, format string, args ...any) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) || errors.Is(err, errArchiveTooLarge) {
+		return &httpError{status: http.StatusRequestEntityTooLarge, message: err.Error()}
+	}
+	return badRequest(format, args...)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var httpErr *httpError
+	if errors.As(err, &httpErr) {
+		status = httpErr.status
+	}
+
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Warnf("Failed to write response: %s", err)
+	}
+}
@@ -0,0 +1,247 @@
+package server
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/railwayapp/railpack/core"
+	"github.com/railwayapp/railpack/core/resolver"
+	"github.com/stretchr/testify/require"
+)
+
+var testFiles = map[string]string{
+	"package.json":      `{"name": "app", "engines": {"node": "22"}, "scripts": {"start": "node index.js"}}`,
+	"package-lock.json": `{"lockfileVersion": 3, "packages": {}}`,
+	"index.js":          `console.log("hello")`,
+}
+
+func testServer(t *testing.T, options Options) *httptest.Server {
+	indexPath := filepath.Join(t.TempDir(), resolver.VersionIndexFileName)
+	index := &resolver.VersionIndex{Packages: map[string][]string{
+		"node": {"20.18.1", "22.12.0", "23.5.0"},
+	}}
+	require.NoError(t, index.Write(indexPath))
+
+	options.RailpackVersion = "test"
+	options.VersionIndex = indexPath
+
+	srv := httptest.NewServer(New(options))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func tarGzArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func postArchive(t *testing.T, url string, archive []byte, req *PlanRequest) *http.Response {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if req != nil {
+		w, err := mw.CreateFormField(requestField)
+		require.NoError(t, err)
+		require.NoError(t, json.NewEncoder(w).Encode(req))
+	}
+	w, err := mw.CreateFormFile(archiveField, "app.tar.gz")
+	require.NoError(t, err)
+	_, err = w.Write(archive)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	resp, err := http.Post(url+"/plan", mw.FormDataContentType(), &body)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func postJSON(t *testing.T, url string, req *PlanRequest) *http.Response {
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	resp, err := http.Post(url+"/plan", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func decodeBuildResult(t *testing.T, resp *http.Response) *core.BuildResult {
+	buildResult := &core.BuildResult{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(buildResult))
+	return buildResult
+}
+
+func TestServerEndpoints(t *testing.T) {
+	srv := testServer(t, Options{})
+
+	resp, err := http.Get(srv.URL + "/healthz")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var health map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&health))
+	require.Equal(t, map[string]string{"status": "ok", "version": "test"}, health)
+
+	resp, err = http.Get(srv.URL + "/providers")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var providers map[string][]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&providers))
+	require.Contains(t, providers["providers"], "node")
+	require.Equal(t, "shell", providers["providers"][len(providers["providers"])-1])
+
+	resp, err = http.Get(srv.URL + "/schema")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var schema map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&schema))
+	require.Contains(t, schema, "properties")
+
+	resp, err = http.Get(srv.URL + "/plan")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestServerPlanArchive(t *testing.T) {
+	srv := testServer(t, Options{})
+
+	for name, archive := range map[string][]byte{
+		"tar.gz": tarGzArchive(t, testFiles),
+		"zip":    zipArchive(t, testFiles),
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := postArchive(t, srv.URL, archive, &PlanRequest{
+				Env:          map[string]string{"NODE_ENV": "production"},
+				StartCommand: "node server.js",
+			})
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			buildResult := decodeBuildResult(t, resp)
+			require.True(t, buildResult.Success, buildResult.Logs)
+			require.Equal(t, "test", buildResult.RailpackVersion)
+			require.Equal(t, []string{"node"}, buildResult.DetectedProviders)
+			require.Equal(t, "22.12.0", *buildResult.ResolvedPackages["node"].ResolvedVersion)
+			require.Equal(t, "node server.js", buildResult.Plan.Deploy.StartCmd)
+			require.Contains(t, buildResult.Plan.Secrets, "NODE_ENV")
+		})
+	}
+}
+
+func TestServerPlanPath(t *testing.T) {
+	examples, err := filepath.Abs("../examples")
+	require.NoError(t, err)
+
+	srv := testServer(t, Options{AllowedPaths: []string{examples}})
+
+	resp := postJSON(t, srv.URL, &PlanRequest{Path: filepath.Join(examples, "node-npm")})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	buildResult := decodeBuildResult(t, resp)
+	require.True(t, buildResult.Success, buildResult.Logs)
+
+	resp = postJSON(t, srv.URL, &PlanRequest{Path: filepath.Join(examples, "..", "core")})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = postJSON(t, srv.URL, &PlanRequest{Path: "examples/node-npm"})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// Symlinks in an allowed path cannot point outside of it
+	allowed := t.TempDir()
+	require.NoError(t, os.Symlink(filepath.Join(examples, "node-npm"), filepath.Join(allowed, "app")))
+	srv = testServer(t, Options{AllowedPaths: []string{allowed}})
+	resp = postJSON(t, srv.URL, &PlanRequest{Path: filepath.Join(allowed, "app")})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// Config files cannot extend files outside of the allowed paths, even in the git repository of the app
+	root := t.TempDir()
+	allowed = filepath.Join(root, "allowed")
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(allowed, "app"), 0755))
+	for name, contents := range testFiles {
+		require.NoError(t, os.WriteFile(filepath.Join(allowed, "app", name), []byte(contents), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(allowed, "app", "railpack.json"), []byte(`{"extends": ["../../other/railpack.json"]}`), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "other"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "other", "railpack.json"), []byte(`{}`), 0644))
+	srv = testServer(t, Options{AllowedPaths: []string{allowed}})
+	resp = postJSON(t, srv.URL, &PlanRequest{Path: filepath.Join(allowed, "app")})
+	buildResult = decodeBuildResult(t, resp)
+	require.False(t, buildResult.Success)
+	require.Contains(t, buildResult.Logs[len(buildResult.Logs)-1].Msg, "is outside of the git repository of the app and the allowed paths")
+
+	// Paths are not allowed by default
+	srv = testServer(t, Options{})
+	resp = postJSON(t, srv.URL, &PlanRequest{Path: filepath.Join(examples, "node-npm")})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestServerPlanErrors(t *testing.T) {
+	srv := testServer(t, Options{MaxArchiveSize: 1024})
+
+	resp := postArchive(t, srv.URL, tarGzArchive(t, map[string]string{"../package.json": "{}"}), nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = postArchive(t, srv.URL, tarGzArchive(t, map[string]string{"big.txt": string(make([]byte, 4096))}), nil)
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	resp = postArchive(t, srv.URL, zipArchive(t, map[string]string{"big.txt": string(make([]byte, 4096))}), nil)
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	resp = postArchive(t, srv.URL, zipArchive(t, testFiles), &PlanRequest{Path: "/srv/app"})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err := http.Post(srv.URL+"/plan", "text/plain", bytes.NewReader([]byte("hello")))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Contains(t, body["error"], "unsupported content type")
+}
+
+func TestServerPlanTimeout(t *testing.T) {
+	srv := testServer(t, Options{Timeout: time.Nanosecond})
+
+	resp := postArchive(t, srv.URL, tarGzArchive(t, testFiles), nil)
+	require.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+
+	buildResult := decodeBuildResult(t, resp)
+	require.False(t, buildResult.Success)
+}